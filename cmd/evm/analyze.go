@@ -0,0 +1,80 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/core/asm"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var analyzeCommand = cli.Command{
+	Action:    analyzeCmd,
+	Name:      "analyze",
+	Usage:     "builds the control flow graph of evm binary, reporting functions and issues",
+	ArgsUsage: "<file>",
+}
+
+func analyzeCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("filename required")
+	}
+	in, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(in)), "0x"))
+	if err != nil {
+		return err
+	}
+	cfg := asm.Analyze(code)
+
+	switch {
+	case ctx.GlobalBool(DotFlag.Name):
+		fmt.Print(cfg.DOT())
+	case ctx.GlobalBool(MachineFlag.Name):
+		out, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		var unreachable int
+		for _, block := range cfg.Blocks {
+			if !block.Reachable {
+				unreachable++
+			}
+		}
+		fmt.Printf("%d basic blocks, %d unreachable\n", len(cfg.Blocks), unreachable)
+
+		fmt.Printf("\n%d functions:\n", len(cfg.Functions))
+		for _, fn := range cfg.Functions {
+			fmt.Printf("%v: entry %06v\n", fn.Selector, fn.Entry)
+		}
+		fmt.Printf("\n%d issues:\n", len(cfg.Issues))
+		for _, issue := range cfg.Issues {
+			fmt.Println(issue)
+		}
+	}
+	return nil
+}
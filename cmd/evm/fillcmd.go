@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// customFork is the name the chain configuration loaded from a genesis file is
+// registered with among the state test forks.
+const customFork = "Custom"
+
+var fillCommand = cli.Command{
+	Action:    fillCmd,
+	Name:      "fill",
+	Usage:     "fills the given state test fillers with the post states of each fork",
+	ArgsUsage: "<file>",
+}
+
+var fuzzCommand = cli.Command{
+	Action: fuzzCmd,
+	Name:   "fuzz",
+	Usage:  "executes random state tests, reporting the outcomes diverging across forks",
+}
+
+// stateForks returns the forks requested on the command line, registering the
+// custom chain configuration if one was supplied.
+func stateForks(ctx *cli.Context, defaults []string) ([]string, error) {
+	if file := ctx.GlobalString(ChainConfigFlag.Name); file != "" {
+		genesis := readGenesis(file)
+		if genesis.Config == nil {
+			return nil, fmt.Errorf("no chain configuration in %s", file)
+		}
+		tests.Forks[customFork] = genesis.Config
+	}
+	forks := defaults
+	if list := ctx.GlobalString(ForksFlag.Name); list != "" {
+		forks = strings.Split(list, ",")
+	}
+	for _, fork := range forks {
+		if _, ok := tests.Forks[fork]; !ok {
+			return nil, tests.UnsupportedForkError{Name: fork}
+		}
+	}
+	return forks, nil
+}
+
+func fillCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-filler argument required")
+	}
+	forks, err := stateForks(ctx, tests.FillForks)
+	if err != nil {
+		return err
+	}
+	src, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var fillers map[string]*tests.StateFiller
+	if err = json.Unmarshal(src, &fillers); err != nil {
+		return err
+	}
+	filled := make(map[string]*tests.StateTest, len(fillers))
+	for name, filler := range fillers {
+		test, err := filler.Fill(forks)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		filled[name] = test
+	}
+	out, err := json.MarshalIndent(filled, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func fuzzCmd(ctx *cli.Context) error {
+	forks, err := stateForks(ctx, []string{"EIP158", "Byzantium"})
+	if err != nil {
+		return err
+	}
+	if len(forks) < 2 {
+		return errors.New("at least two forks required to compare")
+	}
+	var (
+		seed   = ctx.GlobalInt64(SeedFlag.Name)
+		outdir = ctx.GlobalString(OutDirFlag.Name)
+		r      = rand.New(rand.NewSource(seed))
+		found  int
+	)
+	if outdir != "" {
+		if err := os.MkdirAll(outdir, 0755); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < ctx.GlobalInt(IterationsFlag.Name); i++ {
+		filler := tests.RandomStateFiller(r)
+
+		divergences, err := filler.DiffForks(forks)
+		if err != nil {
+			return fmt.Errorf("case %d: %v", i, err)
+		}
+		if len(divergences) == 0 {
+			continue
+		}
+		found++
+		for _, d := range divergences {
+			fmt.Printf("case %d: %v\n", i, d)
+		}
+		// Save the diverging case as a filler to reproduce it
+		if outdir != "" {
+			blob, err := json.MarshalIndent(map[string]*tests.StateFiller{fmt.Sprintf("fuzz_%d_%d", seed, i): filler}, "", "  ")
+			if err != nil {
+				return err
+			}
+			file := filepath.Join(outdir, fmt.Sprintf("fuzz_%d_%d_Filler.json", seed, i))
+			if err := ioutil.WriteFile(file, blob, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("%d of %d cases diverged across %s\n", found, ctx.GlobalInt(IterationsFlag.Name), strings.Join(forks, ", "))
+	return nil
+}
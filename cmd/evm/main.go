@@ -114,6 +114,39 @@ var (
 		Name:  "nostack",
 		Usage: "disable stack output",
 	}
+	ProfileFlag = cli.BoolFlag{
+		Name:  "profile",
+		Usage: "output the coverage and gas profile of the executed code (json)",
+	}
+	SourceMapFlag = cli.StringFlag{
+		Name:  "sourcemap",
+		Usage: "File containing the Solidity source map of the code to map the profile onto",
+	}
+	ForksFlag = cli.StringFlag{
+		Name:  "forks",
+		Usage: "Comma separated forks to fill or fuzz state tests with",
+	}
+	ChainConfigFlag = cli.StringFlag{
+		Name:  "chainconfig",
+		Usage: "JSON genesis file whose chain config is made available as the \"Custom\" fork",
+	}
+	IterationsFlag = cli.IntFlag{
+		Name:  "iterations",
+		Usage: "number of random state tests to fuzz",
+		Value: 1000,
+	}
+	SeedFlag = cli.Int64Flag{
+		Name:  "seed",
+		Usage: "seed of the random state test generator",
+	}
+	OutDirFlag = cli.StringFlag{
+		Name:  "outdir",
+		Usage: "directory to save the fillers of diverging fuzzed state tests into",
+	}
+	DotFlag = cli.BoolFlag{
+		Name:  "dot",
+		Usage: "output the control flow graph in Graphviz DOT format",
+	}
 )
 
 func init() {
@@ -138,12 +171,23 @@ func init() {
 		ReceiverFlag,
 		DisableMemoryFlag,
 		DisableStackFlag,
+		ProfileFlag,
+		SourceMapFlag,
+		ForksFlag,
+		ChainConfigFlag,
+		IterationsFlag,
+		SeedFlag,
+		OutDirFlag,
+		DotFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
 		disasmCommand,
+		analyzeCommand,
 		runCommand,
 		stateTestCommand,
+		fillCommand,
+		fuzzCommand,
 	}
 }
 
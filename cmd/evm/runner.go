@@ -34,6 +34,8 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/core/state"
 	"github.com/teamnsrg/ethereum-p2p/core/vm"
 	"github.com/teamnsrg/ethereum-p2p/core/vm/runtime"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/eth/tracers"
 	"github.com/teamnsrg/ethereum-p2p/ethdb"
 	"github.com/teamnsrg/ethereum-p2p/log"
 	"github.com/teamnsrg/ethereum-p2p/params"
@@ -81,16 +83,20 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer      vm.Tracer
 		debugLogger *vm.StructLogger
+		profiler    *tracers.ProfileTracer
 		statedb     *state.StateDB
 		chainConfig *params.ChainConfig
 		sender      = common.StringToAddress("sender")
 		receiver    = common.StringToAddress("receiver")
 	)
 	if ctx.GlobalBool(MachineFlag.Name) {
-		tracer = NewJSONLogger(logconfig, os.Stdout)
+		tracer = vm.NewJSONLogger(logconfig, os.Stdout)
 	} else if ctx.GlobalBool(DebugFlag.Name) {
 		debugLogger = vm.NewStructLogger(logconfig)
 		tracer = debugLogger
+	} else if ctx.GlobalBool(ProfileFlag.Name) {
+		profiler = tracers.NewProfileTracer()
+		tracer = profiler
 	} else {
 		debugLogger = vm.NewStructLogger(logconfig)
 	}
@@ -160,7 +166,7 @@ func runCmd(ctx *cli.Context) error {
 		Value:    utils.GlobalBig(ctx, ValueFlag.Name),
 		EVMConfig: vm.Config{
 			Tracer:             tracer,
-			Debug:              ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
+			Debug:              ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name) || ctx.GlobalBool(ProfileFlag.Name),
 			DisableGasMetering: ctx.GlobalBool(DisableGasMeteringFlag.Name),
 		},
 	}
@@ -182,14 +188,19 @@ func runCmd(ctx *cli.Context) error {
 		runtimeConfig.ChainConfig = chainConfig
 	}
 	tstart := time.Now()
-	var leftOverGas uint64
+	var (
+		leftOverGas uint64
+		executed    []byte
+	)
 	if ctx.GlobalBool(CreateFlag.Name) {
 		input := append(code, common.Hex2Bytes(ctx.GlobalString(InputFlag.Name))...)
+		executed = input
 		ret, _, leftOverGas, err = runtime.Create(input, &runtimeConfig)
 	} else {
 		if len(code) > 0 {
 			statedb.SetCode(receiver, code)
 		}
+		executed = statedb.GetCode(receiver)
 		ret, leftOverGas, err = runtime.Call(receiver, common.Hex2Bytes(ctx.GlobalString(InputFlag.Name)), &runtimeConfig)
 	}
 	execTime := time.Since(tstart)
@@ -233,6 +244,9 @@ Gas used:           %d
 
 `, execTime, mem.HeapObjects, mem.Alloc, mem.TotalAlloc, mem.NumGC, initialGas-leftOverGas)
 	}
+	if profiler != nil {
+		return printProfile(ctx, profiler.Profile(), executed)
+	}
 	if tracer != nil {
 		tracer.CaptureEnd(ret, initialGas-leftOverGas, execTime, err)
 	} else {
@@ -244,3 +258,32 @@ Gas used:           %d
 
 	return nil
 }
+
+// printProfile outputs the execution profile as JSON, mapping the profile of the
+// executed code onto its Solidity source map if one was supplied.
+func printProfile(ctx *cli.Context, profile *tracers.Profile, code []byte) error {
+	output := struct {
+		Profile *tracers.Profile         `json:"profile"`
+		Sources []*tracers.SourceProfile `json:"sources,omitempty"`
+	}{Profile: profile}
+
+	if file := ctx.GlobalString(SourceMapFlag.Name); file != "" {
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		srcmap, err := tracers.ParseSourceMap(string(blob))
+		if err != nil {
+			return err
+		}
+		if prof := profile.Contracts[crypto.Keccak256Hash(code)]; prof != nil {
+			output.Sources = prof.MapSource(code, srcmap)
+		}
+	}
+	out, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
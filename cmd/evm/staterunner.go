@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/teamnsrg/ethereum-p2p/core/state"
 	"github.com/teamnsrg/ethereum-p2p/core/vm"
@@ -66,7 +67,7 @@ func stateTestCmd(ctx *cli.Context) error {
 	)
 	switch {
 	case ctx.GlobalBool(MachineFlag.Name):
-		tracer = NewJSONLogger(config, os.Stderr)
+		tracer = vm.NewJSONLogger(config, os.Stderr)
 
 	case ctx.GlobalBool(DebugFlag.Name):
 		debugger = vm.NewStructLogger(config)
@@ -94,7 +95,8 @@ func stateTestCmd(ctx *cli.Context) error {
 		for _, st := range test.Subtests() {
 			// Run the test and aggregate the result
 			result := &StatetestResult{Name: key, Fork: st.Fork, Pass: true}
-			state, err := test.Run(st, cfg)
+			start := time.Now()
+			state, outcome, err := test.Run(st, cfg)
 			if err != nil {
 				// Test failed, mark as so and dump any state to aid debugging
 				result.Pass, result.Error = false, err.Error()
@@ -103,9 +105,10 @@ func stateTestCmd(ctx *cli.Context) error {
 					result.State = &dump
 				}
 			}
-			// print state root for evmlab tracing (already committed above, so no need to delete objects again
-			if ctx.GlobalBool(MachineFlag.Name) && state != nil {
-				fmt.Fprintf(os.Stderr, "{\"stateRoot\": \"%x\"}\n", state.IntermediateRoot(false))
+			// Print the trace summary and state root for cross-client trace diffing
+			if ctx.GlobalBool(MachineFlag.Name) && outcome != nil {
+				tracer.CaptureEnd(outcome.Output, outcome.GasUsed, time.Since(start), outcome.Err)
+				fmt.Fprintf(os.Stderr, "{\"stateRoot\": \"%#x\"}\n", outcome.Root)
 			}
 
 			results = append(results, *result)
@@ -67,11 +67,16 @@ It expects the genesis file as argument.`,
 			utils.DataDirFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
+			utils.ForceImportFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
-The import command imports blocks from an RLP-encoded form. The form can be one file
-with several RLP-encoded blocks, or several files can be used.
+The import command imports blocks from a chain archive or an RLP-encoded form. The
+form can be one file with several RLP-encoded blocks, or several files can be used.
+
+Chain archives are verified in their entirety (checksums, transaction and receipt
+roots, total difficulties) before any of their blocks are imported. Archives exported
+on a different network are rejected unless --force is given.
 
 If only one file is used, import error will result in failure. If several files are used,
 processing will proceed even if an individual RLP-file import failure occurs.`,
@@ -85,13 +90,22 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 			utils.DataDirFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
+			utils.LegacyExportFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
-last block to write. In this mode, the file will be appended
-if already existing.`,
+last block to write.
+
+By default the blocks are written into a versioned chain archive
+containing the network ID, genesis hash and block range, the
+receipts and total difficulty of every block, periodic checksums
+and an index for random access. Any existing file is replaced.
+
+With --legacy, bare RLP-encoded blocks are written instead. In this
+mode, if a block range is given, the file will be appended if
+already existing.`,
 	}
 	copydbCommand = cli.Command{
 		Action:    utils.MigrateFlags(copyDb),
@@ -178,10 +192,12 @@ func importChain(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
 		utils.Fatalf("This command requires an argument.")
 	}
-	stack := makeFullNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	chain, chainDb := utils.MakeChain(ctx, stack)
 	defer chainDb.Close()
 
+	force := ctx.GlobalBool(utils.ForceImportFlag.Name)
+
 	// Start periodically gathering memory profiles
 	var peakMemAlloc, peakMemSys uint64
 	go func() {
@@ -201,12 +217,12 @@ func importChain(ctx *cli.Context) error {
 	start := time.Now()
 
 	if len(ctx.Args()) == 1 {
-		if err := utils.ImportChain(chain, ctx.Args().First()); err != nil {
+		if err := utils.ImportChain(chain, ctx.Args().First(), cfg.Eth.NetworkId, force); err != nil {
 			utils.Fatalf("Import error: %v", err)
 		}
 	} else {
 		for _, arg := range ctx.Args() {
-			if err := utils.ImportChain(chain, arg); err != nil {
+			if err := utils.ImportChain(chain, arg, cfg.Eth.NetworkId, force); err != nil {
 				log.Error("Import error", "file", arg, "err", err)
 			}
 		}
@@ -259,25 +275,36 @@ func exportChain(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
 		utils.Fatalf("This command requires an argument.")
 	}
-	stack := makeFullNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	chain, _ := utils.MakeChain(ctx, stack)
 	start := time.Now()
 
-	var err error
-	fp := ctx.Args().First()
+	var (
+		err         error
+		first, last uint64
+		fp          = ctx.Args().First()
+	)
 	if len(ctx.Args()) < 3 {
-		err = utils.ExportChain(chain, fp)
+		first, last = 0, chain.CurrentBlock().NumberU64()
 	} else {
 		// This can be improved to allow for numbers larger than 9223372036854775807
-		first, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
-		last, lerr := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
+		ifirst, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+		ilast, lerr := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
 		if ferr != nil || lerr != nil {
 			utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
 		}
-		if first < 0 || last < 0 {
+		if ifirst < 0 || ilast < 0 {
 			utils.Fatalf("Export error: block number must be greater than 0\n")
 		}
-		err = utils.ExportAppendChain(chain, fp, uint64(first), uint64(last))
+		first, last = uint64(ifirst), uint64(ilast)
+	}
+	switch {
+	case !ctx.GlobalBool(utils.LegacyExportFlag.Name):
+		err = utils.ExportChainArchive(chain, fp, cfg.Eth.NetworkId, first, last)
+	case len(ctx.Args()) < 3:
+		err = utils.ExportChain(chain, fp)
+	default:
+		err = utils.ExportAppendChain(chain, fp, first, last)
 	}
 
 	if err != nil {
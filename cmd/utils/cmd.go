@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"bufio"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -80,7 +81,10 @@ func StartNode(stack *node.Node) {
 	}()
 }
 
-func ImportChain(chain *core.BlockChain, fn string) error {
+// ImportChain imports a blockchain from a file, either a chain archive or bare
+// RLP encoded blocks. Chain archives exported on a network other than networkId
+// are rejected unless force is set.
+func ImportChain(chain *core.BlockChain, fn string, networkId uint64, force bool) error {
 	// Watch for Ctrl-C while the import is running.
 	// If a signal is received, the import will stop at the next batch.
 	interrupt := make(chan os.Signal, 1)
@@ -104,19 +108,17 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 	}
 
 	log.Info("Importing blockchain", "file", fn)
-	fh, err := os.Open(fn)
+	fh, err := core.OpenChainFile(fn)
 	if err != nil {
 		return err
 	}
 	defer fh.Close()
 
-	var reader io.Reader = fh
-	if strings.HasSuffix(fn, ".gz") {
-		if reader, err = gzip.NewReader(reader); err != nil {
-			return err
-		}
+	reader := bufio.NewReader(fh)
+	if core.IsChainArchive(reader) {
+		_, err := chain.ImportArchiveFile(fn, networkId, force, importBatchSize, checkInterrupt)
+		return err
 	}
-
 	stream := rlp.NewStream(reader, 0)
 
 	// Run actual the import.
@@ -214,3 +216,14 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	log.Info("Exported blockchain to", "file", fn)
 	return nil
 }
+
+// ExportChainArchive writes a segment of the chain, including receipts and total
+// difficulties, into a verifiable chain archive, replacing any existing file.
+func ExportChainArchive(blockchain *core.BlockChain, fn string, networkId uint64, first uint64, last uint64) error {
+	log.Info("Exporting blockchain archive", "file", fn)
+	if err := blockchain.ExportArchiveFile(fn, networkId, first, last); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain archive", "file", fn)
+	return nil
+}
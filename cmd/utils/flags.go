@@ -41,6 +41,7 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/dashboard"
 	"github.com/teamnsrg/ethereum-p2p/eth"
 	"github.com/teamnsrg/ethereum-p2p/eth/downloader"
+	"github.com/teamnsrg/ethereum-p2p/eth/filters"
 	"github.com/teamnsrg/ethereum-p2p/eth/gasprice"
 	"github.com/teamnsrg/ethereum-p2p/ethdb"
 	"github.com/teamnsrg/ethereum-p2p/ethstats"
@@ -165,9 +166,13 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "checkpoint")`,
 		Value: &defaultSyncMode,
 	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: `Trusted checkpoint for checkpoint sync ("<number>:<hash>:<td>")`,
+	}
 
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
@@ -250,9 +255,18 @@ var (
 	}
 	TxPoolRejournalFlag = cli.DurationFlag{
 		Name:  "txpool.rejournal",
-		Usage: "Time interval to regenerate the local transaction journal",
+		Usage: "Time interval to regenerate the local transaction journal and remote snapshot",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolSnapshotFlag = cli.StringFlag{
+		Name:  "txpool.snapshot",
+		Usage: "Disk snapshot of remote transactions to survive node restarts (empty = disabled)",
+	}
+	TxPoolSnapshotLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.snapshotlimit",
+		Usage: "Maximum number of remote transactions to retain in the disk snapshot",
+		Value: core.DefaultTxPoolConfig.SnapshotLimit,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -288,6 +302,16 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPolicyFlag = cli.StringFlag{
+		Name:  "txpool.policy",
+		Usage: "Transaction admission and eviction policy (" + strings.Join(core.TxPolicies(), ", ") + ")",
+		Value: eth.DefaultConfig.TxPool.Policy,
+	}
+	TxPoolSenderCapFlag = cli.Uint64Flag{
+		Name:  "txpool.sendercap",
+		Usage: "Maximum number of pooled transactions per remote sender (fairness policy)",
+		Value: eth.DefaultConfig.TxPool.SenderCap,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -328,6 +352,10 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerBundlesFlag = cli.BoolFlag{
+		Name:  "minerbundles",
+		Usage: "Accept transaction bundles through the miner API for atomic, ordered inclusion",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -361,6 +389,14 @@ var (
 		Name:  "nocompaction",
 		Usage: "Disables db compaction after import",
 	}
+	LegacyExportFlag = cli.BoolFlag{
+		Name:  "legacy",
+		Usage: "Export bare RLP encoded blocks instead of a verifiable chain archive",
+	}
+	ForceImportFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Import chain archives exported on a different network",
+	}
 	// RPC settings
 	RPCEnabledFlag = cli.BoolFlag{
 		Name:  "rpc",
@@ -483,6 +519,14 @@ var (
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	PropagationTraceFlag = cli.IntFlag{
+		Name:  "proptrace",
+		Usage: "Number of recently seen transactions and blocks to trace the propagation of (0 = disabled)",
+	}
+	PropagationJournalFlag = cli.StringFlag{
+		Name:  "proptrace.journal",
+		Usage: "JSON lines file to persist the propagation records to, reloaded on startup",
+	}
 
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
@@ -502,6 +546,28 @@ var (
 		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
 		Value: eth.DefaultConfig.GPO.Percentile,
 	}
+
+	// Log filtering settings
+	LogsMaxResultsFlag = cli.IntFlag{
+		Name:  "logs.maxresults",
+		Usage: "Maximum number of logs returned by a single log query (0 = unlimited)",
+		Value: eth.DefaultConfig.Filters.MaxResults,
+	}
+	LogsMaxRangeFlag = cli.Uint64Flag{
+		Name:  "logs.maxrange",
+		Usage: "Maximum number of blocks searched by a single log query (0 = unlimited)",
+		Value: eth.DefaultConfig.Filters.MaxRange,
+	}
+	LogsTimeoutFlag = cli.DurationFlag{
+		Name:  "logs.timeout",
+		Usage: "Time budget of a single log query (0 = unlimited)",
+		Value: eth.DefaultConfig.Filters.Timeout,
+	}
+	LogsMaxDurableFlag = cli.IntFlag{
+		Name:  "logs.maxdurable",
+		Usage: "Maximum number of durable log filters, which never expire (0 = disabled)",
+		Value: eth.DefaultConfig.Filters.MaxDurable,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -860,6 +926,21 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	}
 }
 
+func setFilters(ctx *cli.Context, cfg *filters.Config) {
+	if ctx.GlobalIsSet(LogsMaxResultsFlag.Name) {
+		cfg.MaxResults = ctx.GlobalInt(LogsMaxResultsFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogsMaxRangeFlag.Name) {
+		cfg.MaxRange = ctx.GlobalUint64(LogsMaxRangeFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogsTimeoutFlag.Name) {
+		cfg.Timeout = ctx.GlobalDuration(LogsTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogsMaxDurableFlag.Name) {
+		cfg.MaxDurable = ctx.GlobalInt(LogsMaxDurableFlag.Name)
+	}
+}
+
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
@@ -870,6 +951,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.GlobalString(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSnapshotLimitFlag.Name) {
+		cfg.SnapshotLimit = ctx.GlobalUint64(TxPoolSnapshotLimitFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -891,6 +978,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPolicyFlag.Name) {
+		cfg.Policy = ctx.GlobalString(TxPoolPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSenderCapFlag.Name) {
+		cfg.SenderCap = ctx.GlobalUint64(TxPoolSenderCapFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -935,6 +1028,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
+	setFilters(ctx, &cfg.Filters)
 	setTxPool(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 
@@ -946,6 +1040,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	case ctx.GlobalBool(LightModeFlag.Name):
 		cfg.SyncMode = downloader.LightSync
 	}
+	if ctx.GlobalIsSet(CheckpointFlag.Name) {
+		checkpoint, err := params.ParseTrustedCheckpoint(ctx.GlobalString(CheckpointFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", CheckpointFlag.Name, err)
+		}
+		cfg.Checkpoint = checkpoint
+	}
+	if ctx.GlobalIsSet(PropagationTraceFlag.Name) {
+		cfg.PropagationTraceSize = ctx.GlobalInt(PropagationTraceFlag.Name)
+	}
+	if ctx.GlobalIsSet(PropagationJournalFlag.Name) {
+		cfg.PropagationTraceJournal = ctx.GlobalString(PropagationJournalFlag.Name)
+	}
 	if ctx.GlobalIsSet(LightServFlag.Name) {
 		cfg.LightServ = ctx.GlobalInt(LightServFlag.Name)
 	}
@@ -970,6 +1077,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
+	if ctx.GlobalIsSet(MinerBundlesFlag.Name) {
+		cfg.MinerBundles = ctx.GlobalBool(MinerBundlesFlag.Name)
+	}
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
@@ -0,0 +1,519 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+// maxBlockStates is the number of distinct abstract stacks a basic block is
+// explored with, bounding the analysis of loops accumulating stack items.
+const maxBlockStates = 256
+
+// Kinds of the issues reported by the static analysis.
+const (
+	IssueInvalidJump     = "invalid jump"     // Jump to a statically known position not being a JUMPDEST
+	IssueDynamicJump     = "dynamic jump"     // Jump whose destination could not be resolved
+	IssueUnreachableCode = "unreachable code" // Code no execution path leads to
+	IssueIncompletePush  = "incomplete push"  // Push instruction truncated by the end of the code
+)
+
+// Instruction is a disassembled EVM instruction.
+type Instruction struct {
+	PC  uint64    // Position of the instruction in the code
+	Op  vm.OpCode // Opcode of the instruction
+	Arg []byte    // Immediate argument of push instructions
+}
+
+// String implements fmt.Stringer, formatting the instruction as the disassembler.
+func (ins *Instruction) String() string {
+	if len(ins.Arg) > 0 {
+		return fmt.Sprintf("%06v: %v 0x%x", ins.PC, ins.Op, ins.Arg)
+	}
+	return fmt.Sprintf("%06v: %v", ins.PC, ins.Op)
+}
+
+func (ins *Instruction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		PC  uint64        `json:"pc"`
+		Op  string        `json:"op"`
+		Arg hexutil.Bytes `json:"arg,omitempty"`
+	}{ins.PC, ins.Op.String(), ins.Arg})
+}
+
+// BasicBlock is a straight-line sequence of instructions, entered only at its
+// first instruction and left only after its last one.
+type BasicBlock struct {
+	Start        uint64         `json:"start"`        // Position of the first instruction
+	End          uint64         `json:"end"`          // Position following the last instruction
+	Instructions []*Instruction `json:"instructions"` // Instructions of the block
+	Jumps        []uint64       `json:"jumps"`        // Resolved destinations of the closing jump
+	FallsThrough bool           `json:"fallsThrough"` // Whether execution may continue into the next block
+	Preds        []uint64       `json:"predecessors"` // Starts of the blocks continuing into this one
+	Reachable    bool           `json:"reachable"`    // Whether any execution path leads to the block
+	Dynamic      bool           `json:"dynamic"`      // Whether the closing jump could not be resolved
+
+	jumps map[uint64]bool
+}
+
+// Successors returns the starts of the blocks execution may continue with after
+// the block.
+func (b *BasicBlock) Successors() []uint64 {
+	succs := append([]uint64(nil), b.Jumps...)
+	if b.FallsThrough && !b.jumps[b.End] {
+		succs = append(succs, b.End)
+	}
+	return succs
+}
+
+// Function is a public function of a contract, found in its selector dispatcher.
+type Function struct {
+	Selector hexutil.Bytes `json:"selector"` // Four byte signature hash of the function
+	Entry    uint64        `json:"entry"`    // Position the dispatcher jumps to
+}
+
+// Issue is a problem found in the code by the static analysis.
+type Issue struct {
+	PC     uint64 `json:"pc"`     // Position of the offending instruction
+	Kind   string `json:"kind"`   // Kind of the issue (Issue* constants)
+	Detail string `json:"detail"` // Human readable description
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("%06v: %s: %s", i.PC, i.Kind, i.Detail)
+}
+
+// ControlFlowGraph is the result of the static analysis of a contract code: its
+// basic blocks linked by the jumps between them, the public functions of the
+// contract and the issues found.
+type ControlFlowGraph struct {
+	Blocks    []*BasicBlock `json:"blocks"`    // Basic blocks, ordered by position
+	Functions []*Function   `json:"functions"` // Public functions, in dispatcher order
+	Issues    []*Issue      `json:"issues"`    // Issues found, ordered by position
+
+	dests  map[uint64]bool        // Valid jump destinations
+	blocks map[uint64]*BasicBlock // Basic blocks by start position
+	issues map[Issue]bool         // Issues already reported
+}
+
+// absValue is an abstract stack item, either a known constant or unknown.
+type absValue struct {
+	val   uint64
+	known bool
+}
+
+// absState is a basic block to explore with the abstract stack it is entered with.
+type absState struct {
+	block *BasicBlock
+	stack []absValue
+}
+
+// Analyze disassembles the code and builds its control flow graph. Jump targets
+// are resolved by tracking the constants pushed to the stack across blocks, so
+// the return jumps of internal functions are followed as well. Jumps depending
+// on runtime values are marked dynamic and make all JUMPDESTs potential targets.
+func Analyze(code []byte) *ControlFlowGraph {
+	g := &ControlFlowGraph{
+		Functions: []*Function{},
+		Issues:    []*Issue{},
+		dests:     vm.JumpDests(code),
+		blocks:    make(map[uint64]*BasicBlock),
+		issues:    make(map[Issue]bool),
+	}
+	g.split(code)
+	g.explore()
+	g.link()
+	g.findFunctions()
+	g.findUnreachable()
+
+	sort.Sort(issues(g.Issues))
+	return g
+}
+
+// Block returns the basic block starting at the given position, or nil.
+func (g *ControlFlowGraph) Block(pc uint64) *BasicBlock {
+	return g.blocks[pc]
+}
+
+// report records an issue unless reported already.
+func (g *ControlFlowGraph) report(pc uint64, kind, format string, args ...interface{}) {
+	issue := Issue{PC: pc, Kind: kind, Detail: fmt.Sprintf(format, args...)}
+	if !g.issues[issue] {
+		g.issues[issue] = true
+		g.Issues = append(g.Issues, &issue)
+	}
+}
+
+// split disassembles the code into basic blocks, starting new ones at the valid
+// jump destinations and after the jumps and the halting instructions.
+func (g *ControlFlowGraph) split(code []byte) {
+	var block *BasicBlock
+
+	it := NewInstructionIterator(code)
+	for it.Next() {
+		ins := &Instruction{PC: it.PC(), Op: it.Op(), Arg: it.Arg()}
+		if block == nil || (ins.Op == vm.JUMPDEST && g.dests[ins.PC]) {
+			block = &BasicBlock{Start: ins.PC, Jumps: []uint64{}, Preds: []uint64{}, jumps: make(map[uint64]bool)}
+			g.Blocks = append(g.Blocks, block)
+			g.blocks[ins.PC] = block
+		}
+		block.Instructions = append(block.Instructions, ins)
+		block.End = ins.PC + 1 + uint64(len(ins.Arg))
+
+		if ins.Op == vm.JUMP || ins.Op == vm.JUMPI || halts(ins.Op) {
+			block = nil
+		}
+	}
+	if err := it.Error(); err != nil {
+		g.report(it.PC(), IssueIncompletePush, "%v", err)
+	}
+}
+
+// explore executes the basic blocks reachable from the start of the code on an
+// abstract stack, resolving the jump destinations and marking the blocks visited
+// reachable. If any jump is dynamic, the JUMPDESTs not visited are explored too
+// as potential targets.
+func (g *ControlFlowGraph) explore() {
+	if len(g.Blocks) == 0 {
+		return
+	}
+	var (
+		seen    = make(map[uint64]map[string]bool)
+		queue   = []*absState{{block: g.Blocks[0]}}
+		dynamic bool
+	)
+	visit := func(block *BasicBlock, stack []absValue) {
+		states := seen[block.Start]
+		if states == nil {
+			states = make(map[string]bool)
+			seen[block.Start] = states
+		}
+		key := stackKey(stack)
+		if states[key] {
+			return
+		}
+		// Too many distinct entry stacks, give up on resolving precisely
+		if len(states) >= maxBlockStates {
+			dynamic = true
+			return
+		}
+		states[key] = true
+		queue = append(queue, &absState{block: block, stack: append([]absValue(nil), stack...)})
+	}
+	for {
+		for len(queue) > 0 {
+			state := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+
+			if g.execute(state.block, state.stack, visit) {
+				dynamic = true
+			}
+		}
+		if !dynamic {
+			return
+		}
+		dynamic = false
+
+		// Unresolved jumps may target any JUMPDEST not seen yet
+		for _, block := range g.Blocks {
+			if !block.Reachable && g.dests[block.Start] {
+				visit(block, nil)
+			}
+		}
+		if len(queue) == 0 {
+			return
+		}
+	}
+}
+
+// execute runs a basic block on an abstract stack, passing the successor blocks
+// with their entry stacks to visit. It returns whether the closing jump of the
+// block could not be resolved.
+func (g *ControlFlowGraph) execute(block *BasicBlock, stack []absValue, visit func(*BasicBlock, []absValue)) bool {
+	block.Reachable = true
+
+	var dynamic bool
+	for _, ins := range block.Instructions {
+		switch op := ins.Op; {
+		case op.IsPush():
+			stack = append(stack, constant(ins.Arg))
+		case op == vm.PC:
+			stack = append(stack, absValue{val: ins.PC, known: true})
+		case op >= vm.DUP1 && op <= vm.DUP16:
+			stack = append(stack, peek(stack, int(op-vm.DUP1)))
+		case op >= vm.SWAP1 && op <= vm.SWAP16:
+			stack = swap(stack, int(op-vm.SWAP1)+1)
+		case op == vm.JUMP || op == vm.JUMPI:
+			dest := peek(stack, 0)
+			if op == vm.JUMP {
+				stack = pop(stack, 1)
+			} else {
+				stack = pop(stack, 2)
+			}
+			switch next := g.blocks[dest.val]; {
+			case !dest.known:
+				block.Dynamic, dynamic = true, true
+				g.report(ins.PC, IssueDynamicJump, "destination not statically known")
+			case next == nil || !g.dests[dest.val]:
+				g.report(ins.PC, IssueInvalidJump, "destination %d is not a JUMPDEST", dest.val)
+			default:
+				block.jumps[dest.val] = true
+				visit(next, stack)
+			}
+		default:
+			pops, pushes, _ := stackEffect(op)
+			stack = pop(stack, pops)
+			for i := 0; i < pushes; i++ {
+				stack = append(stack, absValue{})
+			}
+		}
+	}
+	if last := block.Instructions[len(block.Instructions)-1].Op; last != vm.JUMP && !halts(last) {
+		if next := g.blocks[block.End]; next != nil {
+			block.FallsThrough = true
+			visit(next, stack)
+		}
+	}
+	return dynamic
+}
+
+// link fills in the resolved jump destinations and the predecessors of the blocks.
+func (g *ControlFlowGraph) link() {
+	for _, block := range g.Blocks {
+		for dest := range block.jumps {
+			block.Jumps = append(block.Jumps, dest)
+		}
+		sort.Sort(positions(block.Jumps))
+	}
+	for _, block := range g.Blocks {
+		for _, succ := range block.Successors() {
+			next := g.blocks[succ]
+			next.Preds = append(next.Preds, block.Start)
+		}
+	}
+}
+
+// findFunctions looks for the function selector comparisons of the dispatcher,
+// emitted by the compilers as the instruction sequence
+//
+//	PUSH4 <selector> DUPn EQ PUSH <entry> JUMPI
+//
+// with the PUSH4 and DUPn possibly swapped.
+func (g *ControlFlowGraph) findFunctions() {
+	seen := make(map[string]bool)
+	for _, block := range g.Blocks {
+		n := len(block.Instructions)
+		if !block.Reachable || n < 5 || block.Instructions[n-1].Op != vm.JUMPI {
+			continue
+		}
+		entry, eq := block.Instructions[n-2], block.Instructions[n-3]
+		if !entry.Op.IsPush() || eq.Op != vm.EQ {
+			continue
+		}
+		var selector []byte
+		for _, ins := range block.Instructions[n-5 : n-3] {
+			if ins.Op == vm.PUSH4 {
+				selector = ins.Arg
+			}
+		}
+		dest := constant(entry.Arg)
+		if selector == nil || !dest.known || !block.jumps[dest.val] || seen[string(selector)] {
+			continue
+		}
+		seen[string(selector)] = true
+		g.Functions = append(g.Functions, &Function{Selector: selector, Entry: dest.val})
+	}
+}
+
+// findUnreachable reports the runs of consecutive unreachable blocks.
+func (g *ControlFlowGraph) findUnreachable() {
+	for i := 0; i < len(g.Blocks); i++ {
+		if g.Blocks[i].Reachable {
+			continue
+		}
+		start := g.Blocks[i].Start
+		for i+1 < len(g.Blocks) && !g.Blocks[i+1].Reachable {
+			i++
+		}
+		g.report(start, IssueUnreachableCode, "%d bytes until %d", g.Blocks[i].End-start, g.Blocks[i].End)
+	}
+}
+
+// DOT renders the control flow graph in the Graphviz DOT language. Unreachable
+// blocks are greyed out, blocks ending with dynamic jumps outlined in red and the
+// entries of the public functions labeled with their selectors.
+func (g *ControlFlowGraph) DOT() string {
+	entries := make(map[uint64][]hexutil.Bytes)
+	for _, fn := range g.Functions {
+		entries[fn.Entry] = append(entries[fn.Entry], fn.Selector)
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString("digraph cfg {\n\tnode [shape=box fontname=\"Courier\"];\n")
+	for _, block := range g.Blocks {
+		label := new(bytes.Buffer)
+		for _, selector := range entries[block.Start] {
+			fmt.Fprintf(label, "function %v\\l", selector)
+		}
+		for _, ins := range block.Instructions {
+			fmt.Fprintf(label, "%v\\l", ins)
+		}
+		attrs := ""
+		if !block.Reachable {
+			attrs += " style=filled fillcolor=lightgrey"
+		}
+		if block.Dynamic {
+			attrs += " color=red"
+		}
+		fmt.Fprintf(buf, "\tb%d [label=\"%s\"%s];\n", block.Start, label, attrs)
+		for _, dest := range block.Jumps {
+			fmt.Fprintf(buf, "\tb%d -> b%d;\n", block.Start, dest)
+		}
+		if block.FallsThrough {
+			fmt.Fprintf(buf, "\tb%d -> b%d [style=dashed];\n", block.Start, block.End)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// constant returns the abstract value of a push argument, known if it fits into
+// a program counter.
+func constant(arg []byte) absValue {
+	val := new(big.Int).SetBytes(arg)
+	if val.BitLen() > 64 {
+		return absValue{}
+	}
+	return absValue{val: val.Uint64(), known: true}
+}
+
+// peek returns the n-th item from the top of the abstract stack, unknown if the
+// stack is not deep enough.
+func peek(stack []absValue, n int) absValue {
+	if n >= len(stack) {
+		return absValue{}
+	}
+	return stack[len(stack)-1-n]
+}
+
+// pop removes n items from the top of the abstract stack.
+func pop(stack []absValue, n int) []absValue {
+	if n >= len(stack) {
+		return stack[:0]
+	}
+	return stack[:len(stack)-n]
+}
+
+// swap exchanges the top item of the abstract stack with the n-th one below it,
+// padding the stack with unknown items if not deep enough.
+func swap(stack []absValue, n int) []absValue {
+	if n >= len(stack) {
+		stack = append(make([]absValue, n+1-len(stack)), stack...)
+	}
+	top := len(stack) - 1
+	stack[top], stack[top-n] = stack[top-n], stack[top]
+	return stack
+}
+
+// stackKey encodes an abstract stack to tell apart the states blocks are entered with.
+func stackKey(stack []absValue) string {
+	key := make([]byte, 0, 9*len(stack))
+	for _, item := range stack {
+		if !item.known {
+			key = append(key, 0)
+			continue
+		}
+		var val [8]byte
+		binary.BigEndian.PutUint64(val[:], item.val)
+		key = append(append(key, 1), val[:]...)
+	}
+	return string(key)
+}
+
+// halts returns whether the opcode ends the execution, undefined ones included.
+func halts(op vm.OpCode) bool {
+	if _, _, ok := stackEffect(op); !ok {
+		return true
+	}
+	return op == vm.STOP || op == vm.RETURN || op == vm.REVERT || op == vm.SELFDESTRUCT
+}
+
+// stackEffect returns the number of stack items consumed and produced by the
+// opcode as of Byzantium, or false if the opcode is not defined.
+func stackEffect(op vm.OpCode) (pops int, pushes int, ok bool) {
+	switch {
+	case op.IsPush():
+		return 0, 1, true
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 1, int(op-vm.DUP1) + 2, true
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2, int(op-vm.SWAP1) + 2, true
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return int(op-vm.LOG0) + 2, 0, true
+	}
+	switch op {
+	case vm.STOP, vm.JUMPDEST:
+		return 0, 0, true
+	case vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE, vm.GASPRICE,
+		vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.DIFFICULTY, vm.GASLIMIT, vm.PC, vm.MSIZE, vm.GAS:
+		return 0, 1, true
+	case vm.ISZERO, vm.NOT, vm.BALANCE, vm.CALLDATALOAD, vm.EXTCODESIZE, vm.BLOCKHASH, vm.MLOAD, vm.SLOAD:
+		return 1, 1, true
+	case vm.POP, vm.JUMP, vm.SELFDESTRUCT:
+		return 1, 0, true
+	case vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.EXP, vm.SIGNEXTEND,
+		vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR, vm.XOR, vm.BYTE, vm.SHA3:
+		return 2, 1, true
+	case vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.JUMPI, vm.RETURN, vm.REVERT:
+		return 2, 0, true
+	case vm.ADDMOD, vm.MULMOD, vm.CREATE:
+		return 3, 1, true
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		return 3, 0, true
+	case vm.EXTCODECOPY:
+		return 4, 0, true
+	case vm.DELEGATECALL, vm.STATICCALL:
+		return 6, 1, true
+	case vm.CALL, vm.CALLCODE:
+		return 7, 1, true
+	}
+	return 0, 0, false
+}
+
+type positions []uint64
+
+func (p positions) Len() int           { return len(p) }
+func (p positions) Less(i, j int) bool { return p[i] < p[j] }
+func (p positions) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type issues []*Issue
+
+func (s issues) Len() int      { return len(s) }
+func (s issues) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s issues) Less(i, j int) bool {
+	if s[i].PC != s[j].PC {
+		return s[i].PC < s[j].PC
+	}
+	return s[i].Kind < s[j].Kind
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dispatcherCode is a contract dispatching the selector 0xaabbccdd to a function
+// calling an internal one, returning to a jump into push data. The code after
+// the halting instructions is unreachable.
+//
+//	00: PUSH1 0 CALLDATALOAD DUP1 PUSH4 0xaabbccdd EQ PUSH1 0x12 JUMPI
+//	0d: PUSH1 0 DUP1 REVERT
+//	11: STOP
+//	12: JUMPDEST PUSH1 0x1c PUSH1 0x19 JUMP
+//	18: STOP
+//	19: JUMPDEST JUMP
+//	1b: STOP
+//	1c: JUMPDEST PUSH1 0x5b PUSH1 0x1e JUMP
+const dispatcherCode = "60003580" + "63aabbccdd" + "14601257" + "600080fd" + "00" + "5b601c601956" + "00" + "5b56" + "00" + "5b605b601e56"
+
+// Tests that the control flow graph of a dispatcher is built, with the return
+// jump of the internal function resolved.
+func TestAnalyze(t *testing.T) {
+	code, _ := hex.DecodeString(dispatcherCode)
+	cfg := Analyze(code)
+
+	var starts []uint64
+	for _, block := range cfg.Blocks {
+		starts = append(starts, block.Start)
+	}
+	if want := []uint64{0x00, 0x0d, 0x11, 0x12, 0x18, 0x19, 0x1b, 0x1c}; !reflect.DeepEqual(starts, want) {
+		t.Fatalf("block mismatch: have %v, want %v", starts, want)
+	}
+	// Check the edges and the reachability of the blocks
+	tests := []struct {
+		start     uint64
+		succs     []uint64
+		preds     []uint64
+		reachable bool
+	}{
+		{0x00, []uint64{0x12, 0x0d}, []uint64{}, true},
+		{0x0d, nil, []uint64{0x00}, true},
+		{0x11, nil, []uint64{}, false},
+		{0x12, []uint64{0x19}, []uint64{0x00}, true},
+		{0x18, nil, []uint64{}, false},
+		{0x19, []uint64{0x1c}, []uint64{0x12}, true},
+		{0x1b, nil, []uint64{}, false},
+		{0x1c, nil, []uint64{0x19}, true},
+	}
+	for _, test := range tests {
+		block := cfg.Block(test.start)
+		if succs := block.Successors(); !reflect.DeepEqual(succs, test.succs) {
+			t.Errorf("block %d: successor mismatch: have %v, want %v", test.start, succs, test.succs)
+		}
+		if !reflect.DeepEqual(block.Preds, test.preds) {
+			t.Errorf("block %d: predecessor mismatch: have %v, want %v", test.start, block.Preds, test.preds)
+		}
+		if block.Reachable != test.reachable {
+			t.Errorf("block %d: reachability mismatch: have %v, want %v", test.start, block.Reachable, test.reachable)
+		}
+		if block.Dynamic {
+			t.Errorf("block %d: unexpected dynamic jump", test.start)
+		}
+	}
+	// Check the functions and issues found
+	if len(cfg.Functions) != 1 || cfg.Functions[0].Selector.String() != "0xaabbccdd" || cfg.Functions[0].Entry != 0x12 {
+		t.Errorf("function mismatch: have %v", cfg.Functions)
+	}
+	var issues []string
+	for _, issue := range cfg.Issues {
+		issues = append(issues, issue.String())
+	}
+	want := []string{
+		"000017: unreachable code: 1 bytes until 18",
+		"000024: unreachable code: 1 bytes until 25",
+		"000027: unreachable code: 1 bytes until 28",
+		"000033: invalid jump: destination 30 is not a JUMPDEST",
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issue mismatch:\nhave %q\nwant %q", issues, want)
+	}
+	// Spot check the rendered graph
+	dot := cfg.DOT()
+	for _, want := range []string{
+		"b0 -> b18;",
+		"b0 -> b13 [style=dashed];",
+		"b25 -> b28;",
+		"function 0xaabbccdd\\l000018: JUMPDEST\\l",
+		"b17 [label=\"000017: STOP\\l\" style=filled fillcolor=lightgrey];",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+// Tests that jumps to runtime values are reported, making all JUMPDESTs possible
+// destinations, and that truncated code is reported.
+func TestAnalyzeDynamic(t *testing.T) {
+	// PUSH1 0 CALLDATALOAD JUMP STOP JUMPDEST STOP PUSH2 0x01
+	code, _ := hex.DecodeString("6000355600" + "5b00" + "6101")
+	cfg := Analyze(code)
+
+	if block := cfg.Block(0); !block.Dynamic || len(block.Successors()) != 0 {
+		t.Errorf("jump to calldata not dynamic")
+	}
+	if block := cfg.Block(4); block.Reachable {
+		t.Errorf("code after dynamic jump reachable")
+	}
+	if block := cfg.Block(5); !block.Reachable {
+		t.Errorf("JUMPDEST not reachable via dynamic jump")
+	}
+	var issues []string
+	for _, issue := range cfg.Issues {
+		issues = append(issues, issue.String())
+	}
+	want := []string{
+		"000003: dynamic jump: destination not statically known",
+		"000004: unreachable code: 1 bytes until 5",
+		"000007: incomplete push: incomplete push instruction at 7",
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issue mismatch:\nhave %q\nwant %q", issues, want)
+	}
+}
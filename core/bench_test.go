@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/common/math"
@@ -31,6 +32,7 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/crypto"
 	"github.com/teamnsrg/ethereum-p2p/ethdb"
 	"github.com/teamnsrg/ethereum-p2p/params"
+	"github.com/teamnsrg/ethereum-p2p/rlp"
 )
 
 func BenchmarkInsertChain_empty_memdb(b *testing.B) {
@@ -178,9 +180,76 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	defer chainman.Stop()
 	b.ReportAllocs()
 	b.ResetTimer()
+	start := time.Now()
 	if i, err := chainman.InsertChain(chain); err != nil {
 		b.Fatalf("insert error (block %d): %v\n", i, err)
 	}
+	reportImportThroughput(b, chain, time.Since(start))
+}
+
+// reportImportThroughput reports the block, transaction and gas throughput of
+// a chain import as custom benchmark metrics.
+func reportImportThroughput(b *testing.B, chain types.Blocks, elapsed time.Duration) {
+	var gas uint64
+	for _, block := range chain {
+		gas += block.GasUsed().Uint64()
+	}
+	secs := elapsed.Seconds()
+	if secs == 0 {
+		return
+	}
+	b.ReportMetric(float64(len(chain))/secs, "blocks/s")
+	b.ReportMetric(float64(countTransactions(chain))/secs, "txs/s")
+	b.ReportMetric(float64(gas)/1000000/secs, "mgas/s")
+}
+
+func BenchmarkSenderRecovery_serial_ring1000(b *testing.B) {
+	benchSenderRecovery(b, false)
+}
+func BenchmarkSenderRecovery_parallel_ring1000(b *testing.B) {
+	benchSenderRecovery(b, true)
+}
+
+// benchSenderRecovery measures the time needed to recover the senders of all
+// transactions in a batch of blocks, either one after the other on a single
+// thread or through the concurrent sender cacher.
+func benchSenderRecovery(b *testing.B, parallel bool) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+	}
+	genesis := gspec.MustCommit(db)
+	chain, _ := GenerateChain(gspec.Config, genesis, db, 4, genTxRing(1000))
+	signer := types.MakeSigner(gspec.Config, chain[0].Number())
+	blob, err := rlp.EncodeToBytes(chain)
+	if err != nil {
+		b.Fatalf("failed to encode blocks: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		// Re-decode the blocks to drop any cached senders
+		var blocks types.Blocks
+		if err := rlp.DecodeBytes(blob, &blocks); err != nil {
+			b.Fatalf("failed to decode blocks: %v", err)
+		}
+		b.StartTimer()
+
+		// Wait for the background recoveries to finish, so the loop below only
+		// verifies the cached senders instead of racing the cacher
+		if parallel {
+			senderCacher.recoverFromBlocks(signer, blocks).Wait()
+		}
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				if _, err := types.Sender(signer, tx); err != nil {
+					b.Fatalf("failed to recover sender: %v", err)
+				}
+			}
+		}
+	}
 }
 
 func BenchmarkChainRead_header_10k(b *testing.B) {
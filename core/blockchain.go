@@ -45,7 +45,9 @@ import (
 )
 
 var (
-	blockInsertTimer = metrics.NewTimer("chain/inserts")
+	blockInsertTimer            = metrics.NewTimer("chain/inserts")
+	blockPrefetchTimer          = metrics.NewTimer("chain/prefetch/executes")
+	blockPrefetchInterruptMeter = metrics.NewMeter("chain/prefetch/interrupts")
 
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 )
@@ -85,6 +87,7 @@ type BlockChain struct {
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -108,18 +111,23 @@ type BlockChain struct {
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	engine    consensus.Engine
-	processor Processor // block processor interface
-	validator Validator // block and state validator interface
-	vmConfig  vm.Config
+	engine     consensus.Engine
+	processor  Processor  // block processor interface
+	prefetcher Prefetcher // block state prefetcher interface
+	validator  Validator  // block and state validator interface
+	vmConfig   vm.Config
 
-	badBlocks *lru.Cache // Bad block cache
+	badBlocks *lru.Cache   // Bad block cache
+	reorgs    reorgHistory // Recent chain reorganisations
 }
 
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
 func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
+	if err := vm.CheckPrecompiles(config); err != nil {
+		return nil, err
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -141,6 +149,7 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine co
 	}
 	bc.SetValidator(NewBlockValidator(config, bc, engine))
 	bc.SetProcessor(NewStateProcessor(config, bc, engine))
+	bc.prefetcher = newStatePrefetcher(config, bc, engine)
 
 	var err error
 	bc.hc, err = NewHeaderChain(chainDb, config, engine, bc.getProcInterrupt)
@@ -826,7 +835,7 @@ func (bc *BlockChain) WriteBlockAndState(block *types.Block, receipts []*types.R
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != bc.currentBlock.Hash() {
-			if err := bc.reorg(bc.currentBlock, block); err != nil {
+			if err := bc.reorg(bc.currentBlock, block, receipts); err != nil {
 				return NonStatTy, err
 			}
 		}
@@ -870,6 +879,10 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 // only reason this method exists as a separate one is to make locking cleaner
 // with deferred statements.
 func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*types.Log, error) {
+	// If the chain is empty, there's nothing to do
+	if len(chain) == 0 {
+		return 0, nil, nil, nil
+	}
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 1; i < len(chain); i++ {
 		if chain[i].NumberU64() != chain[i-1].NumberU64()+1 || chain[i].ParentHash() != chain[i-1].Hash() {
@@ -908,6 +921,10 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 	abort, results := bc.engine.VerifyHeaders(bc, headers, seals)
 	defer close(abort)
 
+	// Start recovering the transaction senders of the whole batch in parallel,
+	// so that signatures are already cached by the time blocks are executed
+	senderCacher.recoverFromBlocks(types.MakeSigner(bc.config, chain[0].Number()), chain)
+
 	// Iterate over the blocks and insert when the verifier permits
 	for i, block := range chain {
 		// If the chain is terminating, stop processing blocks
@@ -955,20 +972,41 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			bc.reportBlock(block, nil, err)
 			return i, events, coalescedLogs, err
 		}
-		// Create a new statedb using the parent block and report an
-		// error if it fails.
+		// Retrieve the parent block to use as the reference point for execution
 		var parent *types.Block
 		if i == 0 {
 			parent = bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
 		} else {
 			parent = chain[i-1]
 		}
+		// If we have a followup block, speculatively run it against the current
+		// parent state on a throwaway copy to warm up the trie caches.
+		var followupInterrupt uint32
+		if i+1 < len(chain) {
+			if throwaway, err := state.New(parent.Root(), bc.stateCache); err == nil {
+				bc.wg.Add(1)
+				go func(followup *types.Block, throwaway *state.StateDB) {
+					defer bc.wg.Done()
+
+					start := time.Now()
+					bc.prefetcher.Prefetch(followup, throwaway, vm.Config{}, &followupInterrupt)
+					blockPrefetchTimer.UpdateSince(start)
+					if atomic.LoadUint32(&followupInterrupt) == 1 {
+						blockPrefetchInterruptMeter.Mark(1)
+					}
+				}(chain[i+1], throwaway)
+			}
+		}
+		// Create a new statedb using the parent block and report an
+		// error if it fails.
 		state, err := state.New(parent.Root(), bc.stateCache)
 		if err != nil {
+			atomic.StoreUint32(&followupInterrupt, 1)
 			return i, events, coalescedLogs, err
 		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
+		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			return i, events, coalescedLogs, err
@@ -1064,27 +1102,31 @@ func countTransactions(chain []*types.Block) (c int) {
 
 // reorgs takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
 // to be part of the new canonical chain and accumulates potential missing transactions and post an
-// event about them
-func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
+// event about them. The receipts of the new head block are passed in as they are not yet in the database.
+func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block, newReceipts types.Receipts) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
 		commonBlock *types.Block
 		deletedTxs  types.Transactions
 		deletedLogs []*types.Log
+		blockLogs   [][]*types.Log // deleted logs grouped by the blocks of oldChain
 		// collectLogs collects the logs that were generated during the
 		// processing of the block that corresponds with the given hash.
 		// These logs are later announced as deleted.
 		collectLogs = func(h common.Hash) {
 			// Coalesce logs and set 'Removed'.
+			var logs []*types.Log
 			receipts := GetBlockReceipts(bc.chainDb, h, bc.hc.GetBlockNumber(h))
 			for _, receipt := range receipts {
 				for _, log := range receipt.Logs {
 					del := *log
 					del.Removed = true
-					deletedLogs = append(deletedLogs, &del)
+					logs = append(logs, &del)
 				}
 			}
+			deletedLogs = append(deletedLogs, logs...)
+			blockLogs = append(blockLogs, logs)
 		}
 	)
 
@@ -1140,7 +1182,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	} else {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.Number(), "oldhash", oldBlock.Hash(), "newnum", newBlock.Number(), "newhash", newBlock.Hash())
 	}
-	var addedTxs types.Transactions
+	var (
+		addedTxs  types.Transactions
+		addedLogs []*types.Log
+	)
 	// insert blocks. Order does not matter. Last block will be written in ImportChain itself which creates the new head properly
 	for _, block := range newChain {
 		// insert the block in the canonical way, re-writing history
@@ -1159,6 +1204,16 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	for _, tx := range diff {
 		DeleteTxLookupEntry(bc.chainDb, tx.Hash())
 	}
+	// collect the logs of the new chain, the head's receipts are not yet persisted
+	for i := len(newChain) - 1; i >= 0; i-- {
+		receipts := newReceipts
+		if i > 0 {
+			receipts = GetBlockReceipts(bc.chainDb, newChain[i].Hash(), newChain[i].NumberU64())
+		}
+		for _, receipt := range receipts {
+			addedLogs = append(addedLogs, receipt.Logs...)
+		}
+	}
 	if len(deletedLogs) > 0 {
 		go bc.rmLogsFeed.Send(RemovedLogsEvent{deletedLogs})
 	}
@@ -1169,6 +1224,24 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			}
 		}()
 	}
+	// assemble the details of the whole reorg, ordering everything by block number
+	ev := ReorgEvent{
+		CommonBlock: commonBlock,
+		OldChain:    make(types.Blocks, 0, len(oldChain)),
+		NewChain:    make(types.Blocks, 0, len(newChain)),
+		DroppedTxs:  diff,
+		AddedTxs:    types.TxDifference(addedTxs, deletedTxs),
+		AddedLogs:   addedLogs,
+	}
+	for i := len(oldChain) - 1; i >= 0; i-- {
+		ev.OldChain = append(ev.OldChain, oldChain[i])
+		ev.DroppedLogs = append(ev.DroppedLogs, blockLogs[i]...)
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		ev.NewChain = append(ev.NewChain, newChain[i])
+	}
+	bc.reorgs.add(newReorgRecord(&ev))
+	go bc.reorgFeed.Send(ev)
 
 	return nil
 }
@@ -1283,6 +1356,26 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (i
 	return bc.hc.InsertHeaderChain(chain, whFunc, start)
 }
 
+// InsertCheckpointHeader injects a trusted checkpoint header and its total
+// difficulty as the new head header of the chain, allowing subsequent headers
+// to be imported on top of it without the preceding history being present.
+func (bc *BlockChain) InsertCheckpointHeader(header *types.Header, td *big.Int) error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if number := header.Number.Uint64(); number <= bc.hc.CurrentHeader().Number.Uint64() {
+		return fmt.Errorf("checkpoint #%d not above head header #%d", number, bc.hc.CurrentHeader().Number)
+	}
+	log.Info("Injected trusted checkpoint header", "number", header.Number, "hash", header.Hash(), "td", td)
+	return bc.hc.WriteCheckpointHeader(header, td)
+}
+
 // writeHeader writes a header into the local chain, given that its parent is
 // already known. If the total difficulty of the newly inserted header becomes
 // greater than the current known TD, the canonical chain is re-routed.
@@ -1380,6 +1473,11 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
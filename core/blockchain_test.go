@@ -904,6 +904,86 @@ func TestLogReorgs(t *testing.T) {
 	}
 }
 
+// Tests that a reorg is announced as a single event containing all the dropped
+// and added blocks, transactions and logs, and that it's recorded in the history.
+func TestReorgEvent(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db, _   = ethdb.NewMemDatabase()
+		// this code generates a log
+		code    = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+
+	blockchain, _ := NewBlockChain(db, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	reorgCh := make(chan ReorgEvent, 1)
+	blockchain.SubscribeReorgEvent(reorgCh)
+
+	// Create a chain with a log in its head, then a heavier fork with another one
+	makeFork := func(offset int64, price int64) types.Blocks {
+		chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 2, func(i int, gen *BlockGen) {
+			gen.OffsetTime(offset)
+			if i == 1 {
+				tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), big.NewInt(price), code), signer, key1)
+				if err != nil {
+					t.Fatalf("failed to create tx: %v", err)
+				}
+				gen.AddTx(tx)
+			}
+		})
+		return chain
+	}
+	oldChain, newChain := makeFork(0, 0), makeFork(-9, 1)
+
+	if _, err := blockchain.InsertChain(oldChain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, err := blockchain.InsertChain(newChain); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	var ev ReorgEvent
+	select {
+	case ev = <-reorgCh:
+	case <-time.After(time.Second):
+		t.Fatal("no reorg event received")
+	}
+	if ev.CommonBlock.Hash() != genesis.Hash() {
+		t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonBlock.Hash(), genesis.Hash())
+	}
+	for i := range oldChain {
+		if ev.OldChain[i].Hash() != oldChain[i].Hash() {
+			t.Errorf("dropped block %d mismatch: have %x, want %x", i, ev.OldChain[i].Hash(), oldChain[i].Hash())
+		}
+		if ev.NewChain[i].Hash() != newChain[i].Hash() {
+			t.Errorf("added block %d mismatch: have %x, want %x", i, ev.NewChain[i].Hash(), newChain[i].Hash())
+		}
+	}
+	if len(ev.DroppedTxs) != 1 || ev.DroppedTxs[0].Hash() != oldChain[1].Transactions()[0].Hash() {
+		t.Errorf("dropped transactions mismatch: %v", ev.DroppedTxs)
+	}
+	if len(ev.AddedTxs) != 1 || ev.AddedTxs[0].Hash() != newChain[1].Transactions()[0].Hash() {
+		t.Errorf("added transactions mismatch: %v", ev.AddedTxs)
+	}
+	if len(ev.DroppedLogs) != 1 || !ev.DroppedLogs[0].Removed || ev.DroppedLogs[0].BlockHash != oldChain[1].Hash() {
+		t.Errorf("dropped logs mismatch: %v", ev.DroppedLogs)
+	}
+	if len(ev.AddedLogs) != 1 || ev.AddedLogs[0].Removed || ev.AddedLogs[0].BlockHash != newChain[1].Hash() {
+		t.Errorf("added logs mismatch: %v", ev.AddedLogs)
+	}
+	history := blockchain.ReorgHistory()
+	if len(history) != 1 {
+		t.Fatalf("reorg history length mismatch: have %d, want 1", len(history))
+	}
+	if record := history[0]; record.CommonHash != genesis.Hash() || len(record.Dropped) != 2 || len(record.Added) != 2 || record.DroppedLogs != 1 || record.AddedLogs != 1 {
+		t.Errorf("reorg record mismatch: %+v", record)
+	}
+}
+
 func TestReorgSideEvent(t *testing.T) {
 	var (
 		db, _   = ethdb.NewMemDatabase()
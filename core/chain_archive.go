@@ -0,0 +1,732 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/crypto/sha3"
+	"github.com/teamnsrg/ethereum-p2p/log"
+	"github.com/teamnsrg/ethereum-p2p/rlp"
+)
+
+// A chain archive is a self-describing export of a contiguous segment of the
+// canonical chain. Its layout is:
+//
+//   magic | header | (block entry* checksum entry)* | index entry | footer
+//
+// The header identifies the network, genesis and block range contained in the
+// archive. Every block entry carries the block itself, its receipts and its total
+// difficulty. After every ChecksumInterval blocks (and after the last block) a
+// checksum entry seals the keccak256 hash of all block entries since the previous
+// checksum. The index entry lists the byte offset of every checksummed segment,
+// and the fixed size footer points at the index, allowing random access to any
+// block of an uncompressed archive.
+
+const (
+	// ChainArchiveVersion is the version of the archive format produced by the
+	// ChainArchiveWriter. Readers refuse archives of other versions.
+	ChainArchiveVersion = 1
+
+	// chainArchiveChecksumInterval is the default number of blocks sealed by a
+	// single checksum entry.
+	chainArchiveChecksumInterval = 1024
+
+	// chainArchiveFooterSize is the size of the trailing footer: the big endian
+	// offset of the index entry followed by the archive magic.
+	chainArchiveFooterSize = 8 + 8
+)
+
+// Entry kinds contained in a chain archive.
+const (
+	archiveBlockEntry uint8 = iota
+	archiveChecksumEntry
+	archiveIndexEntry
+)
+
+var (
+	// chainArchiveMagic is the preamble every chain archive starts and ends with.
+	chainArchiveMagic = []byte("ETHCHARC")
+
+	// ErrNotChainArchive is returned if the data being opened does not start
+	// with the chain archive preamble (e.g. it is a legacy bare RLP export).
+	ErrNotChainArchive = errors.New("not a chain archive")
+
+	// ErrArchiveNetworkMismatch is returned if an archive exported on a different
+	// network is imported without being forced.
+	ErrArchiveNetworkMismatch = errors.New("chain archive of different network")
+
+	// errArchiveTruncated is returned if an archive ends before its index.
+	errArchiveTruncated = errors.New("chain archive truncated")
+)
+
+// ChainArchiveHeader describes the contents of a chain archive.
+type ChainArchiveHeader struct {
+	Version          uint64      // Archive format version
+	NetworkId        uint64      // Network identifier of the exporting node
+	Genesis          common.Hash // Genesis hash of the exported chain
+	First            uint64      // Number of the first block in the archive
+	Last             uint64      // Number of the last block in the archive
+	ChecksumInterval uint64      // Number of blocks sealed by each checksum
+}
+
+// ChainArchiveBlock is a single block entry of a chain archive.
+type ChainArchiveBlock struct {
+	Block    *types.Block
+	Receipts types.Receipts
+	Td       *big.Int
+}
+
+// archiveEntry is the envelope every item after the header is wrapped into.
+type archiveEntry struct {
+	Kind uint8
+	Data rlp.RawValue
+}
+
+// archiveBlockRLP is the storage representation of a block entry.
+type archiveBlockRLP struct {
+	Block    *types.Block
+	Receipts []*types.ReceiptForStorage
+	Td       *big.Int
+}
+
+// archiveChecksum seals all block entries since the previous checksum.
+type archiveChecksum struct {
+	Last uint64      // Number of the last block sealed
+	Hash common.Hash // Keccak256 hash of the sealed block entries
+}
+
+// archiveIndexItem is the position of a checksummed segment in the archive.
+type archiveIndexItem struct {
+	First  uint64 // Number of the first block in the segment
+	Offset uint64 // Byte offset of the segment's first block entry
+}
+
+// countingWriter tracks the number of bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += uint64(n)
+	return n, err
+}
+
+// ChainArchiveWriter produces a chain archive out of a stream of blocks.
+type ChainArchiveWriter struct {
+	w      *countingWriter
+	header ChainArchiveHeader
+	hasher hash.Hash
+
+	next    uint64             // Number of the next block expected
+	pending uint64             // Number of blocks since the last checksum
+	index   []archiveIndexItem // Offsets of the checksummed segments
+}
+
+// NewChainArchiveWriter writes the preamble and header of a new chain archive
+// into w and returns a writer to append blocks with. If the header does not
+// specify a checksum interval, a default one is used.
+func NewChainArchiveWriter(w io.Writer, header ChainArchiveHeader) (*ChainArchiveWriter, error) {
+	if header.First > header.Last {
+		return nil, fmt.Errorf("invalid archive range: first (%d) is greater than last (%d)", header.First, header.Last)
+	}
+	header.Version = ChainArchiveVersion
+	if header.ChecksumInterval == 0 {
+		header.ChecksumInterval = chainArchiveChecksumInterval
+	}
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write(chainArchiveMagic); err != nil {
+		return nil, err
+	}
+	if err := rlp.Encode(cw, &header); err != nil {
+		return nil, err
+	}
+	return &ChainArchiveWriter{
+		w:      cw,
+		header: header,
+		hasher: sha3.NewKeccak256(),
+		next:   header.First,
+	}, nil
+}
+
+// Append adds the next block of the archived range, along with its receipts and
+// total difficulty, sealing the current segment if the checksum interval is hit.
+func (w *ChainArchiveWriter) Append(block *types.Block, receipts types.Receipts, td *big.Int) error {
+	if number := block.NumberU64(); number != w.next || number > w.header.Last {
+		return fmt.Errorf("unexpected block #%d in archive, want #%d", number, w.next)
+	}
+	stored := make([]*types.ReceiptForStorage, len(receipts))
+	for i, receipt := range receipts {
+		stored[i] = (*types.ReceiptForStorage)(receipt)
+	}
+	data, err := rlp.EncodeToBytes(&archiveBlockRLP{Block: block, Receipts: stored, Td: td})
+	if err != nil {
+		return err
+	}
+	if w.pending == 0 {
+		w.index = append(w.index, archiveIndexItem{First: w.next, Offset: w.w.n})
+	}
+	if err := w.writeEntry(archiveBlockEntry, data); err != nil {
+		return err
+	}
+	w.hasher.Write(data)
+	w.next++
+	w.pending++
+
+	if w.pending == w.header.ChecksumInterval || block.NumberU64() == w.header.Last {
+		return w.seal()
+	}
+	return nil
+}
+
+// Close finalizes the archive by writing the segment index and the footer. It
+// fails if not all blocks announced by the header have been appended.
+func (w *ChainArchiveWriter) Close() error {
+	if w.next != w.header.Last+1 {
+		return fmt.Errorf("archive incomplete: have blocks up to #%d, want #%d", w.next-1, w.header.Last)
+	}
+	data, err := rlp.EncodeToBytes(w.index)
+	if err != nil {
+		return err
+	}
+	offset := w.w.n
+	if err := w.writeEntry(archiveIndexEntry, data); err != nil {
+		return err
+	}
+	footer := make([]byte, chainArchiveFooterSize)
+	binary.BigEndian.PutUint64(footer, offset)
+	copy(footer[8:], chainArchiveMagic)
+
+	_, err = w.w.Write(footer)
+	return err
+}
+
+// seal writes a checksum entry covering all pending block entries.
+func (w *ChainArchiveWriter) seal() error {
+	data, err := rlp.EncodeToBytes(&archiveChecksum{
+		Last: w.next - 1,
+		Hash: common.BytesToHash(w.hasher.Sum(nil)),
+	})
+	if err != nil {
+		return err
+	}
+	w.hasher.Reset()
+	w.pending = 0
+
+	return w.writeEntry(archiveChecksumEntry, data)
+}
+
+// writeEntry wraps a payload into an entry envelope and writes it out.
+func (w *ChainArchiveWriter) writeEntry(kind uint8, data []byte) error {
+	return rlp.Encode(w.w, &archiveEntry{Kind: kind, Data: data})
+}
+
+// ChainArchiveReader iterates over the blocks of a chain archive, verifying the
+// integrity of every block and of every checksummed segment along the way.
+type ChainArchiveReader struct {
+	stream *rlp.Stream
+	header ChainArchiveHeader
+	hasher hash.Hash
+
+	next    uint64      // Number of the next block expected
+	pending uint64      // Number of blocks since the last checksum
+	parent  common.Hash // Hash of the previously read block
+	td      *big.Int    // Total difficulty of the previously read block
+	done    bool        // Whether the index was reached
+}
+
+// IsChainArchive reports whether the buffered data starts with the preamble of
+// a chain archive, without consuming any of it.
+func IsChainArchive(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(chainArchiveMagic))
+	return err == nil && bytes.Equal(magic, chainArchiveMagic)
+}
+
+// NewChainArchiveReader consumes the preamble and header of a chain archive and
+// returns a reader to iterate over its blocks.
+func NewChainArchiveReader(r io.Reader) (*ChainArchiveReader, error) {
+	magic := make([]byte, len(chainArchiveMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, chainArchiveMagic) {
+		return nil, ErrNotChainArchive
+	}
+	stream := rlp.NewStream(r, 0)
+
+	var header ChainArchiveHeader
+	if err := stream.Decode(&header); err != nil {
+		return nil, fmt.Errorf("invalid archive header: %v", err)
+	}
+	if header.Version != ChainArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d, want %d", header.Version, ChainArchiveVersion)
+	}
+	if header.First > header.Last || header.ChecksumInterval == 0 {
+		return nil, fmt.Errorf("invalid archive header: range #%d-#%d, checksum interval %d", header.First, header.Last, header.ChecksumInterval)
+	}
+	return &ChainArchiveReader{
+		stream: stream,
+		header: header,
+		hasher: sha3.NewKeccak256(),
+		next:   header.First,
+	}, nil
+}
+
+// Header returns the header of the archive being read.
+func (r *ChainArchiveReader) Header() ChainArchiveHeader {
+	return r.header
+}
+
+// Next returns the next block of the archive, or io.EOF if all blocks have been
+// read and the archive was found to be complete.
+//
+// Note, a block is returned before the checksum of its segment is verified; an
+// invalid checksum is reported by the Next call crossing it. Use VerifyChainArchive
+// to validate a whole archive before acting on its contents.
+func (r *ChainArchiveReader) Next() (*ChainArchiveBlock, error) {
+	for !r.done {
+		var entry archiveEntry
+		if err := r.stream.Decode(&entry); err == io.EOF {
+			return nil, errArchiveTruncated
+		} else if err != nil {
+			return nil, fmt.Errorf("block #%d: invalid entry: %v", r.next, err)
+		}
+		switch entry.Kind {
+		case archiveBlockEntry:
+			return r.readBlock(entry.Data)
+
+		case archiveChecksumEntry:
+			var checksum archiveChecksum
+			if err := rlp.DecodeBytes(entry.Data, &checksum); err != nil {
+				return nil, fmt.Errorf("block #%d: invalid checksum entry: %v", r.next, err)
+			}
+			if r.pending == 0 || checksum.Last != r.next-1 {
+				return nil, fmt.Errorf("misplaced checksum for block #%d after block #%d", checksum.Last, r.next-1)
+			}
+			if have := common.BytesToHash(r.hasher.Sum(nil)); have != checksum.Hash {
+				return nil, fmt.Errorf("checksum mismatch for segment ending at block #%d: have %x, want %x", checksum.Last, have, checksum.Hash)
+			}
+			r.hasher.Reset()
+			r.pending = 0
+
+		case archiveIndexEntry:
+			if r.pending > 0 {
+				return nil, fmt.Errorf("%d blocks not covered by a checksum", r.pending)
+			}
+			if r.next != r.header.Last+1 {
+				return nil, fmt.Errorf("archive incomplete: have blocks up to #%d, want #%d", r.next-1, r.header.Last)
+			}
+			r.done = true
+
+		default:
+			return nil, fmt.Errorf("block #%d: unknown entry kind %d", r.next, entry.Kind)
+		}
+	}
+	return nil, io.EOF
+}
+
+// readBlock decodes and validates a block entry against the previous ones.
+func (r *ChainArchiveReader) readBlock(data []byte) (*ChainArchiveBlock, error) {
+	if r.next > r.header.Last {
+		return nil, fmt.Errorf("block #%d outside of archived range #%d-#%d", r.next, r.header.First, r.header.Last)
+	}
+	var dec archiveBlockRLP
+	if err := rlp.DecodeBytes(data, &dec); err != nil {
+		return nil, fmt.Errorf("block #%d: invalid entry: %v", r.next, err)
+	}
+	receipts := make(types.Receipts, len(dec.Receipts))
+	for i, receipt := range dec.Receipts {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	block := &ChainArchiveBlock{Block: dec.Block, Receipts: receipts, Td: dec.Td}
+	if err := r.verifyBlock(block); err != nil {
+		return nil, err
+	}
+	r.hasher.Write(data)
+	r.next++
+	r.pending++
+	r.parent = block.Block.Hash()
+	r.td = block.Td
+
+	return block, nil
+}
+
+// verifyBlock checks that the contents of a block entry are consistent with its
+// header and that it links up with the previously read entry.
+func (r *ChainArchiveReader) verifyBlock(entry *ChainArchiveBlock) error {
+	var (
+		block  = entry.Block
+		header = block.Header()
+	)
+	if block.NumberU64() != r.next {
+		return fmt.Errorf("unexpected block #%d in archive, want #%d", block.NumberU64(), r.next)
+	}
+	if r.next > r.header.First && block.ParentHash() != r.parent {
+		return fmt.Errorf("block #%d: parent hash mismatch: have %x, want %x", r.next, block.ParentHash(), r.parent)
+	}
+	if r.next == 0 && block.Hash() != r.header.Genesis {
+		return fmt.Errorf("genesis hash mismatch: have %x, want %x", block.Hash(), r.header.Genesis)
+	}
+	if hash := types.DeriveSha(block.Transactions()); hash != header.TxHash {
+		return fmt.Errorf("block #%d: transaction root hash mismatch: have %x, want %x", r.next, hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("block #%d: uncle root hash mismatch: have %x, want %x", r.next, hash, header.UncleHash)
+	}
+	if hash := types.DeriveSha(entry.Receipts); hash != header.ReceiptHash {
+		return fmt.Errorf("block #%d: receipt root hash mismatch: have %x, want %x", r.next, hash, header.ReceiptHash)
+	}
+	if entry.Td == nil {
+		return fmt.Errorf("block #%d: missing total difficulty", r.next)
+	}
+	if r.next > r.header.First {
+		if want := new(big.Int).Add(r.td, block.Difficulty()); entry.Td.Cmp(want) != 0 {
+			return fmt.Errorf("block #%d: total difficulty mismatch: have %v, want %v", r.next, entry.Td, want)
+		}
+	}
+	return nil
+}
+
+// VerifyChainArchive reads through an entire chain archive, validating all of
+// its blocks, checksums and completeness, and returns its header.
+func VerifyChainArchive(r io.Reader) (*ChainArchiveHeader, error) {
+	archive, err := NewChainArchiveReader(r)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := archive.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	header := archive.Header()
+	return &header, nil
+}
+
+// ReadChainArchiveBlock retrieves a single block from a seekable, uncompressed
+// chain archive, using the segment index to avoid scanning the whole archive.
+// The checksum of the segment containing the block is verified.
+func ReadChainArchiveBlock(rs io.ReadSeeker, number uint64) (*ChainArchiveBlock, error) {
+	archive, err := NewChainArchiveReader(rs)
+	if err != nil {
+		return nil, err
+	}
+	header := archive.Header()
+	if number < header.First || number > header.Last {
+		return nil, fmt.Errorf("block #%d outside of archived range #%d-#%d", number, header.First, header.Last)
+	}
+	// Locate and load the segment index through the footer
+	footer := make([]byte, chainArchiveFooterSize)
+	if _, err := rs.Seek(-chainArchiveFooterSize, io.SeekEnd); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rs, footer); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[8:], chainArchiveMagic) {
+		return nil, errArchiveTruncated
+	}
+	if _, err := rs.Seek(int64(binary.BigEndian.Uint64(footer)), io.SeekStart); err != nil {
+		return nil, err
+	}
+	var (
+		entry archiveEntry
+		index []archiveIndexItem
+	)
+	if err := rlp.Decode(rs, &entry); err != nil || entry.Kind != archiveIndexEntry {
+		return nil, fmt.Errorf("invalid archive index: %v", err)
+	}
+	if err := rlp.DecodeBytes(entry.Data, &index); err != nil {
+		return nil, fmt.Errorf("invalid archive index: %v", err)
+	}
+	// Find the segment containing the block and verify it while reading
+	pos := sort.Search(len(index), func(i int) bool { return index[i].First > number }) - 1
+	if pos < 0 {
+		return nil, fmt.Errorf("block #%d not indexed", number)
+	}
+	if _, err := rs.Seek(int64(index[pos].Offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	segment := &ChainArchiveReader{
+		stream: rlp.NewStream(rs, 0),
+		header: header,
+		hasher: sha3.NewKeccak256(),
+		next:   index[pos].First,
+	}
+	// The first block of a segment cannot be linked to its parent, pretend it
+	// starts the archive for the purpose of verification
+	segment.header.First = index[pos].First
+
+	var result *ChainArchiveBlock
+	for {
+		block, err := segment.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// Stop once the checksum following the requested block was verified,
+		// which is signalled by the reader having started a new segment
+		if result != nil && segment.pending == 1 {
+			break
+		}
+		if block.Block.NumberU64() == number {
+			result = block
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("block #%d not found in archive", number)
+	}
+	return result, nil
+}
+
+// chainFile is an input chain file, optionally decompressed on the fly.
+type chainFile struct {
+	io.Reader
+	gz *gzip.Reader
+	fh *os.File
+}
+
+// Close closes the decompressor, if any, and the underlying file.
+func (f *chainFile) Close() error {
+	if f.gz != nil {
+		f.gz.Close()
+	}
+	return f.fh.Close()
+}
+
+// OpenChainFile opens a chain file for reading, transparently decompressing it
+// if its name ends in ".gz".
+func OpenChainFile(fn string) (io.ReadCloser, error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	file := &chainFile{Reader: fh, fh: fh}
+	if strings.HasSuffix(fn, ".gz") {
+		if file.gz, err = gzip.NewReader(fh); err != nil {
+			fh.Close()
+			return nil, err
+		}
+		file.Reader = file.gz
+	}
+	return file, nil
+}
+
+// ExportArchive writes a subset of the active chain, including receipts and
+// total difficulties, into the given writer as a chain archive.
+func (bc *BlockChain) ExportArchive(w io.Writer, networkId uint64, first uint64, last uint64) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	log.Info("Exporting archive of blocks", "count", last-first+1)
+
+	archive, err := NewChainArchiveWriter(w, ChainArchiveHeader{
+		NetworkId: networkId,
+		Genesis:   bc.genesisBlock.Hash(),
+		First:     first,
+		Last:      last,
+	})
+	if err != nil {
+		return err
+	}
+	for nr := first; nr <= last; nr++ {
+		block := bc.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		td := bc.GetTd(block.Hash(), nr)
+		if td == nil {
+			return fmt.Errorf("export failed on #%d: total difficulty not found", nr)
+		}
+		receipts := GetBlockReceipts(bc.chainDb, block.Hash(), nr)
+		if err := archive.Append(block, receipts, td); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// ExportArchiveFile writes a subset of the active chain into a chain archive file,
+// replacing any existing one. The archive is compressed if the file name ends in
+// ".gz".
+func (bc *BlockChain) ExportArchiveFile(fn string, networkId uint64, first uint64, last uint64) error {
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var (
+		writer io.Writer = fh
+		gz     *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(fh)
+		writer = gz
+	}
+	if err := bc.ExportArchive(writer, networkId, first, last); err != nil {
+		return err
+	}
+	// Closing the compressor flushes the tail of the archive, it must succeed too
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	return fh.Close()
+}
+
+// ImportArchiveFile verifies an entire chain archive file and only then imports
+// its blocks into the chain. Archives exported on a network other than networkId
+// are rejected unless force is set. The number of blocks imported is returned.
+func (bc *BlockChain) ImportArchiveFile(fn string, networkId uint64, force bool, batch int, abort func() bool) (int, error) {
+	// Scan the whole archive first to detect any corruption before importing
+	file, err := OpenChainFile(fn)
+	if err != nil {
+		return 0, err
+	}
+	header, err := VerifyChainArchive(bufio.NewReader(file))
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("archive verification failed: %v", err)
+	}
+	log.Info("Verified chain archive", "network", header.NetworkId, "genesis", header.Genesis, "first", header.First, "last", header.Last)
+
+	if header.NetworkId != networkId {
+		if !force {
+			return 0, fmt.Errorf("%v: archive %d, local %d", ErrArchiveNetworkMismatch, header.NetworkId, networkId)
+		}
+		log.Warn("Importing chain archive of different network", "archive", header.NetworkId, "local", networkId)
+	}
+	// Archive intact, run the actual import
+	if file, err = OpenChainFile(fn); err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	archive, err := NewChainArchiveReader(bufio.NewReader(file))
+	if err != nil {
+		return 0, err
+	}
+	n, err := bc.ImportArchive(archive, batch, abort)
+	if err != nil {
+		return n, err
+	}
+	log.Info("Imported chain archive", "blocks", n)
+	return n, nil
+}
+
+// ImportArchive inserts all blocks of a chain archive into the chain in batches,
+// checking that the total difficulties recorded in the archive match the ones
+// computed locally. The archived receipts are stored for blocks lacking them in
+// the local database and cross checked against the locally derived ones for the
+// rest. The abort callback is consulted between batches. The number of blocks
+// imported is returned.
+func (bc *BlockChain) ImportArchive(archive *ChainArchiveReader, batch int, abort func() bool) (int, error) {
+	if genesis := archive.Header().Genesis; genesis != bc.genesisBlock.Hash() {
+		return 0, fmt.Errorf("genesis mismatch: archive %x, local %x", genesis, bc.genesisBlock.Hash())
+	}
+	var (
+		blocks   = make(types.Blocks, 0, batch)
+		receipts = make([]types.Receipts, 0, batch)
+		tds      = make([]*big.Int, 0, batch)
+		imported int
+	)
+	for done := false; !done; {
+		if abort != nil && abort() {
+			return imported, errors.New("interrupted")
+		}
+		// Load a batch of blocks from the archive, skipping the genesis
+		for len(blocks) < batch {
+			entry, err := archive.Next()
+			if err == io.EOF {
+				done = true
+				break
+			} else if err != nil {
+				return imported, err
+			}
+			if entry.Block.NumberU64() == 0 {
+				continue
+			}
+			blocks, receipts, tds = append(blocks, entry.Block), append(receipts, entry.Receipts), append(tds, entry.Td)
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		// Import the batch unless already known and cross check the difficulties
+		// and receipts
+		known := true
+		for _, block := range blocks {
+			if !bc.HasBlock(block.Hash(), block.NumberU64()) {
+				known = false
+				break
+			}
+		}
+		if !known {
+			if n, err := bc.InsertChain(blocks); err != nil {
+				return imported + n, fmt.Errorf("invalid block #%d: %v", blocks[n].NumberU64(), err)
+			}
+		}
+		for i, block := range blocks {
+			if td := bc.GetTd(block.Hash(), block.NumberU64()); td == nil || td.Cmp(tds[i]) != 0 {
+				return imported + i, fmt.Errorf("block #%d: total difficulty mismatch: archive %v, local %v", block.NumberU64(), tds[i], td)
+			}
+			if err := bc.importArchiveReceipts(block, receipts[i]); err != nil {
+				return imported + i, err
+			}
+		}
+		imported += len(blocks)
+		blocks, receipts, tds = blocks[:0], receipts[:0], tds[:0]
+	}
+	return imported, nil
+}
+
+// importArchiveReceipts stores the archived receipts of a block if the local
+// database has none for it, or checks that they match the local ones otherwise.
+func (bc *BlockChain) importArchiveReceipts(block *types.Block, receipts types.Receipts) error {
+	local := GetBlockReceipts(bc.chainDb, block.Hash(), block.NumberU64())
+	if len(local) == 0 && len(receipts) > 0 {
+		return WriteBlockReceipts(bc.chainDb, block.Hash(), block.NumberU64(), receipts)
+	}
+	if len(local) != len(receipts) {
+		return fmt.Errorf("block #%d: receipt count mismatch: archive %d, local %d", block.NumberU64(), len(receipts), len(local))
+	}
+	for i, receipt := range receipts {
+		if receipt.TxHash != local[i].TxHash || receipt.ContractAddress != local[i].ContractAddress || receipt.GasUsed.Cmp(local[i].GasUsed) != 0 {
+			return fmt.Errorf("block #%d: receipt %d mismatch", block.NumberU64(), i)
+		}
+	}
+	return nil
+}
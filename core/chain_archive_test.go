@@ -0,0 +1,199 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/consensus/ethash"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+// newArchiveTestChain creates a chain with a few transactions in it, returning
+// the genesis specification and the imported chain.
+func newArchiveTestChain(t *testing.T, length int) (*Genesis, *BlockChain) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, db, length, func(i int, block *BlockGen) {
+		if i%2 == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), bigTxGas, nil, nil), signer, key)
+			block.AddTx(tx)
+		}
+	})
+	chain, _ := NewBlockChain(db, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	return gspec, chain
+}
+
+// Tests that a chain exported into an archive can be verified, randomly accessed
+// and imported into a fresh chain.
+func TestChainArchiveRoundtrip(t *testing.T) {
+	gspec, chain := newArchiveTestChain(t, 20)
+	defer chain.Stop()
+
+	buf := new(bytes.Buffer)
+	archive, err := NewChainArchiveWriter(buf, ChainArchiveHeader{NetworkId: 1, Genesis: chain.Genesis().Hash(), First: 0, Last: 20, ChecksumInterval: 6})
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	for nr := uint64(0); nr <= 20; nr++ {
+		block := chain.GetBlockByNumber(nr)
+		if err := archive.Append(block, GetBlockReceipts(chain.chainDb, block.Hash(), nr), chain.GetTd(block.Hash(), nr)); err != nil {
+			t.Fatalf("failed to append block #%d: %v", nr, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	blob := buf.Bytes()
+
+	// Verify the whole archive and check the header
+	header, err := VerifyChainArchive(bytes.NewReader(blob))
+	if err != nil {
+		t.Fatalf("failed to verify archive: %v", err)
+	}
+	if header.NetworkId != 1 || header.First != 0 || header.Last != 20 || header.Genesis != chain.Genesis().Hash() {
+		t.Errorf("header mismatch: %+v", header)
+	}
+	// Retrieve a few blocks through the index
+	for _, nr := range []uint64{0, 5, 6, 13, 20} {
+		entry, err := ReadChainArchiveBlock(bytes.NewReader(blob), nr)
+		if err != nil {
+			t.Fatalf("failed to read block #%d: %v", nr, err)
+		}
+		if hash := chain.GetBlockByNumber(nr).Hash(); entry.Block.Hash() != hash {
+			t.Errorf("block #%d: hash mismatch: have %x, want %x", nr, entry.Block.Hash(), hash)
+		}
+	}
+	// Import the archive into a new chain
+	db, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	imported, _ := NewBlockChain(db, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer imported.Stop()
+
+	br := bufio.NewReader(bytes.NewReader(blob))
+	if !IsChainArchive(br) {
+		t.Fatalf("archive not detected")
+	}
+	reader, err := NewChainArchiveReader(br)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	if n, err := imported.ImportArchive(reader, 7, nil); err != nil || n != 20 {
+		t.Fatalf("import failed: imported %d, err %v", n, err)
+	}
+	if imported.CurrentBlock().Hash() != chain.CurrentBlock().Hash() {
+		t.Errorf("head mismatch: have %x, want %x", imported.CurrentBlock().Hash(), chain.CurrentBlock().Hash())
+	}
+}
+
+// Tests that compressed archive files are round tripped, that archives of other
+// networks are only imported if forced and that missing receipts are restored.
+func TestChainArchiveFile(t *testing.T) {
+	gspec, chain := newArchiveTestChain(t, 10)
+	defer chain.Stop()
+
+	dir, err := ioutil.TempDir("", "chain-archive-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "archive.gz")
+	if err := chain.ExportArchiveFile(file, 1, 0, 10); err != nil {
+		t.Fatalf("failed to export archive: %v", err)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	imported, _ := NewBlockChain(db, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer imported.Stop()
+
+	if _, err := imported.ImportArchiveFile(file, 2, false, 4, nil); err == nil {
+		t.Fatalf("archive of different network imported")
+	}
+	if imported.CurrentBlock().NumberU64() != 0 {
+		t.Fatalf("chain modified by rejected import: head #%d", imported.CurrentBlock().NumberU64())
+	}
+	if n, err := imported.ImportArchiveFile(file, 2, true, 4, nil); err != nil || n != 10 {
+		t.Fatalf("forced import failed: imported %d, err %v", n, err)
+	}
+	// Drop the receipts of a block and ensure reimporting restores them
+	block := imported.GetBlockByNumber(5)
+	want := GetBlockReceipts(db, block.Hash(), block.NumberU64())
+	if len(want) == 0 {
+		t.Fatalf("no receipts in block #%d", block.NumberU64())
+	}
+	DeleteBlockReceipts(db, block.Hash(), block.NumberU64())
+
+	if n, err := imported.ImportArchiveFile(file, 1, false, 4, nil); err != nil || n != 10 {
+		t.Fatalf("reimport failed: imported %d, err %v", n, err)
+	}
+	if have := GetBlockReceipts(db, block.Hash(), block.NumberU64()); types.DeriveSha(have) != types.DeriveSha(want) {
+		t.Errorf("receipts not restored: have %d, want %d", len(have), len(want))
+	}
+}
+
+// Tests that corrupted and truncated archives are detected.
+func TestChainArchiveCorruption(t *testing.T) {
+	_, chain := newArchiveTestChain(t, 10)
+	defer chain.Stop()
+
+	buf := new(bytes.Buffer)
+	if err := chain.ExportArchive(buf, 1, 0, 10); err != nil {
+		t.Fatalf("failed to export archive: %v", err)
+	}
+	blob := buf.Bytes()
+
+	if _, err := VerifyChainArchive(bytes.NewReader(blob)); err != nil {
+		t.Fatalf("failed to verify pristine archive: %v", err)
+	}
+	if _, err := VerifyChainArchive(bytes.NewReader(blob[:len(blob)/2])); err == nil {
+		t.Errorf("truncated archive verified")
+	}
+	for _, pos := range []int{len(blob) / 3, len(blob) / 2, len(blob) * 2 / 3} {
+		corrupt := common.CopyBytes(blob)
+		corrupt[pos] ^= 0x01
+		if _, err := VerifyChainArchive(bytes.NewReader(corrupt)); err == nil {
+			t.Errorf("archive corrupted at byte %d verified", pos)
+		}
+	}
+	if IsChainArchive(bufio.NewReader(bytes.NewReader([]byte{0xc0}))) {
+		t.Errorf("bare RLP detected as archive")
+	}
+}
@@ -44,9 +44,10 @@ type DatabaseDeleter interface {
 }
 
 var (
-	headHeaderKey = []byte("LastHeader")
-	headBlockKey  = []byte("LastBlock")
-	headFastKey   = []byte("LastFast")
+	headHeaderKey   = []byte("LastHeader")
+	headBlockKey    = []byte("LastBlock")
+	headFastKey     = []byte("LastFast")
+	backfillTailKey = []byte("BackfillTail")
 
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
 	headerPrefix        = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
@@ -146,6 +147,17 @@ func GetHeadFastBlockHash(db DatabaseReader) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// GetBackfillTail retrieves the hash of the lowest block of a checkpoint synced
+// chain whose body and receipts are known. The blocks between the checkpoint and
+// it are still to be backfilled; a zero hash means nothing is pending.
+func GetBackfillTail(db DatabaseReader) common.Hash {
+	data, _ := db.Get(backfillTailKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
@@ -374,6 +386,21 @@ func WriteHeadFastBlockHash(db ethdb.Putter, hash common.Hash) error {
 	return nil
 }
 
+// WriteBackfillTail stores the hash of the lowest block of a checkpoint synced
+// chain whose body and receipts are known.
+func WriteBackfillTail(db ethdb.Putter, hash common.Hash) error {
+	if err := db.Put(backfillTailKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store backfill tail hash", "err", err)
+	}
+	return nil
+}
+
+// DeleteBackfillTail removes the backfill marker once all the blocks between the
+// checkpoint and the sync pivot are retrieved.
+func DeleteBackfillTail(db DatabaseDeleter) {
+	db.Delete(backfillTailKey)
+}
+
 // WriteHeader serializes a block header into the database.
 func WriteHeader(db ethdb.Putter, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/core/types"
 )
@@ -24,6 +26,16 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxPoolEvent is posted when a transaction changes its status within (or leaves)
+// the transaction pool.
+type TxPoolEvent struct {
+	Hash        common.Hash // Hash of the transaction the event is about
+	Status      string      // New status of the transaction (TxEvent* constants)
+	Reason      string      // Reason for dropping the transaction (TxDrop* constants)
+	Replacement common.Hash // Hash of the replacing transaction (if replaced)
+	Time        time.Time   // Timestamp of the status change
+}
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
@@ -52,3 +64,18 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain is reorganised, containing all
+// the details of the fork in a single event. Blocks are ordered by number, from
+// the first block after the common ancestor up to the (old or new) head.
+type ReorgEvent struct {
+	CommonBlock *types.Block // Latest block shared by the old and new chain
+	OldChain    types.Blocks // Blocks dropped from the canonical chain
+	NewChain    types.Blocks // Blocks added to the canonical chain
+
+	DroppedTxs types.Transactions // Transactions of the old chain not included in the new one
+	AddedTxs   types.Transactions // Transactions of the new chain not included in the old one
+
+	DroppedLogs []*types.Log // Logs generated by the old chain (marked removed)
+	AddedLogs   []*types.Log // Logs generated by the new chain
+}
@@ -395,6 +395,30 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
 	hc.currentHeaderHash = head.Hash()
 }
 
+// WriteCheckpointHeader injects a trusted checkpoint header along with its total
+// difficulty into the database and makes it the canonical head header. Contrary
+// to WriteHeader, the ancestors of the checkpoint do not need to be known.
+func (hc *HeaderChain) WriteCheckpointHeader(header *types.Header, td *big.Int) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+
+	if err := WriteTd(hc.chainDb, hash, number, td); err != nil {
+		return err
+	}
+	if err := WriteHeader(hc.chainDb, header); err != nil {
+		return err
+	}
+	if err := WriteCanonicalHash(hc.chainDb, hash, number); err != nil {
+		return err
+	}
+	hc.SetCurrentHeader(types.CopyHeader(header))
+
+	hc.headerCache.Add(hash, header)
+	hc.tdCache.Add(hash, new(big.Int).Set(td))
+	hc.numberCache.Add(hash, number)
+
+	return nil
+}
+
 // DeleteCallback is a callback function that is called by SetHead before
 // each header is deleted.
 type DeleteCallback func(common.Hash, uint64)
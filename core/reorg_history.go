@@ -0,0 +1,101 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+)
+
+// reorgHistoryLimit is the maximum number of chain reorganisations remembered.
+const reorgHistoryLimit = 128
+
+// ReorgRecord is the summary of a chain reorganisation kept in the reorg history.
+type ReorgRecord struct {
+	Time         time.Time   `json:"time"`         // Time instance when the reorg happened
+	CommonNumber uint64      `json:"commonNumber"` // Number of the common ancestor
+	CommonHash   common.Hash `json:"commonHash"`   // Hash of the common ancestor
+
+	Dropped []common.Hash `json:"dropped"` // Hashes of the blocks dropped, ordered by number
+	Added   []common.Hash `json:"added"`   // Hashes of the blocks added, ordered by number
+
+	DroppedTxs []common.Hash `json:"droppedTxs"` // Transactions not included in the new chain
+	AddedTxs   []common.Hash `json:"addedTxs"`   // Transactions not included in the old chain
+
+	DroppedLogs int `json:"droppedLogs"` // Number of logs removed from the canonical chain
+	AddedLogs   int `json:"addedLogs"`   // Number of logs added to the canonical chain
+}
+
+// newReorgRecord summarises a reorg event into a history record.
+func newReorgRecord(ev *ReorgEvent) *ReorgRecord {
+	record := &ReorgRecord{
+		Time:         time.Now(),
+		CommonNumber: ev.CommonBlock.NumberU64(),
+		CommonHash:   ev.CommonBlock.Hash(),
+		Dropped:      make([]common.Hash, len(ev.OldChain)),
+		Added:        make([]common.Hash, len(ev.NewChain)),
+		DroppedTxs:   make([]common.Hash, len(ev.DroppedTxs)),
+		AddedTxs:     make([]common.Hash, len(ev.AddedTxs)),
+		DroppedLogs:  len(ev.DroppedLogs),
+		AddedLogs:    len(ev.AddedLogs),
+	}
+	for i, block := range ev.OldChain {
+		record.Dropped[i] = block.Hash()
+	}
+	for i, block := range ev.NewChain {
+		record.Added[i] = block.Hash()
+	}
+	for i, tx := range ev.DroppedTxs {
+		record.DroppedTxs[i] = tx.Hash()
+	}
+	for i, tx := range ev.AddedTxs {
+		record.AddedTxs[i] = tx.Hash()
+	}
+	return record
+}
+
+// reorgHistory is a bounded list of the most recent chain reorganisations.
+type reorgHistory struct {
+	records []*ReorgRecord
+	lock    sync.RWMutex
+}
+
+// add inserts a new record into the history, evicting the oldest if full.
+func (h *reorgHistory) add(record *ReorgRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.records) >= reorgHistoryLimit {
+		h.records = append(h.records[:0], h.records[1:]...)
+	}
+	h.records = append(h.records, record)
+}
+
+// list retrieves the recorded reorgs, oldest first.
+func (h *reorgHistory) list() []*ReorgRecord {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return append([]*ReorgRecord(nil), h.records...)
+}
+
+// ReorgHistory retrieves the most recent chain reorganisations, oldest first.
+func (bc *BlockChain) ReorgHistory() []*ReorgRecord {
+	return bc.reorgs.list()
+}
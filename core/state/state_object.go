@@ -214,6 +214,22 @@ func (self *stateObject) setState(key, value common.Hash) {
 	}
 }
 
+// setStorage replaces the entire storage of the account with the given slots.
+// The change is not journalled.
+func (self *stateObject) setStorage(db Database, storage map[common.Hash]common.Hash) {
+	tr, err := db.OpenStorageTrie(self.addrHash, common.Hash{})
+	if err != nil {
+		self.setError(fmt.Errorf("can't create storage trie: %v", err))
+		return
+	}
+	self.trie, self.data.Root = tr, tr.Hash()
+	self.cachedStorage, self.dirtyStorage = make(Storage), make(Storage)
+
+	for key, value := range storage {
+		self.setState(key, value)
+	}
+}
+
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (self *stateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
@@ -117,6 +117,37 @@ func TestIntermediateLeaks(t *testing.T) {
 	}
 }
 
+// Tests that replacing the storage of an account drops all its previous slots,
+// including the already committed ones.
+func TestSetStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	addr := common.BytesToAddress([]byte{0x01})
+	state.SetState(addr, common.BytesToHash([]byte{1}), common.BytesToHash([]byte{1}))
+	state.SetState(addr, common.BytesToHash([]byte{2}), common.BytesToHash([]byte{2}))
+	root, _ := state.CommitTo(db, false)
+
+	state, _ = New(root, NewDatabase(db))
+	state.SetStorage(addr, map[common.Hash]common.Hash{common.BytesToHash([]byte{2}): common.BytesToHash([]byte{3})})
+
+	if value := state.GetState(addr, common.BytesToHash([]byte{1})); value != (common.Hash{}) {
+		t.Errorf("dropped slot still present: %x", value)
+	}
+	if value := state.GetState(addr, common.BytesToHash([]byte{2})); value != common.BytesToHash([]byte{3}) {
+		t.Errorf("replaced slot mismatch: have %x, want %x", value, common.BytesToHash([]byte{3}))
+	}
+	// Ensure the replaced storage is also the one committed
+	root, _ = state.CommitTo(db, false)
+	state, _ = New(root, NewDatabase(db))
+	if value := state.GetState(addr, common.BytesToHash([]byte{1})); value != (common.Hash{}) {
+		t.Errorf("dropped slot committed: %x", value)
+	}
+	if value := state.GetState(addr, common.BytesToHash([]byte{2})); value != common.BytesToHash([]byte{3}) {
+		t.Errorf("replaced slot commit mismatch: have %x, want %x", value, common.BytesToHash([]byte{3}))
+	}
+}
+
 func TestSnapshotRandom(t *testing.T) {
 	config := &quick.Config{MaxCount: 1000}
 	err := quick.Check((*snapshotTest).run, config)
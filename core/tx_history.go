@@ -0,0 +1,86 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/teamnsrg/ethereum-p2p/common"
+)
+
+// txHistoryLimit is the maximum number of dropped or replaced transactions the
+// pool remembers the fate of.
+const txHistoryLimit = 8192
+
+// Statuses a transaction can transition into within the pool.
+const (
+	TxEventQueued   = "queued"   // Transaction entered (or was demoted into) the future queue
+	TxEventPending  = "pending"  // Transaction became executable
+	TxEventDropped  = "dropped"  // Transaction was removed from the pool, see the reason
+	TxEventReplaced = "replaced" // Transaction was superseded by one with the same nonce
+	TxEventMined    = "mined"    // Transaction was included in the chain
+)
+
+// Reasons a transaction can be dropped from the pool for.
+const (
+	TxDropUnderpriced        = "underpriced"             // Evicted by more valuable ones or below the price limit
+	TxDropUnpayable          = "insufficient funds"      // Balance or block gas limit can't cover it anymore
+	TxDropLifetime           = "lifetime expired"        // Queued for longer than the configured lifetime
+	TxDropAccountLimit       = "account limit reached"   // Sender exceeded its allowed pending or queued slots
+	TxDropPoolLimit          = "pool limit reached"      // Pool exceeded its allowed queued slots
+	TxDropReplaceUnderpriced = "replacement underpriced" // Lost against an executable one with the same nonce
+	TxDropNonceTooLow        = "nonce too low"           // Nonce was used by another transaction in the chain
+)
+
+// txHistory is a bounded record of the transactions that left the pool without
+// being mined, keeping the last event of each for later inspection.
+type txHistory struct {
+	events *lru.Cache // Transaction hash to last pool event mapping
+}
+
+// newTxHistory creates a transaction history remembering the given number of
+// most recently removed transactions.
+func newTxHistory(limit int) *txHistory {
+	events, _ := lru.New(limit)
+	return &txHistory{events: events}
+}
+
+// add records the event that made a transaction leave the pool.
+func (h *txHistory) add(event TxPoolEvent) {
+	h.events.Add(event.Hash, event)
+}
+
+// get retrieves the last recorded event of a removed transaction, or nil if the
+// transaction is unknown (or forgotten).
+func (h *txHistory) get(hash common.Hash) *TxPoolEvent {
+	if event, ok := h.events.Get(hash); ok {
+		ev := event.(TxPoolEvent)
+		return &ev
+	}
+	return nil
+}
+
+// forget discards any recorded event of a transaction re-entering the pool.
+func (h *txHistory) forget(hash common.Hash) {
+	h.events.Remove(hash)
+}
+
+// newTxPoolEvent creates a pool event for a status change happening now.
+func newTxPoolEvent(hash common.Hash, status string, reason string) TxPoolEvent {
+	return TxPoolEvent{Hash: hash, Status: status, Reason: reason, Time: time.Now()}
+}
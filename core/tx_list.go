@@ -365,38 +365,42 @@ func (l *txList) Flatten() types.Transactions {
 }
 
 // priceHeap is a heap.Interface implementation over transactions for retrieving
-// price-sorted transactions to discard when the pool fills up.
-type priceHeap []*types.Transaction
+// the least valuable transactions (according to the pool policy) to discard when
+// the pool fills up.
+type priceHeap struct {
+	list []*types.Transaction               // Transactions in heap order
+	less func(a, b *types.Transaction) bool // Policy ordering of the transactions
+}
 
-func (h priceHeap) Len() int           { return len(h) }
-func (h priceHeap) Less(i, j int) bool { return h[i].GasPrice().Cmp(h[j].GasPrice()) < 0 }
-func (h priceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *priceHeap) Len() int           { return len(h.list) }
+func (h *priceHeap) Less(i, j int) bool { return h.less(h.list[i], h.list[j]) }
+func (h *priceHeap) Swap(i, j int)      { h.list[i], h.list[j] = h.list[j], h.list[i] }
 
 func (h *priceHeap) Push(x interface{}) {
-	*h = append(*h, x.(*types.Transaction))
+	h.list = append(h.list, x.(*types.Transaction))
 }
 
 func (h *priceHeap) Pop() interface{} {
-	old := *h
+	old := h.list
 	n := len(old)
 	x := old[n-1]
-	*h = old[0 : n-1]
+	h.list = old[0 : n-1]
 	return x
 }
 
-// txPricedList is a price-sorted heap to allow operating on transactions pool
-// contents in a price-incrementing way.
+// txPricedList is a value-sorted heap to allow operating on transactions pool
+// contents in a value-incrementing way, as defined by the pool policy.
 type txPricedList struct {
 	all    *map[common.Hash]*types.Transaction // Pointer to the map of all transactions
 	items  *priceHeap                          // Heap of prices of all the stored transactions
 	stales int                                 // Number of stale price points to (re-heap trigger)
 }
 
-// newTxPricedList creates a new price-sorted transaction heap.
-func newTxPricedList(all *map[common.Hash]*types.Transaction) *txPricedList {
+// newTxPricedList creates a new transaction heap sorted by the given ordering.
+func newTxPricedList(all *map[common.Hash]*types.Transaction, less func(a, b *types.Transaction) bool) *txPricedList {
 	return &txPricedList{
 		all:   all,
-		items: new(priceHeap),
+		items: &priceHeap{less: less},
 	}
 }
 
@@ -411,60 +415,56 @@ func (l *txPricedList) Put(tx *types.Transaction) {
 func (l *txPricedList) Removed() {
 	// Bump the stale counter, but exit if still too low (< 25%)
 	l.stales++
-	if l.stales <= len(*l.items)/4 {
+	if l.stales <= l.items.Len()/4 {
 		return
 	}
 	// Seems we've reached a critical number of stale transactions, reheap
-	reheap := make(priceHeap, 0, len(*l.all))
+	reheap := &priceHeap{list: make([]*types.Transaction, 0, len(*l.all)), less: l.items.less}
 
-	l.stales, l.items = 0, &reheap
+	l.stales, l.items = 0, reheap
 	for _, tx := range *l.all {
-		*l.items = append(*l.items, tx)
+		l.items.list = append(l.items.list, tx)
 	}
 	heap.Init(l.items)
 }
 
 // Cap finds all the transactions below the given price threshold, drops them
 // from the priced list and returs them for further removal from the entire pool.
+// As the heap isn't necessarily ordered by gas price, all items are checked.
 func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transactions {
 	drop := make(types.Transactions, 0, 128) // Remote underpriced transactions to drop
-	save := make(types.Transactions, 0, 64)  // Local underpriced transactions to keep
+	keep := l.items.list[:0]                 // Transactions to retain in the heap
 
-	for len(*l.items) > 0 {
-		// Discard stale transactions if found during cleanup
-		tx := heap.Pop(l.items).(*types.Transaction)
+	for _, tx := range l.items.list {
+		// Retain stale transactions, they will be cleaned up by the stale counting
 		if _, ok := (*l.all)[tx.Hash()]; !ok {
-			l.stales--
+			keep = append(keep, tx)
 			continue
 		}
-		// Stop the discards if we've reached the threshold
-		if tx.GasPrice().Cmp(threshold) >= 0 {
-			save = append(save, tx)
-			break
-		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
-			save = append(save, tx)
-		} else {
+		// Non stale transaction found, discard if underpriced, unless local
+		if tx.GasPrice().Cmp(threshold) < 0 && !local.containsTx(tx) {
 			drop = append(drop, tx)
+		} else {
+			keep = append(keep, tx)
 		}
 	}
-	for _, tx := range save {
-		heap.Push(l.items, tx)
+	if len(drop) > 0 {
+		l.items.list = keep
+		heap.Init(l.items)
 	}
 	return drop
 }
 
-// Underpriced checks whether a transaction is cheaper than (or as cheap as) the
-// lowest priced transaction currently being tracked.
+// Underpriced checks whether a transaction is less valuable than (or as valuable
+// as) the least valuable transaction currently being tracked.
 func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
 	// Local transactions cannot be underpriced
 	if local.containsTx(tx) {
 		return false
 	}
 	// Discard stale price points if found at the heap start
-	for len(*l.items) > 0 {
-		head := []*types.Transaction(*l.items)[0]
+	for l.items.Len() > 0 {
+		head := l.items.list[0]
 		if _, ok := (*l.all)[head.Hash()]; !ok {
 			l.stales--
 			heap.Pop(l.items)
@@ -473,21 +473,21 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 		break
 	}
 	// Check if the transaction is underpriced or not
-	if len(*l.items) == 0 {
+	if l.items.Len() == 0 {
 		log.Error("Pricing query for empty pool") // This cannot happen, print to catch programming errors
 		return false
 	}
-	cheapest := []*types.Transaction(*l.items)[0]
-	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
+	cheapest := l.items.list[0]
+	return !l.items.less(cheapest, tx)
 }
 
-// Discard finds a number of most underpriced transactions, removes them from the
+// Discard finds a number of least valuable transactions, removes them from the
 // priced list and returns them for further removal from the entire pool.
 func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
 	drop := make(types.Transactions, 0, count) // Remote underpriced transactions to drop
 	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
 
-	for len(*l.items) > 0 && count > 0 {
+	for l.items.Len() > 0 && count > 0 {
 		// Discard stale transactions if found during cleanup
 		tx := heap.Pop(l.items).(*types.Transaction)
 		if _, ok := (*l.all)[tx.Hash()]; !ok {
@@ -0,0 +1,241 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+)
+
+var (
+	// ErrSenderCapExceeded is returned if a remote transaction is rejected by the
+	// fairness policy as its sender already has too many transactions pooled.
+	ErrSenderCapExceeded = errors.New("sender transaction cap exceeded")
+
+	// ErrRemoteDisallowed is returned if a remote transaction is rejected by a
+	// policy only accepting locally submitted transactions.
+	ErrRemoteDisallowed = errors.New("remote transactions disallowed")
+)
+
+// DefaultTxPolicy is the name of the transaction pool policy used if none (or an
+// unknown one) is configured, retaining the original gas price based eviction.
+const DefaultTxPolicy = "price"
+
+// TxPoolView is a read only view into the transaction pool, exposed to policies
+// to base their admission decisions on. It must only be used for the duration
+// of the policy call it was passed into.
+type TxPoolView interface {
+	// Pooled returns the number of pending and queued transactions of an account.
+	Pooled(addr common.Address) int
+
+	// Replaces returns whether a transaction would replace an already pooled one
+	// with the same nonce.
+	Replaces(addr common.Address, tx *types.Transaction) bool
+
+	// Local returns whether an account is marked local, exempting its transactions
+	// from eviction.
+	Local(addr common.Address) bool
+}
+
+// TxPolicy defines the admission, ordering and eviction rules of the transaction
+// pool. Policies are invoked with the pool lock held, so they must not call back
+// into the pool.
+type TxPolicy interface {
+	// Name returns the name the policy was registered with.
+	Name() string
+
+	// Admit decides whether a transaction that passed the consensus and pricing
+	// validations may enter the pool, returning the reason if not.
+	Admit(tx *types.Transaction, from common.Address, local bool, pool TxPoolView) error
+
+	// Less reports whether transaction a is less valuable than transaction b. The
+	// least valuable remote transactions are evicted first when the pool is full,
+	// and new ones not more valuable than those are rejected.
+	Less(a, b *types.Transaction) bool
+}
+
+// TxPolicyConstructor creates a transaction pool policy from the configuration
+// of the pool it will be used by.
+type TxPolicyConstructor func(config *TxPoolConfig) TxPolicy
+
+var (
+	txPolicies   = make(map[string]TxPolicyConstructor) // Registered transaction pool policies
+	txPolicyLock sync.RWMutex                           // Protects the policy registry
+)
+
+func init() {
+	RegisterTxPolicy(DefaultTxPolicy, func(*TxPoolConfig) TxPolicy { return priceTxPolicy{} })
+	RegisterTxPolicy("feeperbyte", func(*TxPoolConfig) TxPolicy { return feePerByteTxPolicy{} })
+	RegisterTxPolicy("fairness", func(config *TxPoolConfig) TxPolicy { return &fairnessTxPolicy{cap: config.SenderCap} })
+	RegisterTxPolicy("locals", func(*TxPoolConfig) TxPolicy { return localsTxPolicy{} })
+}
+
+// RegisterTxPolicy makes a transaction pool policy selectable by name through
+// the TxPoolConfig. It panics if a policy with the same name already exists.
+func RegisterTxPolicy(name string, constructor TxPolicyConstructor) {
+	txPolicyLock.Lock()
+	defer txPolicyLock.Unlock()
+
+	if _, ok := txPolicies[name]; ok {
+		panic(fmt.Sprintf("transaction pool policy %q already registered", name))
+	}
+	txPolicies[name] = constructor
+}
+
+// TxPolicies returns the names of all the registered transaction pool policies,
+// sorted alphabetically.
+func TxPolicies() []string {
+	txPolicyLock.RLock()
+	defer txPolicyLock.RUnlock()
+
+	names := make([]string, 0, len(txPolicies))
+	for name := range txPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newTxPolicy creates the named transaction pool policy, or returns nil if no
+// such policy was registered.
+func newTxPolicy(name string, config *TxPoolConfig) TxPolicy {
+	txPolicyLock.RLock()
+	constructor, ok := txPolicies[name]
+	txPolicyLock.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return constructor(config)
+}
+
+// txPoolView is the TxPoolView implementation handed out by the transaction pool
+// to its policies. The pool lock is assumed to be held while it's in use.
+type txPoolView struct {
+	pool *TxPool
+}
+
+// Pooled implements TxPoolView, counting both the executable and the future
+// transactions of an account.
+func (v txPoolView) Pooled(addr common.Address) int {
+	count := 0
+	if list := v.pool.pending[addr]; list != nil {
+		count += list.Len()
+	}
+	if list := v.pool.queue[addr]; list != nil {
+		count += list.Len()
+	}
+	return count
+}
+
+// Replaces implements TxPoolView, checking whether a transaction overlaps with
+// an executable or future one of the same account.
+func (v txPoolView) Replaces(addr common.Address, tx *types.Transaction) bool {
+	if list := v.pool.pending[addr]; list != nil && list.Overlaps(tx) {
+		return true
+	}
+	if list := v.pool.queue[addr]; list != nil && list.Overlaps(tx) {
+		return true
+	}
+	return false
+}
+
+// Local implements TxPoolView, checking whether an account is marked local.
+func (v txPoolView) Local(addr common.Address) bool {
+	return v.pool.locals.contains(addr)
+}
+
+// priceTxPolicy is the default transaction pool policy, admitting everything
+// and evicting the transactions with the lowest gas price first.
+type priceTxPolicy struct{}
+
+func (priceTxPolicy) Name() string { return DefaultTxPolicy }
+
+func (priceTxPolicy) Admit(*types.Transaction, common.Address, bool, TxPoolView) error {
+	return nil
+}
+
+func (priceTxPolicy) Less(a, b *types.Transaction) bool {
+	return a.GasPrice().Cmp(b.GasPrice()) < 0
+}
+
+// feePerByteTxPolicy admits everything, but evicts the transactions paying the
+// lowest maximum fee (gas price times gas limit) per byte of encoding first.
+type feePerByteTxPolicy struct{}
+
+func (feePerByteTxPolicy) Name() string { return "feeperbyte" }
+
+func (feePerByteTxPolicy) Admit(*types.Transaction, common.Address, bool, TxPoolView) error {
+	return nil
+}
+
+func (feePerByteTxPolicy) Less(a, b *types.Transaction) bool {
+	// Cross multiply the fees and sizes to avoid the division: fa/sa < fb/sb
+	fa := new(big.Int).Mul(a.GasPrice(), a.Gas())
+	fb := new(big.Int).Mul(b.GasPrice(), b.Gas())
+
+	fa.Mul(fa, new(big.Int).SetUint64(uint64(b.Size())))
+	fb.Mul(fb, new(big.Int).SetUint64(uint64(a.Size())))
+
+	return fa.Cmp(fb) < 0
+}
+
+// fairnessTxPolicy orders transactions by gas price, but caps the number of
+// transactions a single remote sender may have in the pool, preventing one
+// account from crowding out all others. Replacements are always admitted.
+type fairnessTxPolicy struct {
+	cap uint64 // Maximum number of pooled transactions per remote sender
+}
+
+func (p *fairnessTxPolicy) Name() string { return "fairness" }
+
+func (p *fairnessTxPolicy) Admit(tx *types.Transaction, from common.Address, local bool, pool TxPoolView) error {
+	if local || pool.Local(from) {
+		return nil
+	}
+	if uint64(pool.Pooled(from)) >= p.cap && !pool.Replaces(from, tx) {
+		return ErrSenderCapExceeded
+	}
+	return nil
+}
+
+func (p *fairnessTxPolicy) Less(a, b *types.Transaction) bool {
+	return a.GasPrice().Cmp(b.GasPrice()) < 0
+}
+
+// localsTxPolicy only admits transactions submitted locally or originating from
+// accounts already marked local, ordering them by gas price.
+type localsTxPolicy struct{}
+
+func (localsTxPolicy) Name() string { return "locals" }
+
+func (localsTxPolicy) Admit(tx *types.Transaction, from common.Address, local bool, pool TxPoolView) error {
+	if local || pool.Local(from) {
+		return nil
+	}
+	return ErrRemoteDisallowed
+}
+
+func (localsTxPolicy) Less(a, b *types.Transaction) bool {
+	return a.GasPrice().Cmp(b.GasPrice()) < 0
+}
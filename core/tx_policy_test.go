@@ -0,0 +1,179 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/event"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+// setupPolicyTxPool creates a transaction pool with the given configuration and
+// a number of funded accounts to test policies with.
+func setupPolicyTxPool(config TxPoolConfig, accounts int) (*TxPool, []*ecdsa.PrivateKey) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(10000000))
+	}
+	return pool, keys
+}
+
+// Tests that unknown policies fall back to the default one, and that the policy
+// in use is reported.
+func TestTxPolicySelection(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range TxPolicies() {
+		config := testTxPoolConfig
+		config.Policy = name
+
+		pool, _ := setupPolicyTxPool(config, 0)
+		if have := pool.Policy(); have != name {
+			t.Errorf("policy mismatch: have %s, want %s", have, name)
+		}
+		pool.Stop()
+	}
+	config := testTxPoolConfig
+	config.Policy = "nonexistent"
+
+	pool, _ := setupPolicyTxPool(config, 0)
+	defer pool.Stop()
+
+	if have := pool.Policy(); have != DefaultTxPolicy {
+		t.Errorf("fallback policy mismatch: have %s, want %s", have, DefaultTxPolicy)
+	}
+}
+
+// Tests that the fairness policy caps the number of transactions pooled from a
+// single remote sender, but still admits replacements and local transactions.
+func TestTxPolicyFairness(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.Policy = "fairness"
+	config.SenderCap = 2
+
+	pool, keys := setupPolicyTxPool(config, 2)
+	defer pool.Stop()
+
+	// Fill up the allowance of the remote sender and ensure it's enforced
+	for i := uint64(0); i < config.SenderCap; i++ {
+		if err := pool.AddRemote(transaction(i, big.NewInt(100000), keys[0])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(transaction(config.SenderCap, big.NewInt(100000), keys[0])); err != ErrSenderCapExceeded {
+		t.Fatalf("capped transaction error mismatch: have %v, want %v", err, ErrSenderCapExceeded)
+	}
+	if err := pool.AddRemote(pricedTransaction(1, big.NewInt(100000), big.NewInt(2), keys[0])); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	// Ensure local transactions are exempt from the cap
+	for i := uint64(0); i <= config.SenderCap; i++ {
+		if err := pool.AddLocal(transaction(i, big.NewInt(100000), keys[1])); err != nil {
+			t.Fatalf("failed to add local transaction %d: %v", i, err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != int(2*config.SenderCap+1) {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2*config.SenderCap+1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the locals policy rejects all remote transactions not originating
+// from local accounts.
+func TestTxPolicyLocals(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.Policy = "locals"
+
+	pool, keys := setupPolicyTxPool(config, 2)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(transaction(0, big.NewInt(100000), keys[0])); err != ErrRemoteDisallowed {
+		t.Fatalf("remote transaction error mismatch: have %v, want %v", err, ErrRemoteDisallowed)
+	}
+	if err := pool.AddLocal(transaction(0, big.NewInt(100000), keys[1])); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	// Transactions of local accounts arriving from the network are accepted too
+	if err := pool.AddRemote(transaction(1, big.NewInt(100000), keys[1])); err != nil {
+		t.Fatalf("failed to add remote transaction of local account: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+}
+
+// Tests that the fee per byte policy evicts the transactions paying the lowest
+// total fee per byte, instead of the lowest gas price.
+func TestTxPolicyFeePerByteEviction(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.Policy = "feeperbyte"
+	config.GlobalSlots = 1
+	config.GlobalQueue = 1
+
+	pool, keys := setupPolicyTxPool(config, 3)
+	defer pool.Stop()
+
+	// High gas price, but small total fee, and vice versa
+	cheap := pricedTransaction(0, big.NewInt(100000), big.NewInt(2), keys[0])
+	dear := pricedTransaction(0, big.NewInt(500000), big.NewInt(1), keys[1])
+
+	if err := pool.AddRemote(cheap); err != nil {
+		t.Fatalf("failed to add cheap transaction: %v", err)
+	}
+	if err := pool.AddRemote(dear); err != nil {
+		t.Fatalf("failed to add dear transaction: %v", err)
+	}
+	// Ensure a transaction paying less than the cheapest one is rejected
+	if err := pool.AddRemote(pricedTransaction(0, big.NewInt(50000), big.NewInt(3), keys[2])); err != ErrUnderpriced {
+		t.Fatalf("underpriced transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Ensure a transaction paying more evicts the lowest fee one
+	if err := pool.AddRemote(pricedTransaction(0, big.NewInt(300000), big.NewInt(2), keys[2])); err != nil {
+		t.Fatalf("failed to add well paying transaction: %v", err)
+	}
+	if pool.Get(cheap.Hash()) != nil {
+		t.Errorf("lowest fee transaction not evicted")
+	}
+	if pool.Get(dear.Hash()) == nil {
+		t.Errorf("highest fee transaction evicted")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
@@ -101,6 +101,7 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewCounter("txpool/invalid")
 	underpricedTxCounter = metrics.NewCounter("txpool/underpriced")
+	policyTxCounter      = metrics.NewCounter("txpool/policy") // Refused by the pool policy
 )
 
 // TxStatus is the current status of a transaction as seen py the pool.
@@ -127,7 +128,10 @@ type blockChain interface {
 type TxPoolConfig struct {
 	NoLocals  bool          // Whether local transaction handling should be disabled
 	Journal   string        // Journal of local transactions to survive node restarts
-	Rejournal time.Duration // Time interval to regenerate the local transaction journal
+	Rejournal time.Duration // Time interval to regenerate the local transaction journal and remote snapshot
+
+	Snapshot      string // Snapshot of remote transactions to survive node restarts (empty = disabled)
+	SnapshotLimit uint64 // Maximum number of remote transactions to retain in the snapshot
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -138,6 +142,9 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Policy    string // Name of the admission, ordering and eviction policy to use
+	SenderCap uint64 // Maximum number of transactions per remote sender (fairness policy)
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -146,6 +153,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	SnapshotLimit: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -155,6 +164,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	Policy:    DefaultTxPolicy,
+	SenderCap: 64,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -165,6 +177,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.Snapshot != "" && conf.SnapshotLimit < 1 {
+		log.Warn("Sanitizing invalid txpool snapshot limit", "provided", conf.SnapshotLimit, "updated", DefaultTxPoolConfig.SnapshotLimit)
+		conf.SnapshotLimit = DefaultTxPoolConfig.SnapshotLimit
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -173,6 +189,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if newTxPolicy(conf.Policy, &conf) == nil {
+		log.Warn("Sanitizing unknown txpool policy", "provided", conf.Policy, "updated", DefaultTxPolicy)
+		conf.Policy = DefaultTxPolicy
+	}
+	if conf.SenderCap < 1 {
+		log.Warn("Sanitizing invalid txpool sender cap", "provided", conf.SenderCap, "updated", DefaultTxPoolConfig.SenderCap)
+		conf.SenderCap = DefaultTxPoolConfig.SenderCap
+	}
 	return conf
 }
 
@@ -189,6 +213,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	eventFeed    event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -199,14 +224,23 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas *big.Int            // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exepmt from evicion rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals   *accountSet // Set of local transaction to exepmt from evicion rules
+	journal  *txJournal  // Journal of local transaction to back up to disk
+	snapshot *txSnapshot // Snapshot of remote transactions to back up to disk
+	policy   TxPolicy    // Admission, ordering and eviction rules of the pool
 
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
 	beats   map[common.Address]time.Time       // Last heartbeat from each known account
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	priced  *txPricedList                      // All transactions sorted by price
+	history *txHistory                         // Recently dropped or replaced transactions
+	mined   map[common.Hash]struct{}           // Transactions included by the chain head being reset to
+
+	events     []TxPoolEvent // Status changes not yet sent to subscribers, in order
+	eventsLock sync.Mutex    // Lock protecting the pending status changes
+	eventsWake chan struct{} // Notification channel for new status changes
+	eventsQuit chan struct{} // Termination channel for the status change sender
 
 	wg sync.WaitGroup // for shutdown sync
 
@@ -229,11 +263,15 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
 		all:         make(map[common.Hash]*types.Transaction),
+		history:     newTxHistory(txHistoryLimit),
+		eventsWake:  make(chan struct{}, 1),
+		eventsQuit:  make(chan struct{}),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
-	pool.priced = newTxPricedList(&pool.all)
+	pool.policy = newTxPolicy(config.Policy, &config)
+	pool.priced = newTxPricedList(&pool.all, pool.policy.Less)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// If local transactions and journaling is enabled, load from disk
@@ -247,12 +285,21 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transaction snapshotting is enabled, restore the last one
+	if config.Snapshot != "" {
+		pool.snapshot = newTxSnapshot(config.Snapshot, config.SnapshotLimit)
+
+		if err := pool.snapshot.load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load transaction snapshot", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
-	// Start the event loop and return
-	pool.wg.Add(1)
+	// Start the event loops and return
+	pool.wg.Add(2)
 	go pool.loop()
+	go pool.eventLoop()
 
 	return pool
 }
@@ -320,13 +367,14 @@ func (pool *TxPool) loop() {
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
+						pool.dropped(tx.Hash(), TxDropLifetime)
 						pool.removeTx(tx.Hash())
 					}
 				}
 			}
 			pool.mu.Unlock()
 
-		// Handle local transaction journal rotation
+		// Handle local transaction journal rotation and remote checkpointing
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
@@ -335,6 +383,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.snapshot != nil {
+				pool.mu.RLock()
+				if err := pool.snapshot.save(pool.remote(), pool.policy.Less); err != nil {
+					log.Warn("Failed to checkpoint remote tx snapshot", "err", err)
+				}
+				pool.mu.RUnlock()
+			}
 		}
 	}
 }
@@ -352,7 +407,7 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
+	var reinject, included types.Transactions
 
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
@@ -363,7 +418,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 			log.Warn("Skipping deep transaction reorg", "depth", depth)
 		} else {
 			// Reorg seems shallow enough to pull in all transactions into memory
-			var discarded, included types.Transactions
+			var discarded types.Transactions
 
 			var (
 				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number.Uint64())
@@ -402,6 +457,19 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock().Header() // Special case during testing
 	}
+	// Gather the transactions the chain progression included, to tell mined ones
+	// apart from those whose nonce was used up by a competing transaction
+	if oldHead == nil || oldHead.Hash() == newHead.ParentHash {
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			included = block.Transactions()
+		}
+	}
+	pool.mined = make(map[common.Hash]struct{}, len(included))
+	for _, tx := range included {
+		pool.mined[tx.Hash()] = struct{}{}
+	}
+	defer func() { pool.mined = nil }()
+
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
@@ -438,11 +506,19 @@ func (pool *TxPool) Stop() {
 
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
+	close(pool.eventsQuit)
 	pool.wg.Wait()
 
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.snapshot != nil {
+		pool.mu.RLock()
+		if err := pool.snapshot.save(pool.remote(), pool.policy.Less); err != nil {
+			log.Warn("Failed to save remote tx snapshot", "err", err)
+		}
+		pool.mu.RUnlock()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -452,6 +528,12 @@ func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.Subscription
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxPoolEvent registers a subscription of TxPoolEvent and starts
+// sending the status changes of the pooled transactions to the given channel.
+func (pool *TxPool) SubscribeTxPoolEvent(ch chan<- TxPoolEvent) event.Subscription {
+	return pool.scope.Track(pool.eventFeed.Subscribe(ch))
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -468,6 +550,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
+		pool.dropped(tx.Hash(), TxDropUnderpriced)
 		pool.removeTx(tx.Hash())
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
@@ -490,6 +573,12 @@ func (pool *TxPool) Stats() (int, int) {
 	return pool.stats()
 }
 
+// Policy returns the name of the admission, ordering and eviction policy used
+// by the pool.
+func (pool *TxPool) Policy() string {
+	return pool.policy.Name()
+}
+
 // stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (pool *TxPool) stats() (int, int) {
@@ -521,6 +610,23 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool belonging to a
+// single account, returning its pending as well as queued transactions, sorted
+// by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var pending, queued types.Transactions
+	if list := pool.pending[addr]; list != nil {
+		pending = list.Flatten()
+	}
+	if list := pool.queue[addr]; list != nil {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -551,6 +657,24 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves all currently known remote transactions, groupped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], list.Flatten()...)
+		}
+	}
+	for addr, list := range pool.queue {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], list.Flatten()...)
+		}
+	}
+	return txs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -614,6 +738,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
+	// If the pool policy refuses the transaction, discard it
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if err := pool.policy.Admit(tx, from, local, txPoolView{pool}); err != nil {
+		log.Trace("Discarding transaction refused by policy", "hash", hash, "policy", pool.policy.Name(), "err", err)
+		policyTxCounter.Inc(1)
+		return false, err
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -627,11 +758,11 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
+			pool.dropped(tx.Hash(), TxDropUnderpriced)
 			pool.removeTx(tx.Hash())
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -643,11 +774,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if old != nil {
 			delete(pool.all, old.Hash())
 			pool.priced.Removed()
+			pool.replaced(old.Hash(), hash)
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.priced.Put(tx)
 		pool.journalTx(from, tx)
+		pool.moved(hash, TxEventPending)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
@@ -690,10 +823,12 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		delete(pool.all, old.Hash())
 		pool.priced.Removed()
+		pool.replaced(old.Hash(), hash)
 		queuedReplaceCounter.Inc(1)
 	}
 	pool.all[hash] = tx
 	pool.priced.Put(tx)
+	pool.moved(hash, TxEventQueued)
 	return old != nil, nil
 }
 
@@ -724,6 +859,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		// An older transaction was better, discard this
 		delete(pool.all, hash)
 		pool.priced.Removed()
+		pool.dropped(hash, TxDropReplaceUnderpriced)
 
 		pendingDiscardCounter.Inc(1)
 		return
@@ -732,6 +868,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		delete(pool.all, old.Hash())
 		pool.priced.Removed()
+		pool.replaced(old.Hash(), hash)
 
 		pendingReplaceCounter.Inc(1)
 	}
@@ -743,6 +880,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
+	pool.moved(hash, TxEventPending)
 
 	go pool.txFeed.Send(TxPreEvent{tx})
 }
@@ -838,7 +976,7 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 	for i, hash := range hashes {
 		if tx := pool.all[hash]; tx != nil {
 			from, _ := types.Sender(pool.signer, tx) // already validated
-			if pool.pending[from].txs.items[tx.Nonce()] != nil {
+			if list := pool.pending[from]; list != nil && list.txs.items[tx.Nonce()] != nil {
 				status[i] = TxStatusPending
 			} else {
 				status[i] = TxStatusQueued
@@ -857,6 +995,93 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all[hash]
 }
 
+// History retrieves the last recorded event of a transaction that was dropped
+// from or replaced in the pool, or nil if no such event is remembered.
+func (pool *TxPool) History(hash common.Hash) *TxPoolEvent {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.history.get(hash)
+}
+
+// moved notifies subscribers of a transaction changing its status within the
+// pool, forgetting any previous removal of it.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) moved(hash common.Hash, status string) {
+	pool.history.forget(hash)
+	pool.notify(newTxPoolEvent(hash, status, ""))
+}
+
+// dropped records the removal of a transaction from the pool for the given
+// reason and notifies subscribers. The caller is responsible for the removal.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) dropped(hash common.Hash, reason string) {
+	event := newTxPoolEvent(hash, TxEventDropped, reason)
+	pool.history.add(event)
+	pool.notify(event)
+}
+
+// forwarded records the removal of a transaction whose nonce was used by the
+// chain, as mined if the chain progression included it or as dropped otherwise.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) forwarded(hash common.Hash) {
+	if _, ok := pool.mined[hash]; ok {
+		pool.moved(hash, TxEventMined)
+		return
+	}
+	pool.dropped(hash, TxDropNonceTooLow)
+}
+
+// replaced records the replacement of a transaction by another one with the
+// same nonce and notifies subscribers.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) replaced(hash common.Hash, replacement common.Hash) {
+	event := newTxPoolEvent(hash, TxEventReplaced, "")
+	event.Replacement = replacement
+	pool.history.add(event)
+	pool.notify(event)
+}
+
+// notify queues a status change to be sent to the subscribers, preserving the
+// order of the changes without blocking on the subscribers.
+func (pool *TxPool) notify(event TxPoolEvent) {
+	pool.eventsLock.Lock()
+	pool.events = append(pool.events, event)
+	pool.eventsLock.Unlock()
+
+	select {
+	case pool.eventsWake <- struct{}{}:
+	default:
+	}
+}
+
+// eventLoop sends the queued status changes to the subscribers one by one, in
+// the order they happened.
+func (pool *TxPool) eventLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.eventsWake:
+			pool.eventsLock.Lock()
+			events := pool.events
+			pool.events = nil
+			pool.eventsLock.Unlock()
+
+			for _, event := range events {
+				pool.eventFeed.Send(event)
+			}
+
+		case <-pool.eventsQuit:
+			return
+		}
+	}
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash) {
@@ -923,6 +1148,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.forwarded(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -931,6 +1157,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.dropped(hash, TxDropUnpayable)
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -945,6 +1172,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				hash := tx.Hash()
 				delete(pool.all, hash)
 				pool.priced.Removed()
+				pool.dropped(hash, TxDropAccountLimit)
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -990,6 +1218,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							hash := tx.Hash()
 							delete(pool.all, hash)
 							pool.priced.Removed()
+							pool.dropped(hash, TxDropAccountLimit)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
@@ -1012,6 +1241,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						hash := tx.Hash()
 						delete(pool.all, hash)
 						pool.priced.Removed()
+						pool.dropped(hash, TxDropAccountLimit)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -1050,6 +1280,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Drop all transactions if they are less than the overflow
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
+					pool.dropped(tx.Hash(), TxDropPoolLimit)
 					pool.removeTx(tx.Hash())
 				}
 				drop -= size
@@ -1059,6 +1290,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+				pool.dropped(txs[i].Hash(), TxDropPoolLimit)
 				pool.removeTx(txs[i].Hash())
 				drop--
 				queuedRateLimitCounter.Inc(1)
@@ -1081,6 +1313,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.forwarded(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1089,6 +1322,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.dropped(hash, TxDropUnpayable)
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
@@ -1563,6 +1563,222 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that remote transactions are snapshotted to disk on shutdown, capped to
+// the most valuable ones, and revalidated when restored.
+func TestTransactionSnapshotting(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot, we only need the path for now
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	snapshot := file.Name()
+	defer os.Remove(snapshot)
+
+	file.Close()
+	os.Remove(snapshot)
+
+	// Create the original pool to inject transaction into the snapshot
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Snapshot = snapshot
+	config.SnapshotLimit = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	// Create a local and two remote accounts, one paying more than the other
+	local, _ := crypto.GenerateKey()
+	dear, _ := crypto.GenerateKey()
+	cheap, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(dear.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(cheap.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddLocal(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.AddRemote(pricedTransaction(i, big.NewInt(100000), big.NewInt(1), cheap)); err != nil {
+			t.Fatalf("failed to add cheap remote transaction: %v", err)
+		}
+		if err := pool.AddRemote(pricedTransaction(i, big.NewInt(100000), big.NewInt(2), dear)); err != nil {
+			t.Fatalf("failed to add dear remote transaction: %v", err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 5 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 5)
+	}
+	// Terminate the old pool, bump a remote nonce, create a new pool and ensure
+	// only the capped, still valid remote transactions survive
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(dear.PublicKey), 1)
+	blockchain = &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if txs := pool.pending[crypto.PubkeyToAddress(dear.PublicKey)]; txs == nil || txs.Len() != 1 {
+		t.Fatalf("dear remote transactions not restored")
+	}
+	if txs := pool.pending[crypto.PubkeyToAddress(cheap.PublicKey)]; txs == nil || txs.Len() != 1 {
+		t.Fatalf("cheap remote transactions not capped")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the fate of transactions leaving the pool is recorded and that the
+// status changes are announced to subscribers.
+func TestTransactionHistory(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000000))
+
+	events := make(chan TxPoolEvent, 16)
+	sub := pool.SubscribeTxPoolEvent(events)
+	defer sub.Unsubscribe()
+
+	// Add a transaction, replace it and then invalidate the replacement
+	original := pricedTransaction(0, big.NewInt(100000), big.NewInt(1), key)
+	if err := pool.AddRemote(original); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	replacement := pricedTransaction(0, big.NewInt(100000), big.NewInt(2), key)
+	if err := pool.AddRemote(replacement); err != nil {
+		t.Fatalf("failed to add replacement transaction: %v", err)
+	}
+	pool.currentState.SetNonce(account, 1)
+	pool.lockedReset(nil, nil)
+
+	// Ensure the history reflects what happened
+	if event := pool.History(original.Hash()); event == nil || event.Status != TxEventReplaced || event.Replacement != replacement.Hash() {
+		t.Errorf("original transaction history mismatch: have %+v, want replaced by %x", event, replacement.Hash())
+	}
+	if event := pool.History(replacement.Hash()); event == nil || event.Status != TxEventDropped || event.Reason != TxDropNonceTooLow {
+		t.Errorf("invalidated transaction history mismatch: have %+v, want dropped for low nonce", event)
+	}
+	if pending, queued := pool.ContentFrom(account); len(pending)+len(queued) != 0 {
+		t.Errorf("account content mismatch: have %d pending, %d queued; want none", len(pending), len(queued))
+	}
+	// Ensure all the status changes were announced in order
+	want := []struct {
+		hash   common.Hash
+		status string
+	}{
+		{original.Hash(), TxEventQueued},
+		{original.Hash(), TxEventPending},
+		{original.Hash(), TxEventReplaced},
+		{replacement.Hash(), TxEventPending},
+		{replacement.Hash(), TxEventDropped},
+	}
+	for i, want := range want {
+		select {
+		case event := <-events:
+			if event.Hash != want.hash || event.Status != want.status {
+				t.Errorf("event %d: mismatch: have %x %s, want %x %s", i, event.Hash, event.Status, want.hash, want.status)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout", i)
+		}
+	}
+}
+
+// testMinedChain is a test blockchain whose head block contains a predefined
+// set of transactions.
+type testMinedChain struct {
+	*testBlockChain
+	txs types.Transactions
+}
+
+func (bc *testMinedChain) CurrentBlock() *types.Block {
+	return types.NewBlock(&types.Header{
+		GasLimit: bc.gasLimit,
+	}, bc.txs, nil, nil)
+}
+
+func (bc *testMinedChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.CurrentBlock()
+}
+
+// Tests that only transactions included in the chain are reported as mined, and
+// ones invalidated by a competing transaction are recorded as dropped.
+func TestTransactionHistoryMined(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(account, big.NewInt(1000000000))
+
+	mined := transaction(0, big.NewInt(100000), key)
+	blockchain := &testMinedChain{
+		testBlockChain: &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)},
+		txs:            types.Transactions{mined},
+	}
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	events := make(chan TxPoolEvent, 16)
+	sub := pool.SubscribeTxPoolEvent(events)
+	defer sub.Unsubscribe()
+
+	// Add the included transaction and one from another account sharing its fate
+	other, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	competing := transaction(0, big.NewInt(100000), other)
+	for i, err := range pool.AddRemotes([]*types.Transaction{mined, competing}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	statedb.SetNonce(account, 1)
+	statedb.SetNonce(crypto.PubkeyToAddress(other.PublicKey), 1)
+	pool.lockedReset(nil, nil)
+
+	if event := pool.History(mined.Hash()); event != nil {
+		t.Errorf("mined transaction history mismatch: have %+v, want none", event)
+	}
+	if event := pool.History(competing.Hash()); event == nil || event.Status != TxEventDropped || event.Reason != TxDropNonceTooLow {
+		t.Errorf("competing transaction history mismatch: have %+v, want dropped for low nonce", event)
+	}
+	// Ensure the final status changes were announced accordingly
+	final := make(map[common.Hash]string)
+	for i := 0; i < 6; i++ {
+		select {
+		case event := <-events:
+			final[event.Hash] = event.Status
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout", i)
+		}
+	}
+	if status := final[mined.Hash()]; status != TxEventMined {
+		t.Errorf("mined transaction status mismatch: have %s, want %s", status, TxEventMined)
+	}
+	if status := final[competing.Hash()]; status != TxEventDropped {
+		t.Errorf("competing transaction status mismatch: have %s, want %s", status, TxEventDropped)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/log"
+	"github.com/teamnsrg/ethereum-p2p/rlp"
+)
+
+// txSnapshot is a point in time dump of the remote transactions in the pool,
+// allowing them to survive node restarts without having to be gossiped anew.
+// Contrary to the local journal, it is not appended to on every insertion, but
+// rather regenerated periodically and on shutdown.
+type txSnapshot struct {
+	path  string // Filesystem path to store the transactions at
+	limit int    // Maximum number of transactions to store in a snapshot
+}
+
+// newTxSnapshot creates a new remote transaction snapshot at the given path,
+// capped to the given number of transactions.
+func newTxSnapshot(path string, limit uint64) *txSnapshot {
+	return &txSnapshot{
+		path:  path,
+		limit: int(limit),
+	}
+}
+
+// load parses a transaction snapshot from disk, loading its contents into the
+// specified pool as a single batch. All transactions are revalidated by the
+// pool against its current state, dropping anything that became stale.
+func (snap *txSnapshot) load(add func([]*types.Transaction) []error) error {
+	// Skip the parsing if the snapshot file doesn't exist at all
+	if _, err := os.Stat(snap.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(snap.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	// Parse all the transactions, up to the configured limit
+	var (
+		stream  = rlp.NewStream(bufio.NewReader(input), 0)
+		txs     []*types.Transaction
+		failure error
+	)
+	for len(txs) < snap.limit {
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		txs = append(txs, tx)
+	}
+	// Import the transactions and count the ones that didn't make it
+	dropped := 0
+	for _, err := range add(txs) {
+		if err != nil {
+			log.Debug("Failed to add snapshotted transaction", "err", err)
+			dropped++
+		}
+	}
+	log.Info("Loaded remote transaction snapshot", "transactions", len(txs), "dropped", dropped)
+
+	return failure
+}
+
+// save regenerates the transaction snapshot from the given account transaction
+// lists. If they exceed the snapshot limit, the accounts with the most valuable
+// leading transactions (according to less) are retained first.
+func (snap *txSnapshot) save(all map[common.Address]types.Transactions, less func(a, b *types.Transaction) bool) error {
+	// Order the accounts by value, most valuable first
+	accounts := snapshotAccounts{lists: make([]types.Transactions, 0, len(all)), less: less}
+	for _, txs := range all {
+		if len(txs) > 0 {
+			accounts.lists = append(accounts.lists, txs)
+		}
+	}
+	sort.Sort(sort.Reverse(accounts))
+
+	// Generate a new snapshot and swap it in place of the old one
+	replacement, err := os.OpenFile(snap.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	output := bufio.NewWriter(replacement)
+
+	saved := 0
+	for _, txs := range accounts.lists {
+		// Trim the account's transactions if the limit was reached (nonce gaps
+		// would make the remainder unexecutable anyway)
+		if saved+len(txs) > snap.limit {
+			txs = txs[:snap.limit-saved]
+		}
+		for _, tx := range txs {
+			if err = rlp.Encode(output, tx); err != nil {
+				replacement.Close()
+				return err
+			}
+		}
+		if saved += len(txs); saved >= snap.limit {
+			break
+		}
+	}
+	if err = output.Flush(); err != nil {
+		replacement.Close()
+		return err
+	}
+	replacement.Close()
+
+	if err = os.Rename(snap.path+".new", snap.path); err != nil {
+		return err
+	}
+	log.Info("Regenerated remote transaction snapshot", "transactions", saved, "accounts", len(all))
+
+	return nil
+}
+
+// snapshotAccounts implements sort.Interface to order nonce sorted account
+// transaction lists by the value of their first transaction.
+type snapshotAccounts struct {
+	lists []types.Transactions
+	less  func(a, b *types.Transaction) bool
+}
+
+func (s snapshotAccounts) Len() int           { return len(s.lists) }
+func (s snapshotAccounts) Less(i, j int) bool { return s.less(s.lists[i][0], s.lists[j][0]) }
+func (s snapshotAccounts) Swap(i, j int)      { s.lists[i], s.lists[j] = s.lists[j], s.lists[i] }
@@ -44,6 +44,19 @@ func (d destinations) has(codehash common.Hash, code []byte, dest *big.Int) bool
 	return OpCode(code[udest]) == JUMPDEST && m.codeSegment(udest)
 }
 
+// JumpDests returns the positions of the JUMPDEST instructions in the code which
+// are valid jump destinations, i.e. which are not part of push data.
+func JumpDests(code []byte) map[uint64]bool {
+	bits := codeBitmap(code)
+	dests := make(map[uint64]bool)
+	for pc := uint64(0); pc < uint64(len(code)); pc++ {
+		if OpCode(code[pc]) == JUMPDEST && bits.codeSegment(pc) {
+			dests[pc] = true
+		}
+	}
+	return dests
+}
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
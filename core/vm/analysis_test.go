@@ -51,3 +51,13 @@ func TestJumpDestAnalysis(t *testing.T) {
 	}
 
 }
+
+func TestJumpDests(t *testing.T) {
+	// JUMPDEST, PUSH2 with a JUMPDEST byte, JUMPDEST, PUSH1 truncated by the end
+	code := []byte{byte(JUMPDEST), byte(PUSH2), byte(JUMPDEST), 0x00, byte(JUMPDEST), byte(PUSH1)}
+
+	dests := JumpDests(code)
+	if len(dests) != 2 || !dests[0] || !dests[4] {
+		t.Fatalf("jump destination mismatch: have %v, want [0 4]", dests)
+	}
+}
@@ -18,12 +18,15 @@ package vm
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/common/math"
 	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/crypto/blake2b"
 	"github.com/teamnsrg/ethereum-p2p/crypto/bn256"
 	"github.com/teamnsrg/ethereum-p2p/params"
 	"golang.org/x/crypto/ripemd160"
@@ -59,6 +62,73 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// precompiledContracts is the registry of the precompiled contracts which can be
+// activated by the chain configuration, indexed by name.
+var precompiledContracts = map[string]PrecompiledContract{
+	"ecrecover":      &ecrecover{},
+	"sha256":         &sha256hash{},
+	"ripemd160":      &ripemd160hash{},
+	"identity":       &dataCopy{},
+	"modexp":         &bigModExp{},
+	"bn256Add":       &bn256Add{},
+	"bn256ScalarMul": &bn256ScalarMul{},
+	"bn256Pairing":   &bn256Pairing{},
+	"blake2F":        &blake2F{},
+}
+
+// RegisterPrecompiledContract makes a precompiled contract available under the
+// given name, to be activated by chain configurations at an address and block.
+// It is meant to be called from package initializers and panics if the name is
+// already taken.
+func RegisterPrecompiledContract(name string, p PrecompiledContract) {
+	if _, ok := precompiledContracts[name]; ok {
+		panic(fmt.Sprintf("precompiled contract %q registered twice", name))
+	}
+	precompiledContracts[name] = p
+}
+
+// CheckPrecompiles returns an error if the chain configuration activates any
+// precompiled contract not registered.
+func CheckPrecompiles(config *params.ChainConfig) error {
+	for addr, precompile := range config.Precompiles {
+		if precompile == nil || precompiledContracts[precompile.Name] == nil {
+			return fmt.Errorf("unknown precompiled contract configured at %x", addr)
+		}
+	}
+	return nil
+}
+
+// ActivePrecompiles returns the precompiled contracts active at the given block:
+// the default ones of the current fork, extended (or overridden) by the ones the
+// chain configuration activated until then.
+func ActivePrecompiles(config *params.ChainConfig, number *big.Int) map[common.Address]PrecompiledContract {
+	precompiles := PrecompiledContractsHomestead
+	if config.IsByzantium(number) {
+		precompiles = PrecompiledContractsByzantium
+	}
+	var active map[common.Address]PrecompiledContract
+	for addr, precompile := range config.Precompiles {
+		if precompile == nil || precompile.Block == nil || number == nil || precompile.Block.Cmp(number) > 0 {
+			continue
+		}
+		p := precompiledContracts[precompile.Name]
+		if p == nil {
+			continue // Rejected by CheckPrecompiles on chain setup
+		}
+		if active == nil {
+			active = make(map[common.Address]PrecompiledContract, len(precompiles)+len(config.Precompiles))
+			for addr, p := range precompiles {
+				active[addr] = p
+			}
+		}
+		active[addr] = p
+	}
+	if active == nil {
+		return precompiles
+	}
+	return active
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -377,3 +447,54 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+// blake2FInputLength is the exact length of the BLAKE2b compression input: the
+// rounds, the state, the message block, the offset counters and the final flag.
+const blake2FInputLength = 4 + 64 + 128 + 16 + 1
+
+var (
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+	errBlake2FInvalidFinalFlag   = errors.New("invalid final flag")
+)
+
+// blake2F implements the BLAKE2b compression function F as a native contract.
+type blake2F struct{}
+
+func (c *blake2F) RequiredGas(input []byte) uint64 {
+	if len(input) != blake2FInputLength {
+		return 0 // Rejected in Run, charge nothing
+	}
+	return uint64(binary.BigEndian.Uint32(input[:4])) * params.Blake2FRoundGas
+}
+
+func (c *blake2F) Run(input []byte) ([]byte, error) {
+	if len(input) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if input[212] > 1 {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	var (
+		rounds = binary.BigEndian.Uint32(input[:4])
+		final  = input[212] == 1
+		h      [8]uint64
+		m      [16]uint64
+		t      [2]uint64
+	)
+	for i := range h {
+		h[i] = binary.LittleEndian.Uint64(input[4+i*8:])
+	}
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(input[68+i*8:])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:])
+	t[1] = binary.LittleEndian.Uint64(input[204:])
+
+	blake2b.F(&h, &m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := range h {
+		binary.LittleEndian.PutUint64(output[i*8:], h[i])
+	}
+	return output, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -465,3 +466,67 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// blake2FTests are the test vectors of the BLAKE2b compression function EIP 152.
+var blake2FTests = []precompiledTest{
+	{
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "abc_12_rounds",
+	},
+}
+
+// Tests the sample inputs of the BLAKE2b compression function EIP 152.
+func TestPrecompiledBlake2F(t *testing.T) {
+	p := precompiledContracts["blake2F"]
+	for _, test := range blake2FTests {
+		in := common.Hex2Bytes(test.input)
+		if gas := p.RequiredGas(in); gas != 12 {
+			t.Errorf("%s: gas mismatch: have %d, want %d", test.name, gas, 12)
+		}
+		contract := NewContract(AccountRef(common.HexToAddress("1337")), nil, new(big.Int), p.RequiredGas(in))
+		if res, err := RunPrecompiledContract(p, in, contract); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if common.Bytes2Hex(res) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, common.Bytes2Hex(res))
+		}
+	}
+	// Malformed inputs must be rejected
+	valid := common.Hex2Bytes(blake2FTests[0].input)
+	if _, err := p.Run(valid[1:]); err != errBlake2FInvalidInputLength {
+		t.Errorf("short input: error mismatch: have %v, want %v", err, errBlake2FInvalidInputLength)
+	}
+	invalid := common.CopyBytes(valid)
+	invalid[212] = 2
+	if _, err := p.Run(invalid); err != errBlake2FInvalidFinalFlag {
+		t.Errorf("invalid final flag: error mismatch: have %v, want %v", err, errBlake2FInvalidFinalFlag)
+	}
+}
+
+// Tests that precompiled contracts configured by the chain config are activated
+// at their block, without touching the default sets.
+func TestActivePrecompiles(t *testing.T) {
+	addr := common.BytesToAddress([]byte{9})
+	config := &params.ChainConfig{
+		ByzantiumBlock: big.NewInt(0),
+		Precompiles: map[common.Address]*params.PrecompileConfig{
+			addr: {Name: "blake2F", Block: big.NewInt(5)},
+		},
+	}
+	if err := CheckPrecompiles(config); err != nil {
+		t.Fatalf("failed to check precompiles: %v", err)
+	}
+	if p := ActivePrecompiles(config, big.NewInt(4))[addr]; p != nil {
+		t.Errorf("precompile active before its block: %T", p)
+	}
+	if p := ActivePrecompiles(config, big.NewInt(5))[addr]; p != precompiledContracts["blake2F"] {
+		t.Errorf("precompile mismatch at its block: have %T, want blake2F", p)
+	}
+	if p := PrecompiledContractsByzantium[addr]; p != nil {
+		t.Errorf("default precompiles modified: %T", p)
+	}
+	config.Precompiles[addr] = &params.PrecompileConfig{Name: "nonexistent", Block: big.NewInt(5)}
+	if err := CheckPrecompiles(config); err == nil {
+		t.Errorf("unknown precompile accepted")
+	}
+}
@@ -742,7 +742,12 @@ func opStop(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Sta
 
 func opSuicide(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	balance := evm.StateDB.GetBalance(contract.Address())
-	evm.StateDB.AddBalance(common.BigToAddress(stack.pop()), balance)
+	beneficiary := common.BigToAddress(stack.pop())
+	if evm.vmConfig.Debug {
+		evm.vmConfig.Tracer.CaptureEnter(evm, SELFDESTRUCT, contract.Address(), beneficiary, nil, 0, balance)
+		evm.vmConfig.Tracer.CaptureExit(evm, nil, 0, nil)
+	}
+	evm.StateDB.AddBalance(beneficiary, balance)
 
 	evm.StateDB.Suicide(contract.Address())
 	return nil, nil
@@ -0,0 +1,108 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/common/math"
+)
+
+// JSONLogger is an EVM state logger streaming the execution steps in the standard
+// JSON trace format shared across clients (EIP-3155): one JSON object per line
+// for every executed opcode, followed by a summary line at the end of execution.
+type JSONLogger struct {
+	encoder *json.Encoder
+	cfg     *LogConfig
+	err     error // Execution error of the outermost call frame
+}
+
+// NewJSONLogger creates a new EVM tracer that prints the execution steps as JSON
+// objects into the provided stream.
+func NewJSONLogger(cfg *LogConfig, writer io.Writer) *JSONLogger {
+	if cfg == nil {
+		cfg = new(LogConfig)
+	}
+	return &JSONLogger{encoder: json.NewEncoder(writer), cfg: cfg}
+}
+
+// CaptureEnter is triggered when entering a call frame, not logged.
+func (l *JSONLogger) CaptureEnter(env *EVM, typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState outputs state information on the logger.
+func (l *JSONLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	log := StructLog{
+		Pc:         pc,
+		Op:         op,
+		Gas:        gas,
+		GasCost:    cost,
+		MemorySize: memory.Len(),
+		Stack:      []*big.Int{},
+		Depth:      depth,
+		Err:        err,
+	}
+	if env.StateDB != nil {
+		log.RefundCounter = env.StateDB.GetRefund().Uint64()
+	}
+	if !l.cfg.DisableMemory {
+		log.Memory = memory.Data()
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = stack.Data()
+	}
+	return l.encoder.Encode(log)
+}
+
+// CaptureExit is triggered when leaving a call frame, recording the execution
+// error of the outermost one for the summary.
+func (l *JSONLogger) CaptureExit(env *EVM, output []byte, gasUsed uint64, err error) error {
+	if env.depth == 0 {
+		l.err = err
+	}
+	return nil
+}
+
+// CaptureEnd is triggered at end of execution, printing the summary line. If no
+// error is given, the one the outermost call frame failed with is reported.
+func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	type endLog struct {
+		Output  hexutil.Bytes       `json:"output"`
+		GasUsed math.HexOrDecimal64 `json:"gasUsed"`
+		Time    time.Duration       `json:"time"`
+		Err     string              `json:"error,omitempty"`
+	}
+	if err == nil {
+		err = l.err
+	}
+	l.err = nil
+
+	summary := endLog{Output: output, GasUsed: math.HexOrDecimal64(gasUsed), Time: t}
+	if summary.Output == nil {
+		summary.Output = []byte{}
+	}
+	if err != nil {
+		summary.Err = err.Error()
+	}
+	return l.encoder.Encode(summary)
+}
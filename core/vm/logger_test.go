@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
@@ -68,3 +69,25 @@ func TestStoreCapture(t *testing.T) {
 		t.Errorf("expected %x, got %x", exp, logger.changedValues[contract.Address()][index])
 	}
 }
+
+func TestJSONLogger(t *testing.T) {
+	var (
+		out      = new(bytes.Buffer)
+		env      = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+		logger   = NewJSONLogger(&LogConfig{DisableMemory: true}, out)
+		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 0)
+		stack    = newstack()
+	)
+	stack.push(big.NewInt(1))
+
+	logger.CaptureState(env, 3, POP, 100, 2, NewMemory(), stack, contract, 1, nil)
+	logger.CaptureExit(env, nil, 98, errExecutionReverted)
+	logger.CaptureEnd(nil, 98, 0, nil)
+
+	want := `{"pc":3,"op":80,"gas":"0x64","gasCost":"0x2","memSize":0,"stack":["0x1"],"depth":1,"refund":0,"opName":"POP"}
+{"output":"0x","gasUsed":"0x62","time":0,"error":"evm: execution reverted"}
+`
+	if have := out.String(); have != want {
+		t.Errorf("trace mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
@@ -17,9 +17,11 @@
 package eth
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -34,6 +36,7 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/core/state"
 	"github.com/teamnsrg/ethereum-p2p/core/types"
 	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/eth/tracers"
 	"github.com/teamnsrg/ethereum-p2p/internal/ethapi"
 	"github.com/teamnsrg/ethereum-p2p/log"
 	"github.com/teamnsrg/ethereum-p2p/miner"
@@ -122,6 +125,10 @@ func (api *PublicMinerAPI) SubmitHashrate(hashrate hexutil.Uint64, id common.Has
 	return true
 }
 
+// errBundlesDisabled is returned if a transaction bundle is submitted to a miner
+// not configured to accept them.
+var errBundlesDisabled = errors.New("transaction bundles disabled (enable with --minerbundles)")
+
 // PrivateMinerAPI provides private RPC methods to control the miner.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateMinerAPI struct {
@@ -205,6 +212,97 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.e.miner.HashRate())
 }
 
+// SendBundle submits a list of RLP encoded signed transactions to be included
+// into a future block all together and in order, or not at all. If blockNumber
+// is non-zero, the bundle is only considered for that specific block.
+func (api *PrivateMinerAPI) SendBundle(encodedTxs []hexutil.Bytes, blockNumber hexutil.Uint64) error {
+	selector, ok := api.e.miner.TransactionSelector().(*miner.BundleSelector)
+	if !ok {
+		return errBundlesDisabled
+	}
+	txs := make(types.Transactions, len(encodedTxs))
+	for i, encoded := range encodedTxs {
+		txs[i] = new(types.Transaction)
+		if err := rlp.DecodeBytes(encoded, txs[i]); err != nil {
+			return fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+	return selector.Submit(&miner.Bundle{Txs: txs, BlockNumber: uint64(blockNumber)})
+}
+
+// BuildBlockArgs are the parameters of a block to assemble via BuildBlock. All
+// fields are optional, defaulting to the values the miner would use.
+type BuildBlockArgs struct {
+	Parent    *common.Hash    `json:"parent"`
+	Coinbase  *common.Address `json:"coinbase"`
+	Extra     *hexutil.Bytes  `json:"extraData"`
+	Timestamp *hexutil.Uint64 `json:"timestamp"`
+	GasLimit  *hexutil.Uint64 `json:"gasLimit"`
+}
+
+// BuildTxResult is the outcome of a transaction attempted to be included into a
+// block assembled via BuildBlock.
+type BuildTxResult struct {
+	Hash     common.Hash    `json:"hash"`
+	From     common.Address `json:"from"`
+	Included bool           `json:"included"`
+	GasUsed  *hexutil.Big   `json:"gasUsed,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// BuildBlock assembles and executes a block on top of the requested parent (or
+// the current head) using the miner's transaction selection, returning the block,
+// its receipts and the outcome of every attempted transaction. The block is not
+// sealed, imported nor broadcast.
+func (api *PrivateMinerAPI) BuildBlock(args BuildBlockArgs) (map[string]interface{}, error) {
+	var build miner.BuildArgs
+	if args.Parent != nil {
+		build.Parent = *args.Parent
+	}
+	if args.Coinbase != nil {
+		build.Coinbase = *args.Coinbase
+	} else if etherbase, err := api.e.Etherbase(); err == nil {
+		build.Coinbase = etherbase
+	}
+	if args.Extra != nil {
+		build.Extra = *args.Extra
+	}
+	if args.Timestamp != nil {
+		build.Time = uint64(*args.Timestamp)
+	}
+	if args.GasLimit != nil {
+		build.GasLimit = uint64(*args.GasLimit)
+	}
+	result, err := api.e.miner.BuildBlock(build)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ethapi.RPCMarshalBlock(result.Block, true, true)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]BuildTxResult, len(result.Results))
+	for i, res := range result.Results {
+		txs[i] = BuildTxResult{Hash: res.Hash, From: res.From, Included: res.Included}
+		if res.GasUsed != nil {
+			txs[i].GasUsed = (*hexutil.Big)(res.GasUsed)
+		}
+		if res.Err != nil {
+			txs[i].Error = res.Err.Error()
+		}
+	}
+	receipts := result.Receipts
+	if receipts == nil {
+		receipts = types.Receipts{}
+	}
+	return map[string]interface{}{
+		"block":        block,
+		"receipts":     receipts,
+		"gasUsed":      (*hexutil.Big)(result.GasUsed),
+		"transactions": txs,
+	}, nil
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -217,23 +315,19 @@ func NewPrivateAdminAPI(eth *Ethereum) *PrivateAdminAPI {
 	return &PrivateAdminAPI{eth: eth}
 }
 
-// ExportChain exports the current blockchain into a local file.
-func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
-	// Make sure we can create the file to export into
-	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
-	if err != nil {
-		return false, err
+// ExportChain exports the current blockchain, or the optionally given range of
+// it, into a local file as a verifiable chain archive.
+func (api *PrivateAdminAPI) ExportChain(file string, first *uint64, last *uint64) (bool, error) {
+	// Resolve the range of blocks to export
+	from, to := uint64(0), api.eth.BlockChain().CurrentBlock().NumberU64()
+	if first != nil {
+		from = *first
 	}
-	defer out.Close()
-
-	var writer io.Writer = out
-	if strings.HasSuffix(file, ".gz") {
-		writer = gzip.NewWriter(writer)
-		defer writer.(*gzip.Writer).Close()
+	if last != nil {
+		to = *last
 	}
-
 	// Export the blockchain
-	if err := api.eth.BlockChain().Export(writer); err != nil {
+	if err := api.eth.BlockChain().ExportArchiveFile(file, api.eth.NetVersion(), from, to); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -249,22 +343,24 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
-// ImportChain imports a blockchain from a local file.
-func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
+// ImportChain imports a blockchain from a local file, either a chain archive or
+// bare RLP encoded blocks. Chain archives are verified in full before import and
+// rejected if exported on a different network, unless force is set.
+func (api *PrivateAdminAPI) ImportChain(file string, force *bool) (bool, error) {
 	// Make sure the can access the file to import
-	in, err := os.Open(file)
+	in, err := core.OpenChainFile(file)
 	if err != nil {
 		return false, err
 	}
 	defer in.Close()
 
-	var reader io.Reader = in
-	if strings.HasSuffix(file, ".gz") {
-		if reader, err = gzip.NewReader(reader); err != nil {
+	reader := bufio.NewReader(in)
+	if core.IsChainArchive(reader) {
+		if _, err := api.eth.BlockChain().ImportArchiveFile(file, api.eth.NetVersion(), force != nil && *force, 2500, nil); err != nil {
 			return false, err
 		}
+		return true, nil
 	}
-
 	// Run actual the import in pre-configured batches
 	stream := rlp.NewStream(reader, 0)
 
@@ -359,8 +455,18 @@ type BlockTraceResult struct {
 // TraceArgs holds extra parameters to trace functions
 type TraceArgs struct {
 	*vm.LogConfig
-	Tracer  *string
+	Tracer  *string // Name of a native tracer, or JavaScript tracer code
 	Timeout *string
+	Reexec  *uint64 // Number of blocks to re-execute to regenerate missing state
+}
+
+// reexec returns the number of blocks the tracer may re-execute to regenerate a
+// historical state, defaulting if unset.
+func (config *TraceArgs) reexec() uint64 {
+	if config == nil || config.Reexec == nil {
+		return defaultTraceReexec
+	}
+	return *config.Reexec
 }
 
 // TraceBlock processes the given block'api RLP but does not import the block in to
@@ -450,7 +556,11 @@ func (api *PrivateDebugAPI) traceBlock(block *types.Block, logConfig *vm.LogConf
 	if err := api.eth.engine.VerifyHeader(blockchain, block.Header(), true); err != nil {
 		return false, structLogger.StructLogs(), err
 	}
-	statedb, err := blockchain.StateAt(blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1).Root())
+	parent := blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return false, structLogger.StructLogs(), fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := api.stateAtBlock(parent, defaultTraceReexec)
 	if err != nil {
 		return false, structLogger.StructLogs(), err
 	}
@@ -459,7 +569,7 @@ func (api *PrivateDebugAPI) traceBlock(block *types.Block, logConfig *vm.LogConf
 	if err != nil {
 		return false, structLogger.StructLogs(), err
 	}
-	if err := validator.ValidateState(block, blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1), statedb, receipts, usedGas); err != nil {
+	if err := validator.ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
 		return false, structLogger.StructLogs(), err
 	}
 	return true, structLogger.StructLogs(), nil
@@ -483,50 +593,140 @@ func (t *timeoutError) Error() string {
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.Hash, config *TraceArgs) (interface{}, error) {
-	var tracer vm.Tracer
-	if config != nil && config.Tracer != nil {
-		timeout := defaultTraceTimeout
-		if config.Timeout != nil {
-			var err error
-			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-				return nil, err
-			}
-		}
-
-		var err error
-		if tracer, err = ethapi.NewJavascriptTracer(*config.Tracer); err != nil {
-			return nil, err
-		}
-
-		// Handle timeouts and RPC cancellations
-		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
-		go func() {
-			<-deadlineCtx.Done()
-			tracer.(*ethapi.JavascriptTracer).Stop(&timeoutError{})
-		}()
-		defer cancel()
-	} else if config == nil {
-		tracer = vm.NewStructLogger(nil)
-	} else {
-		tracer = vm.NewStructLogger(config.LogConfig)
-	}
-
 	// Retrieve the tx from the chain and the containing block
 	tx, blockHash, _, txIndex := core.GetTransaction(api.eth.ChainDb(), txHash)
 	if tx == nil {
 		return nil, fmt.Errorf("transaction %x not found", txHash)
 	}
-	msg, context, statedb, err := api.computeTxEnv(blockHash, int(txIndex))
+	msg, context, statedb, err := api.computeTxEnv(blockHash, int(txIndex), config.reexec())
 	if err != nil {
 		return nil, err
 	}
-
 	// Run the transaction with tracing enabled.
+	return api.traceTx(ctx, msg, context, statedb, config)
+}
+
+// traceTx executes a transaction message on top of the given state with the
+// tracer requested by the trace arguments, returning the trace result.
+func (api *PrivateDebugAPI) traceTx(ctx context.Context, msg core.Message, context vm.Context, statedb *state.StateDB, config *TraceArgs) (interface{}, error) {
+	tracer, cancel, err := newTracer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
 	vmenv := vm.NewEVM(context, statedb, api.config, vm.Config{Debug: true, Tracer: tracer})
-	ret, gas, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+	ret, gas, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return traceResult(tracer, ret, gas, failed)
+}
+
+// TraceCallArgs holds extra parameters to call tracing, on top of the ones of
+// the other trace functions.
+type TraceCallArgs struct {
+	TraceArgs
+	StateOverrides *ethapi.StateOverride
+}
+
+// TraceCall executes the given call on top of the state of the requested block
+// and returns its trace, the same way as TraceTransaction does. The call is run
+// the same way as eth_call, but with gas metering enabled.
+func (api *PrivateDebugAPI) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallArgs) (interface{}, error) {
+	var (
+		traceConfig *TraceArgs
+		overrides   *ethapi.StateOverride
+	)
+	if config != nil {
+		traceConfig, overrides = &config.TraceArgs, config.StateOverrides
+	}
+	// Retrieve the state to execute the call on top of
+	var (
+		block   *types.Block
+		statedb *state.StateDB
+		header  *types.Header
+		err     error
+	)
+	switch {
+	case blockNrOrHash.BlockHash != nil:
+		if block = api.eth.blockchain.GetBlockByHash(*blockNrOrHash.BlockHash); block == nil {
+			return nil, fmt.Errorf("block %x not found", *blockNrOrHash.BlockHash)
+		}
+	case *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber:
+		statedb, header, err = api.eth.ApiBackend.StateAndHeaderByNumber(ctx, rpc.PendingBlockNumber)
+	default:
+		block, err = api.blockByNumber(*blockNrOrHash.BlockNumber)
+	}
+	if block != nil {
+		header = block.Header()
+		statedb, err = api.stateAtBlock(block, traceConfig.reexec())
+	}
+	if statedb == nil || err != nil {
+		if err == nil {
+			err = errors.New("block not found")
+		}
+		return nil, err
+	}
+	// Execute the call with tracing enabled
+	tracer, cancel, err := newTracer(ctx, traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	ret, gas, failed, err := ethapi.DoCall(ctx, api.eth.ApiBackend, args, statedb, header, overrides, vm.Config{Debug: true, Tracer: tracer})
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %v", err)
 	}
+	return traceResult(tracer, ret, gas, failed)
+}
+
+// newTracer creates the tracer requested by the trace arguments: a struct logger
+// by default, or the named native tracer, or a JavaScript one. Custom tracers are
+// stopped when the returned cancel function is called, the context is cancelled
+// or the trace timeout expires, whichever comes first.
+func newTracer(ctx context.Context, config *TraceArgs) (vm.Tracer, context.CancelFunc, error) {
+	if config == nil {
+		return vm.NewStructLogger(nil), func() {}, nil
+	}
+	if config.Tracer == nil {
+		return vm.NewStructLogger(config.LogConfig), func() {}, nil
+	}
+	timeout := defaultTraceTimeout
+	if config.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Use the native tracer if one is registered by the name, otherwise
+	// interpret the tracer as JavaScript code
+	var (
+		tracer vm.Tracer
+		stop   func(error)
+	)
+	if native, ok := tracers.New(*config.Tracer); ok {
+		tracer, stop = native, native.Stop
+	} else {
+		jst, err := ethapi.NewJavascriptTracer(*config.Tracer)
+		if err != nil {
+			return nil, nil, err
+		}
+		tracer, stop = jst, jst.Stop
+	}
+	// Handle timeouts and RPC cancellations
+	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
+	go func() {
+		<-deadlineCtx.Done()
+		stop(&timeoutError{})
+	}()
+	return tracer, cancel, nil
+}
+
+// traceResult assembles the result of a traced execution, depending on the type
+// of the tracer used.
+func traceResult(tracer vm.Tracer, ret []byte, gas *big.Int, failed bool) (interface{}, error) {
 	switch tracer := tracer.(type) {
 	case *vm.StructLogger:
 		return &ethapi.ExecutionResult{
@@ -537,13 +737,15 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 		}, nil
 	case *ethapi.JavascriptTracer:
 		return tracer.GetResult()
+	case tracers.Tracer:
+		return tracer.GetResult()
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
 }
 
 // computeTxEnv returns the execution environment of a certain transaction.
-func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.Message, vm.Context, *state.StateDB, error) {
+func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
 	// Create the parent state.
 	block := api.eth.BlockChain().GetBlockByHash(blockHash)
 	if block == nil {
@@ -553,7 +755,7 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (co
 	if parent == nil {
 		return nil, vm.Context{}, nil, fmt.Errorf("block parent %x not found", block.ParentHash())
 	}
-	statedb, err := api.eth.BlockChain().StateAt(parent.Root())
+	statedb, err := api.stateAtBlock(parent, reexec)
 	if err != nil {
 		return nil, vm.Context{}, nil, err
 	}
@@ -592,6 +794,47 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]core.BadBlockAr
 	return api.eth.BlockChain().BadBlocks()
 }
 
+// GetReorgHistory returns the most recent chain reorganisations the node went
+// through (oldest first), optionally limited to the last few.
+func (api *PrivateDebugAPI) GetReorgHistory(ctx context.Context, limit *int) []*core.ReorgRecord {
+	history := api.eth.BlockChain().ReorgHistory()
+	if limit != nil && *limit >= 0 && *limit < len(history) {
+		history = history[len(history)-*limit:]
+	}
+	return history
+}
+
+// GetPropagationRecord returns when and from which peer a recently seen transaction
+// or block was first received, along with its subsequent arrivals from others.
+func (api *PrivateDebugAPI) GetPropagationRecord(hash common.Hash) (*PropagationRecord, error) {
+	tracer := api.eth.protocolManager.propagation
+	if tracer == nil {
+		return nil, errPropagationTraceDisabled
+	}
+	return tracer.record(hash), nil
+}
+
+// ExportPropagation writes all the propagation records currently traced into a
+// local file as JSON lines, oldest first, returning the number of records.
+func (api *PrivateDebugAPI) ExportPropagation(file string) (int, error) {
+	tracer := api.eth.protocolManager.propagation
+	if tracer == nil {
+		return 0, errPropagationTraceDisabled
+	}
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var writer io.Writer = out
+	if strings.HasSuffix(file, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	return tracer.export(writer)
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -607,7 +850,7 @@ type storageEntry struct {
 
 // StorageRangeAt returns the storage at the given block height and transaction index.
 func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
-	_, _, statedb, err := api.computeTxEnv(blockHash, txIndex)
+	_, _, statedb, err := api.computeTxEnv(blockHash, txIndex, defaultTraceReexec)
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
@@ -0,0 +1,656 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/log"
+	"github.com/teamnsrg/ethereum-p2p/rlp"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+	"github.com/teamnsrg/ethereum-p2p/trie"
+)
+
+const (
+	// defaultTraceReexec is the number of blocks the tracer is willing to go back
+	// and re-execute to regenerate a historical state not available in the database.
+	defaultTraceReexec = uint64(128)
+
+	// maxRegenMemory is the maximum amount of state data regenerated in memory
+	// for tracing a single request.
+	maxRegenMemory = 256 * 1024 * 1024
+
+	// traceChainAhead is the number of blocks per tracing thread the chain tracer
+	// may prepare ahead of the first block not yet delivered to the subscriber.
+	traceChainAhead = 4
+)
+
+var (
+	// errPendingTrace is returned if the pending block is requested to be traced
+	// as a part of a chain segment.
+	errPendingTrace = errors.New("pending block cannot be traced")
+
+	// errRegenMemoryLimit is returned if regenerating a historical state would
+	// need more memory than allowed.
+	errRegenMemoryLimit = errors.New("state regeneration memory limit reached")
+)
+
+// txTraceResult is the result of tracing a single transaction.
+type txTraceResult struct {
+	TxHash common.Hash `json:"txHash"`           // Hash of the traced transaction
+	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
+	Error  string      `json:"error,omitempty"`  // Trace failure produced by the tracer
+}
+
+// blockTraceResult is the result of tracing all the transactions of a block.
+type blockTraceResult struct {
+	Number hexutil.Uint64   `json:"number"`          // Number of the traced block
+	Hash   common.Hash      `json:"hash"`            // Hash of the traced block
+	Traces []*txTraceResult `json:"traces"`          // Trace results of the transactions, in order
+	Error  string           `json:"error,omitempty"` // Failure aborting the chain tracing at this block
+}
+
+// blockTraceTask is a block to trace on top of its parent state.
+type blockTraceTask struct {
+	statedb *state.StateDB   // Parent state of the block, owned by the task
+	block   *types.Block     // Block to trace the transactions of
+	results []*txTraceResult // Trace results of the transactions, once done
+}
+
+// TraceChain creates a subscription streaming the traces of the transactions of
+// all the blocks after start, up to and including end. The blocks are traced in
+// parallel, but delivered in chain order, one notification per block. Tracing is
+// aborted when the subscription is cancelled. If a block cannot be traced, a last
+// notification carrying the error is sent for it.
+func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.BlockNumber, config *TraceArgs) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	from, err := api.blockByNumber(start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.blockByNumber(end)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() >= to.NumberU64() {
+		return nil, fmt.Errorf("end block #%d needs to come after start block #%d", to.NumberU64(), from.NumberU64())
+	}
+	// Retrieve the state to start tracing on top of, before subscribing
+	regen := newStateRegenerator(api)
+
+	statedb, err := regen.stateAt(from, config.reexec())
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+	go api.traceChain(notifier, rpcSub, regen, statedb, from, to, config)
+
+	return rpcSub, nil
+}
+
+// traceChain traces the blocks after from, up to and including to, on top of the
+// given state, streaming the results to the subscription. The states missing from
+// the database are regenerated along the way.
+func (api *PrivateDebugAPI) traceChain(notifier *rpc.Notifier, rpcSub *rpc.Subscription, regen *stateRegenerator, statedb *state.StateDB, from, to *types.Block, config *TraceArgs) {
+	// Any running tracer is stopped when the chain tracing is abandoned
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	threads := runtime.NumCPU()
+	if blocks := int(to.NumberU64() - from.NumberU64()); threads > blocks {
+		threads = blocks
+	}
+	var (
+		tasks   = make(chan *blockTraceTask, threads)
+		results = make(chan *blockTraceTask, threads)
+		slots   = make(chan struct{}, traceChainAhead*threads) // Blocks fed but not delivered yet
+		pend    sync.WaitGroup
+	)
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+
+			for task := range tasks {
+				task.results = api.traceBlockTxs(ctx, task.block, task.statedb, config)
+				select {
+				case results <- task:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	// Feed the blocks to the tracers, advancing the state in the meantime. The
+	// feeder may only run a limited number of blocks ahead of the delivery.
+	var (
+		failed   error
+		failedAt uint64
+	)
+	go func() {
+		defer close(tasks)
+
+		blockchain := api.eth.BlockChain()
+		for number := from.NumberU64() + 1; number <= to.NumberU64(); number++ {
+			block := blockchain.GetBlockByNumber(number)
+			if block == nil {
+				failed, failedAt = fmt.Errorf("block #%d not found", number), number
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case tasks <- &blockTraceTask{statedb: statedb.Copy(), block: block}:
+			case <-ctx.Done():
+				return
+			}
+			if number == to.NumberU64() {
+				return
+			}
+			// Prefer the state from the database, regenerate only if unavailable
+			if fresh, err := regen.open(block.Root()); err == nil {
+				statedb = fresh
+				continue
+			}
+			var err error
+			if statedb, err = regen.advance(statedb, block); err != nil {
+				failed, failedAt = err, number+1
+				return
+			}
+		}
+	}()
+	go func() {
+		pend.Wait()
+		close(results)
+	}()
+	// Deliver the trace results in chain order until done or unsubscribed
+	var (
+		done = make(map[uint64]*blockTraceTask)
+		next = from.NumberU64() + 1
+	)
+	for {
+		select {
+		case task, ok := <-results:
+			if !ok {
+				if failed != nil {
+					log.Warn("Chain tracing failed", "start", from.NumberU64(), "end", to.NumberU64(), "err", failed)
+					notifier.Notify(rpcSub.ID, &blockTraceResult{
+						Number: hexutil.Uint64(failedAt),
+						Error:  failed.Error(),
+					})
+				}
+				return
+			}
+			done[task.block.NumberU64()] = task
+			for task := done[next]; task != nil; task = done[next] {
+				notifier.Notify(rpcSub.ID, &blockTraceResult{
+					Number: hexutil.Uint64(task.block.NumberU64()),
+					Hash:   task.block.Hash(),
+					Traces: task.results,
+				})
+				delete(done, next)
+				next++
+				<-slots
+			}
+		case <-rpcSub.Err():
+			return
+		case <-notifier.Closed():
+			return
+		}
+	}
+}
+
+// traceBlockTxs traces all the transactions of a block on top of its parent state,
+// in order. Failing transactions are reported, but do not abort the tracing.
+func (api *PrivateDebugAPI) traceBlockTxs(ctx context.Context, block *types.Block, statedb *state.StateDB, config *TraceArgs) []*txTraceResult {
+	var (
+		signer  = types.MakeSigner(api.config, block.Number())
+		results = make([]*txTraceResult, 0, len(block.Transactions()))
+	)
+	for _, tx := range block.Transactions() {
+		if ctx.Err() != nil {
+			break
+		}
+		msg, _ := tx.AsMessage(signer)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.BlockChain(), nil)
+
+		result := &txTraceResult{TxHash: tx.Hash()}
+		if res, err := api.traceTx(ctx, msg, vmctx, statedb, config); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Result = res
+		}
+		results = append(results, result)
+
+		statedb.Finalise(api.config.IsEIP158(block.Number()))
+	}
+	return results
+}
+
+// StdTraceConfig holds the extra parameters of the standard JSON trace functions.
+type StdTraceConfig struct {
+	*vm.LogConfig
+	Reexec *uint64      // Number of blocks to re-execute to regenerate missing state
+	TxHash *common.Hash // Only trace this transaction of the block, if set
+}
+
+// StandardTraceBlockToFile re-executes the transactions of a block, writing the
+// standard JSON trace (EIP-3155) of each into a separate file in the temporary
+// directory, allowing the traces to be diffed against other clients. The names
+// of the created files are returned.
+func (api *PrivateDebugAPI) StandardTraceBlockToFile(ctx context.Context, hash common.Hash, config *StdTraceConfig) ([]string, error) {
+	block := api.eth.BlockChain().GetBlockByHash(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	parent := api.eth.BlockChain().GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	var (
+		logConfig *vm.LogConfig
+		txHash    *common.Hash
+		reexec    = defaultTraceReexec
+	)
+	if config != nil {
+		logConfig, txHash = config.LogConfig, config.TxHash
+		if config.Reexec != nil {
+			reexec = *config.Reexec
+		}
+	}
+	statedb, err := api.stateAtBlock(parent, reexec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		signer = types.MakeSigner(api.config, block.Number())
+		dumps  []string
+	)
+	for i, tx := range block.Transactions() {
+		if err := ctx.Err(); err != nil {
+			return dumps, err
+		}
+		msg, _ := tx.AsMessage(signer)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.BlockChain(), nil)
+
+		// Trace the transaction into its own file, unless filtered out
+		var (
+			vmConf vm.Config
+			tracer *vm.JSONLogger
+			dump   *os.File
+			writer *bufio.Writer
+		)
+		if txHash == nil || *txHash == tx.Hash() {
+			prefix := fmt.Sprintf("block_%#x-%d-%#x-", hash.Bytes()[:4], i, tx.Hash().Bytes()[:4])
+			if dump, err = ioutil.TempFile(os.TempDir(), prefix); err != nil {
+				return dumps, err
+			}
+			dumps = append(dumps, dump.Name())
+
+			writer = bufio.NewWriter(dump)
+			tracer = vm.NewJSONLogger(logConfig, writer)
+			vmConf = vm.Config{Debug: true, Tracer: tracer}
+		}
+		start := time.Now()
+		vmenv := vm.NewEVM(vmctx, statedb, api.config, vmConf)
+		ret, gas, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+
+		if tracer != nil {
+			used := uint64(0)
+			if gas != nil {
+				used = gas.Uint64()
+			}
+			tracer.CaptureEnd(ret, used, time.Since(start), err)
+			writer.Flush()
+			dump.Close()
+			log.Info("Wrote standard trace", "file", dump.Name())
+		}
+		if err != nil {
+			return dumps, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		// Stop if the requested transaction was traced, finalise the state otherwise
+		if txHash != nil && *txHash == tx.Hash() {
+			return dumps, nil
+		}
+		statedb.Finalise(api.config.IsEIP158(block.Number()))
+	}
+	if txHash != nil {
+		return nil, fmt.Errorf("transaction %x not found in block %x", *txHash, hash)
+	}
+	return dumps, nil
+}
+
+// blockByNumber retrieves a block of the canonical chain to trace.
+func (api *PrivateDebugAPI) blockByNumber(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+	switch number {
+	case rpc.PendingBlockNumber:
+		return nil, errPendingTrace
+	case rpc.LatestBlockNumber:
+		block = api.eth.BlockChain().CurrentBlock()
+	default:
+		block = api.eth.BlockChain().GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// stateAtBlock retrieves the state after the given block was executed, going back
+// at most reexec blocks to regenerate it if it's not available in the database.
+func (api *PrivateDebugAPI) stateAtBlock(block *types.Block, reexec uint64) (*state.StateDB, error) {
+	return newStateRegenerator(api).stateAt(block, reexec)
+}
+
+// stateRegenerator re-executes blocks to regenerate historical states, keeping
+// the trie nodes of the produced states in a memory capped database overlay. When
+// the overlay fills up, it is replaced by one holding only the live state.
+//
+// All the states advanced by a regenerator must be opened by it too, otherwise
+// the regenerated trie nodes could leak into the caches of other state databases.
+type stateRegenerator struct {
+	api *PrivateDebugAPI
+	db  *regenDatabase // In-memory overlay over the chain database
+	sdb state.Database // State database reading from the overlay
+}
+
+// newStateRegenerator creates a state regenerator with an empty overlay.
+func newStateRegenerator(api *PrivateDebugAPI) *stateRegenerator {
+	db := newRegenDatabase(api.eth.ChainDb(), maxRegenMemory)
+	return &stateRegenerator{api: api, db: db, sdb: state.NewDatabase(db)}
+}
+
+// open opens the state with the given root, if available in the database or
+// already regenerated.
+func (r *stateRegenerator) open(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, r.sdb)
+}
+
+// stateAt retrieves the state after the given block was executed. If it is not
+// available in the database, it is regenerated in memory by re-executing the
+// blocks on top of the nearest ancestor with available state, going back at most
+// reexec blocks.
+func (r *stateRegenerator) stateAt(block *types.Block, reexec uint64) (*state.StateDB, error) {
+	if statedb, err := r.open(block.Root()); err == nil {
+		return statedb, nil
+	}
+	// Walk back to the nearest ancestor with available state
+	var (
+		blockchain = r.api.eth.BlockChain()
+		path       []*types.Block
+		origin     = block
+		statedb    *state.StateDB
+	)
+	for statedb == nil {
+		if uint64(len(path)) >= reexec || origin.NumberU64() == 0 {
+			return nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
+		}
+		path = append(path, origin)
+		if origin = blockchain.GetBlock(origin.ParentHash(), origin.NumberU64()-1); origin == nil {
+			return nil, fmt.Errorf("missing block %x", path[len(path)-1].ParentHash())
+		}
+		statedb, _ = r.open(origin.Root())
+	}
+	// Re-execute the blocks on top of the ancestor state
+	var (
+		start  = time.Now()
+		logged time.Time
+		err    error
+	)
+	for i := len(path) - 1; i >= 0; i-- {
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Regenerating historical state", "block", path[i].NumberU64(), "target", block.NumberU64(), "remaining", i+1, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if statedb, err = r.advance(statedb, path[i]); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Historical state regenerated", "block", block.NumberU64(), "elapsed", common.PrettyDuration(time.Since(start)), "size", common.StorageSize(r.db.Size()))
+	return statedb, nil
+}
+
+// advance re-executes a block on top of its parent state, returning its post
+// state. The post state is committed into the overlay, allowing the caches of
+// the parent state to be released.
+func (r *stateRegenerator) advance(statedb *state.StateDB, block *types.Block) (*state.StateDB, error) {
+	// Drop the states no longer needed if the overlay is filling up
+	if r.db.Size() > r.db.limit/2 {
+		parent := r.api.eth.BlockChain().GetHeader(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return nil, fmt.Errorf("missing block %x", block.ParentHash())
+		}
+		var err error
+		if statedb, err = r.prune(parent.Root); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.api.processBlock(block, statedb); err != nil {
+		return nil, err
+	}
+	root, err := statedb.CommitTo(r.db, r.api.config.IsEIP158(block.Number()))
+	if err != nil {
+		return nil, fmt.Errorf("state regeneration failed at block #%d: %v", block.NumberU64(), err)
+	}
+	return r.open(root)
+}
+
+// prune replaces the overlay with a fresh one holding only the regenerated data of
+// the state with the given root, returning the state opened from the new overlay.
+// States opened from the old overlay remain usable.
+func (r *stateRegenerator) prune(root common.Hash) (*state.StateDB, error) {
+	start, size := time.Now(), r.db.Size()
+
+	fresh := newRegenDatabase(r.db.Database, r.db.limit)
+	if err := r.db.copyTrie(fresh, root, true); err != nil {
+		return nil, err
+	}
+	r.db, r.sdb = fresh, state.NewDatabase(fresh)
+
+	log.Debug("Pruned regenerated states", "root", root, "before", common.StorageSize(size), "after", common.StorageSize(fresh.Size()), "elapsed", common.PrettyDuration(time.Since(start)))
+	return r.open(root)
+}
+
+// processBlock re-executes a block on top of its parent state, advancing it to
+// the block's post state.
+func (api *PrivateDebugAPI) processBlock(block *types.Block, statedb *state.StateDB) error {
+	if _, _, _, err := api.eth.BlockChain().Processor().Process(block, statedb, vm.Config{}); err != nil {
+		return fmt.Errorf("processing block #%d failed: %v", block.NumberU64(), err)
+	}
+	if root := statedb.IntermediateRoot(api.config.IsEIP158(block.Number())); root != block.Root() {
+		return fmt.Errorf("state root mismatch for block #%d: have %x, want %x", block.NumberU64(), root, block.Root())
+	}
+	return nil
+}
+
+// regenDatabase is an in-memory database overlay on top of the chain database,
+// holding the data of regenerated historical states. Reads fall through to the
+// chain database, writes are kept in memory up to a size limit.
+type regenDatabase struct {
+	ethdb.Database // Chain database to read through to
+
+	mem   *ethdb.MemDatabase
+	size  int        // Total size of the data written into memory
+	limit int        // Maximum size of the data written into memory
+	lock  sync.Mutex // Protects the size accounting
+}
+
+// newRegenDatabase creates an empty overlay on top of the given database.
+func newRegenDatabase(db ethdb.Database, limit int) *regenDatabase {
+	mem, _ := ethdb.NewMemDatabase()
+	return &regenDatabase{Database: db, mem: mem, limit: limit}
+}
+
+// Put implements ethdb.Putter, storing the data in memory.
+func (db *regenDatabase) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	if db.size+len(key)+len(value) > db.limit {
+		db.lock.Unlock()
+		return errRegenMemoryLimit
+	}
+	db.size += len(key) + len(value)
+	db.lock.Unlock()
+
+	return db.mem.Put(key, value)
+}
+
+// Get implements ethdb.Database, retrieving the data from memory or the chain.
+func (db *regenDatabase) Get(key []byte) ([]byte, error) {
+	if value, err := db.mem.Get(key); err == nil {
+		return value, nil
+	}
+	return db.Database.Get(key)
+}
+
+// Has implements ethdb.Database, checking the data in memory and in the chain.
+func (db *regenDatabase) Has(key []byte) (bool, error) {
+	if ok, _ := db.mem.Has(key); ok {
+		return true, nil
+	}
+	return db.Database.Has(key)
+}
+
+// Delete implements ethdb.Database, deleting the data only from memory.
+func (db *regenDatabase) Delete(key []byte) error {
+	return db.mem.Delete(key)
+}
+
+// Close implements ethdb.Database, leaving the chain database open.
+func (db *regenDatabase) Close() {}
+
+// NewBatch implements ethdb.Database, creating a batch writing into memory.
+func (db *regenDatabase) NewBatch() ethdb.Batch {
+	return &regenBatch{db: db, batch: db.mem.NewBatch()}
+}
+
+// copyTrie copies the trie nodes held in memory of the trie with the given root
+// into another overlay. Nodes read from the chain database are not descended
+// into, as their entire subtries are available there. For state tries, the data
+// of the referenced storage tries and contract codes is copied too.
+func (db *regenDatabase) copyTrie(dest *regenDatabase, root common.Hash, accounts bool) error {
+	if ok, _ := db.mem.Has(root[:]); !ok {
+		return nil
+	}
+	t, err := trie.NewSecure(root, db, 0)
+	if err != nil {
+		return err
+	}
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		// Copy the standalone nodes in memory, skipping the subtries of the others
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			copied, err := db.copyData(dest, hash[:])
+			if err != nil {
+				return err
+			}
+			if descend = copied; !descend {
+				continue
+			}
+		}
+		if !accounts || !it.Leaf() {
+			continue
+		}
+		// Copy the storage and code of the accounts changed in memory
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		if err := db.copyTrie(dest, account.Root, false); err != nil {
+			return err
+		}
+		if _, err := db.copyData(dest, account.CodeHash); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// copyData copies a single entry held in memory into another overlay, reporting
+// whether the entry was found in memory.
+func (db *regenDatabase) copyData(dest *regenDatabase, key []byte) (bool, error) {
+	blob, err := db.mem.Get(key)
+	if err != nil {
+		return false, nil
+	}
+	if ok, _ := dest.mem.Has(key); ok {
+		return true, nil
+	}
+	return true, dest.Put(key, blob)
+}
+
+// Size returns the total size of the data held in memory.
+func (db *regenDatabase) Size() int {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.size
+}
+
+// regenBatch is a batch writing into the memory of a regeneration overlay,
+// subject to its size limit.
+type regenBatch struct {
+	db    *regenDatabase
+	batch ethdb.Batch
+	size  int
+}
+
+// Put implements ethdb.Putter, queueing the data for writing.
+func (b *regenBatch) Put(key, value []byte) error {
+	b.size += len(key) + len(value)
+	return b.batch.Put(key, value)
+}
+
+// ValueSize implements ethdb.Batch, returning the amount of data queued.
+func (b *regenBatch) ValueSize() int {
+	return b.batch.ValueSize()
+}
+
+// Write implements ethdb.Batch, writing the queued data into memory if it fits
+// within the overlay's size limit.
+func (b *regenBatch) Write() error {
+	b.db.lock.Lock()
+	if b.db.size+b.size > b.db.limit {
+		b.db.lock.Unlock()
+		return errRegenMemoryLimit
+	}
+	b.db.size += b.size
+	b.db.lock.Unlock()
+
+	return b.batch.Write()
+}
@@ -0,0 +1,293 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/consensus/ethash"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/params"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+)
+
+// newTestTraceChain creates a chain of the given length with a value transfer in
+// each block, dropping the state of the requested blocks from the database.
+func newTestTraceChain(t *testing.T, blocks int, pruned []int) (*Ethereum, []*types.Block) {
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		engine = ethash.NewFaker()
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{testBank: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	gendb, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(gendb)
+	chain, _ := core.GenerateChain(gspec.Config, genesis, gendb, blocks, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(testBank), common.Address{0x01}, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil), signer, testBankKey)
+		gen.AddTx(tx)
+	})
+	blockchain, _ := core.NewBlockChain(db, gspec.Config, engine, vm.Config{})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	blockchain.Stop()
+
+	// Drop the requested states and reopen the chain to avoid cached tries
+	for _, number := range pruned {
+		db.Delete(chain[number-1].Root().Bytes())
+	}
+	blockchain, _ = core.NewBlockChain(db, gspec.Config, engine, vm.Config{})
+	return &Ethereum{blockchain: blockchain, chainDb: db}, chain
+}
+
+// Tests that chain tracing streams the traces of all the requested blocks in
+// order, regenerating the states missing from the database.
+func TestTraceChain(t *testing.T) {
+	eth, chain := newTestTraceChain(t, 8, []int{1, 2, 3, 5, 6})
+	defer eth.blockchain.Stop()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", NewPrivateDebugAPI(params.TestChainConfig, eth)); err != nil {
+		t.Fatalf("failed to register debug API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	results := make(chan *blockTraceResult)
+	tracer := "callTracer"
+	sub, err := client.Subscribe(context.Background(), "debug", results, "traceChain", hexutil.Uint64(2), hexutil.Uint64(7), &TraceArgs{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to subscribe to chain traces: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for number := uint64(3); number <= 7; number++ {
+		select {
+		case result := <-results:
+			block := chain[number-1]
+			if uint64(result.Number) != number || result.Hash != block.Hash() {
+				t.Fatalf("block mismatch: have #%d [%x], want #%d [%x]", result.Number, result.Hash, number, block.Hash())
+			}
+			if len(result.Traces) != 1 || result.Traces[0].TxHash != block.Transactions()[0].Hash() {
+				t.Fatalf("block #%d: trace mismatch: %v", number, result.Traces)
+			}
+			if trace := result.Traces[0]; trace.Error != "" || trace.Result.(map[string]interface{})["type"] != "CALL" {
+				t.Errorf("block #%d: call trace mismatch: %+v", number, trace)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: trace timeout", number)
+		}
+	}
+	// Ensure states too far back are not regenerated
+	api := NewPrivateDebugAPI(params.TestChainConfig, eth)
+	if _, err := api.stateAtBlock(chain[2], 2); err == nil {
+		t.Errorf("state regenerated beyond the reexec limit")
+	}
+	if _, err := api.stateAtBlock(chain[2], 3); err != nil {
+		t.Errorf("failed to regenerate state: %v", err)
+	}
+}
+
+// Tests that chain tracing reports a block failing to be traced in a last
+// notification, after delivering the blocks preceding it.
+func TestTraceChainFailure(t *testing.T) {
+	eth, chain := newTestTraceChain(t, 8, nil)
+	defer eth.blockchain.Stop()
+
+	core.DeleteBody(eth.chainDb, chain[4].Hash(), 5)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", NewPrivateDebugAPI(params.TestChainConfig, eth)); err != nil {
+		t.Fatalf("failed to register debug API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	results := make(chan *blockTraceResult)
+	sub, err := client.Subscribe(context.Background(), "debug", results, "traceChain", hexutil.Uint64(2), hexutil.Uint64(7), &TraceArgs{})
+	if err != nil {
+		t.Fatalf("failed to subscribe to chain traces: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for number := uint64(3); number <= 5; number++ {
+		select {
+		case result := <-results:
+			if uint64(result.Number) != number {
+				t.Fatalf("block number mismatch: have #%d, want #%d", result.Number, number)
+			}
+			if number < 5 && result.Error != "" {
+				t.Fatalf("block #%d: unexpected error: %s", number, result.Error)
+			}
+			if number == 5 && result.Error != "block #5 not found" {
+				t.Fatalf("block #%d: error mismatch: have %q, want %q", number, result.Error, "block #5 not found")
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: trace timeout", number)
+		}
+	}
+	select {
+	case result := <-results:
+		t.Fatalf("unexpected result after failure: %+v", result)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that transactions can be traced on top of historical states missing from
+// the database, as long as they are within the reexec limit.
+func TestTraceTransactionRegeneration(t *testing.T) {
+	eth, chain := newTestTraceChain(t, 4, []int{1, 2, 3})
+	defer eth.blockchain.Stop()
+
+	api := NewPrivateDebugAPI(params.TestChainConfig, eth)
+	hash := chain[3].Transactions()[0].Hash()
+
+	tracer, reexec := "callTracer", uint64(2)
+	if _, err := api.TraceTransaction(context.Background(), hash, &TraceArgs{Tracer: &tracer, Reexec: &reexec}); err == nil {
+		t.Errorf("transaction traced beyond the reexec limit")
+	}
+	reexec = 3
+	if _, err := api.TraceTransaction(context.Background(), hash, &TraceArgs{Tracer: &tracer, Reexec: &reexec}); err != nil {
+		t.Errorf("failed to trace transaction: %v", err)
+	}
+	// Ensure the regenerated states don't leak into the chain
+	if _, err := eth.blockchain.StateAt(chain[2].Root()); err == nil {
+		t.Errorf("regenerated state leaked into the chain")
+	}
+	// Ensure the memory used for regeneration is capped
+	db := newRegenDatabase(eth.chainDb, 64)
+	if err := db.Put(make([]byte, 32), make([]byte, 32)); err != nil {
+		t.Fatalf("failed to write within limit: %v", err)
+	}
+	if err := db.Put(make([]byte, 1), nil); err != errRegenMemoryLimit {
+		t.Errorf("memory limit error mismatch: have %v, want %v", err, errRegenMemoryLimit)
+	}
+	batch := newRegenDatabase(eth.chainDb, 64).NewBatch()
+	batch.Put(make([]byte, 32), make([]byte, 33))
+	if err := batch.Write(); err != errRegenMemoryLimit {
+		t.Errorf("batch memory limit error mismatch: have %v, want %v", err, errRegenMemoryLimit)
+	}
+}
+
+// Tests that regenerating states over a range needing more memory than allowed
+// succeeds by pruning the no longer needed states from memory.
+func TestStateRegenerationPruning(t *testing.T) {
+	pruned := make([]int, 31)
+	for i := range pruned {
+		pruned[i] = i + 1
+	}
+	eth, chain := newTestTraceChain(t, 32, pruned)
+	defer eth.blockchain.Stop()
+
+	api := NewPrivateDebugAPI(params.TestChainConfig, eth)
+	newRegen := func(limit int) *stateRegenerator {
+		db := newRegenDatabase(eth.chainDb, limit)
+		return &stateRegenerator{api: api, db: db, sdb: state.NewDatabase(db)}
+	}
+	// Ensure the range doesn't fit into the limit without pruning
+	limit := 4096
+
+	full := newRegen(maxRegenMemory)
+	if _, err := full.stateAt(chain[30], 32); err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if full.db.Size() <= limit {
+		t.Fatalf("regenerated state too small: have %d, want above %d", full.db.Size(), limit)
+	}
+	// Regenerate within the limit and check the resulting state
+	regen := newRegen(limit)
+	statedb, err := regen.stateAt(chain[30], 32)
+	if err != nil {
+		t.Fatalf("failed to regenerate state with pruning: %v", err)
+	}
+	if size := regen.db.Size(); size > limit {
+		t.Errorf("regeneration memory exceeded: have %d, limit %d", size, limit)
+	}
+	if root := statedb.IntermediateRoot(true); root != chain[30].Root() {
+		t.Errorf("regenerated state root mismatch: have %x, want %x", root, chain[30].Root())
+	}
+	if statedb, err = regen.advance(statedb, chain[31]); err != nil {
+		t.Fatalf("failed to advance pruned state: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(big.NewInt(32*1000)) != 0 {
+		t.Errorf("regenerated balance mismatch: have %v, want %v", balance, 32*1000)
+	}
+}
+
+// Tests that the standard JSON traces of a block are written into one file per
+// transaction, optionally filtered to a single one.
+func TestStandardTraceBlockToFile(t *testing.T) {
+	eth, chain := newTestTraceChain(t, 4, []int{2, 3})
+	defer eth.blockchain.Stop()
+
+	api := NewPrivateDebugAPI(params.TestChainConfig, eth)
+	block := chain[3]
+
+	dumps, err := api.StandardTraceBlockToFile(context.Background(), block.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	defer func() {
+		for _, dump := range dumps {
+			os.Remove(dump)
+		}
+	}()
+	if len(dumps) != len(block.Transactions()) {
+		t.Fatalf("trace file count mismatch: have %d, want %d", len(dumps), len(block.Transactions()))
+	}
+	blob, err := ioutil.ReadFile(dumps[0])
+	if err != nil {
+		t.Fatalf("failed to read trace: %v", err)
+	}
+	var summary struct {
+		Output  hexutil.Bytes   `json:"output"`
+		GasUsed *hexutil.Uint64 `json:"gasUsed"`
+	}
+	if err := json.Unmarshal(blob, &summary); err != nil {
+		t.Fatalf("failed to decode trace summary %q: %v", blob, err)
+	}
+	if summary.GasUsed == nil || *summary.GasUsed != 21000 {
+		t.Errorf("summary gas used mismatch: have %v, want %d", summary.GasUsed, 21000)
+	}
+	// Ensure unknown transactions are rejected
+	unknown := common.HexToHash("0xdeadbeef")
+	if _, err := api.StandardTraceBlockToFile(context.Background(), block.Hash(), &StdTraceConfig{TxHash: &unknown}); err == nil {
+		t.Errorf("unknown transaction traced")
+	}
+}
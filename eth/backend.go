@@ -161,13 +161,42 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = ctx.ResolvePath(config.TxPool.Snapshot)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	if config.PropagationTraceSize > 0 {
+		journal := config.PropagationTraceJournal
+		if journal != "" {
+			journal = ctx.ResolvePath(journal)
+		}
+		if eth.protocolManager.propagation, err = newPropagationTracer(config.PropagationTraceSize, journal); err != nil {
+			return nil, err
+		}
+	}
+	if config.SyncMode == downloader.CheckpointSync {
+		checkpoint := config.Checkpoint
+		if checkpoint == nil {
+			checkpoint = params.TrustedCheckpoints[genesisHash]
+		}
+		if checkpoint == nil {
+			return nil, errors.New("checkpoint sync requested, but no trusted checkpoint known for the network")
+		}
+		if checkpoint.Td == nil {
+			return nil, errors.New("trusted checkpoint without total difficulty")
+		}
+		log.Info("Using trusted checkpoint", "number", checkpoint.Number, "hash", checkpoint.Hash, "td", checkpoint.Td)
+		eth.protocolManager.downloader.SetCheckpoint(checkpoint)
+	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
+	if config.MinerBundles {
+		eth.miner.SetTransactionSelector(miner.NewBundleSelector(miner.PriceSelector{}))
+	}
 
 	eth.ApiBackend = &EthApiBackend{eth, nil}
 	gpoParams := config.GPO
@@ -258,6 +287,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader, s.eventMux),
 			Public:    true,
+		}, {
+			Namespace: "downloader",
+			Version:   "1.0",
+			Service:   downloader.NewPublicSyncProgressAPI(s.protocolManager.downloader),
+			Public:    true,
 		}, {
 			Namespace: "miner",
 			Version:   "1.0",
@@ -266,7 +300,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false),
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false, s.config.Filters),
 			Public:    true,
 		}, {
 			Namespace: "admin",
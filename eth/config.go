@@ -27,6 +27,7 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
 	"github.com/teamnsrg/ethereum-p2p/core"
 	"github.com/teamnsrg/ethereum-p2p/eth/downloader"
+	"github.com/teamnsrg/ethereum-p2p/eth/filters"
 	"github.com/teamnsrg/ethereum-p2p/eth/gasprice"
 	"github.com/teamnsrg/ethereum-p2p/params"
 )
@@ -76,6 +77,14 @@ type Config struct {
 	NetworkId uint64 // Network ID to use for selecting peers to connect to
 	SyncMode  downloader.SyncMode
 
+	// Trusted checkpoint to start checkpoint synchronisation from. If nil, the
+	// checkpoint known for the network in params is used.
+	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
+
+	// Propagation tracing options
+	PropagationTraceSize    int    `toml:",omitempty"` // Number of recently seen transactions and blocks to trace the arrivals of (0 = disabled)
+	PropagationTraceJournal string `toml:",omitempty"` // JSON lines file to append the records falling out of the trace to
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
@@ -89,6 +98,7 @@ type Config struct {
 	Etherbase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
+	MinerBundles bool           `toml:",omitempty"`
 	GasPrice     *big.Int
 
 	// Ethash options
@@ -105,6 +115,9 @@ type Config struct {
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Log filtering options
+	Filters filters.Config
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
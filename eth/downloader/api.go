@@ -18,7 +18,9 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	ethereum "github.com/teamnsrg/ethereum-p2p"
 	"github.com/teamnsrg/ethereum-p2p/event"
@@ -164,3 +166,64 @@ func (api *PublicDownloaderAPI) SubscribeSyncStatus(status chan interface{}) *Sy
 	api.installSyncSubscription <- status
 	return &SyncStatusSubscription{api: api, c: status}
 }
+
+// PublicSyncProgressAPI provides detailed insight into the progress of the chain
+// synchronisation: the state of the individual sync phases, the throughput of
+// the peers and an estimate of the time remaining.
+type PublicSyncProgressAPI struct {
+	d *Downloader
+}
+
+// NewPublicSyncProgressAPI creates a new API reporting the detailed progress of
+// the given downloader.
+func NewPublicSyncProgressAPI(d *Downloader) *PublicSyncProgressAPI {
+	return &PublicSyncProgressAPI{d: d}
+}
+
+// Status retrieves a breakdown of the progress of the current (or last) sync cycle.
+func (api *PublicSyncProgressAPI) Status() *DetailedProgress {
+	return api.d.DetailedProgress()
+}
+
+// Progress creates a subscription that periodically reports the detailed sync
+// progress while the node is synchronising, followed by a final report when the
+// sync cycle ends. The optional interval is given in seconds.
+func (api *PublicSyncProgressAPI) Progress(ctx context.Context, interval *uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	period := progressReportInterval
+	if interval != nil {
+		if *interval == 0 {
+			return nil, errors.New("zero progress interval")
+		}
+		period = time.Duration(*interval) * time.Second
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		syncing := false
+		for {
+			select {
+			case <-ticker.C:
+				// Report while syncing and once more when the cycle ends
+				progress := api.d.DetailedProgress()
+				if progress.Syncing || syncing {
+					notifier.Notify(rpcSub.ID, progress)
+				}
+				syncing = progress.Syncing
+
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/rcrowley/go-metrics"
 	ethereum "github.com/teamnsrg/ethereum-p2p"
 	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
 	"github.com/teamnsrg/ethereum-p2p/core/types"
 	"github.com/teamnsrg/ethereum-p2p/ethdb"
 	"github.com/teamnsrg/ethereum-p2p/event"
@@ -92,6 +93,10 @@ var (
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
+	errNoCheckpoint            = errors.New("checkpoint sync requested without a trusted checkpoint")
+	errCheckpointMismatch      = errors.New("local chain conflicts with the trusted checkpoint")
+	errNoBackfill              = errors.New("no blocks to backfill")
+	errEmptyBackfill           = errors.New("peer delivered no backfill content")
 )
 
 type Downloader struct {
@@ -105,6 +110,8 @@ type Downloader struct {
 	fsPivotLock  *types.Header // Pivot header on critical section entry (cannot change between retries)
 	fsPivotFails uint32        // Number of subsequent fast sync failures in the critical section
 
+	checkpoint *params.TrustedCheckpoint // Trusted checkpoint to start checkpoint sync from
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -112,6 +119,7 @@ type Downloader struct {
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
+	syncStatsProgress    syncProgress
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	lightchain LightChain
@@ -143,6 +151,13 @@ type Downloader struct {
 	cancelCh   chan struct{} // Channel to cancel mid-flight syncs
 	cancelLock sync.RWMutex  // Lock to protect the cancel channel and peer in delivers
 
+	// Checkpoint sync backfilling, independent of (and yielding to) synchronisation
+	backfillPeer      string        // Identifier of the peer currently being backfilled from
+	backfillCancel    chan struct{} // Channel to cancel a mid-flight backfill (nil = none running)
+	backfillLock      sync.Mutex    // Lock to protect the backfill peer and cancel channel
+	backfillBodyCh    chan dataPack // Channel receiving the block bodies of a backfill
+	backfillReceiptCh chan dataPack // Channel receiving the receipts of a backfill
+
 	quitCh   chan struct{} // Quit channel to signal termination
 	quitLock sync.RWMutex  // Lock to prevent double closes
 
@@ -193,6 +208,9 @@ type BlockChain interface {
 	// FastSyncCommitHead directly commits the head block to a certain entity.
 	FastSyncCommitHead(common.Hash) error
 
+	// InsertCheckpointHeader injects a trusted checkpoint header as the head header.
+	InsertCheckpointHeader(*types.Header, *big.Int) error
+
 	// InsertChain inserts a batch of blocks into the local chain.
 	InsertChain(types.Blocks) (int, error)
 
@@ -207,32 +225,40 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 	}
 
 	dl := &Downloader{
-		mode:           mode,
-		stateDB:        stateDb,
-		mux:            mux,
-		queue:          newQueue(),
-		peers:          newPeerSet(),
-		rttEstimate:    uint64(rttMaxEstimate),
-		rttConfidence:  uint64(1000000),
-		blockchain:     chain,
-		lightchain:     lightchain,
-		dropPeer:       dropPeer,
-		headerCh:       make(chan dataPack, 1),
-		bodyCh:         make(chan dataPack, 1),
-		receiptCh:      make(chan dataPack, 1),
-		bodyWakeCh:     make(chan bool, 1),
-		receiptWakeCh:  make(chan bool, 1),
-		headerProcCh:   make(chan []*types.Header, 1),
-		quitCh:         make(chan struct{}),
-		stateCh:        make(chan dataPack),
-		stateSyncStart: make(chan *stateSync),
-		trackStateReq:  make(chan *stateReq),
+		mode:              mode,
+		stateDB:           stateDb,
+		mux:               mux,
+		queue:             newQueue(),
+		peers:             newPeerSet(),
+		rttEstimate:       uint64(rttMaxEstimate),
+		rttConfidence:     uint64(1000000),
+		blockchain:        chain,
+		lightchain:        lightchain,
+		dropPeer:          dropPeer,
+		headerCh:          make(chan dataPack, 1),
+		bodyCh:            make(chan dataPack, 1),
+		receiptCh:         make(chan dataPack, 1),
+		backfillBodyCh:    make(chan dataPack, 1),
+		backfillReceiptCh: make(chan dataPack, 1),
+		bodyWakeCh:        make(chan bool, 1),
+		receiptWakeCh:     make(chan bool, 1),
+		headerProcCh:      make(chan []*types.Header, 1),
+		quitCh:            make(chan struct{}),
+		stateCh:           make(chan dataPack),
+		stateSyncStart:    make(chan *stateSync),
+		trackStateReq:     make(chan *stateReq),
 	}
 	go dl.qosTuner()
 	go dl.stateFetcher()
 	return dl
 }
 
+// SetCheckpoint sets the trusted checkpoint from which checkpoint synchronisation
+// starts downloading and verifying headers.
+func (d *Downloader) SetCheckpoint(checkpoint *params.TrustedCheckpoint) {
+	d.checkpoint = checkpoint
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -251,7 +277,7 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 		current = d.blockchain.CurrentBlock().NumberU64()
 	case FastSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
-	case LightSync:
+	case LightSync, CheckpointSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
 	}
 	return ethereum.SyncProgress{
@@ -346,6 +372,9 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 	}
 	defer atomic.StoreInt32(&d.synchronising, 0)
 
+	// Abort any running backfill, synchronisation takes precedence
+	d.cancelBackfill()
+
 	// Post a user notification of the sync (only once per session)
 	if atomic.CompareAndSwapInt32(&d.notified, 0, 1) {
 		log.Info("Block synchronisation started")
@@ -389,6 +418,9 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 	if d.mode == FastSync && atomic.LoadUint32(&d.fsPivotFails) >= fsCriticalTrials {
 		d.mode = FullSync
 	}
+	if d.mode == CheckpointSync && d.checkpoint == nil {
+		return errNoCheckpoint
+	}
 	// Retrieve the origin peer and initiate the downloading process
 	p := d.peers.Peer(id)
 	if p == nil {
@@ -418,6 +450,13 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		log.Debug("Synchronisation terminated", "elapsed", time.Since(start))
 	}(time.Now())
 
+	// Track the progress of the sync cycle and report it periodically
+	d.resetProgress(d.mode)
+
+	reportDone := make(chan struct{})
+	defer close(reportDone)
+	go d.reportProgress(reportDone)
+
 	// Look up the sync boundaries: the common ancestor and the target block
 	latest, err := d.fetchHeight(p)
 	if err != nil {
@@ -425,6 +464,13 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	}
 	height := latest.Number.Uint64()
 
+	// If checkpoint syncing, make sure the local chain is anchored at the checkpoint
+	if d.mode == CheckpointSync {
+		if err := d.fetchCheckpoint(p); err != nil {
+			return err
+		}
+	}
+
 	origin, err := d.findAncestor(p, height)
 	if err != nil {
 		return err
@@ -464,8 +510,32 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			}
 		}
 		log.Debug("Fast syncing until pivot block", "pivot", pivot)
+
+	case CheckpointSync:
+		// Pick the pivot close to the head, but above the common ancestor, since
+		// the history below the ancestor (and the checkpoint) is never retrieved
+		if d.fsPivotLock == nil {
+			if height > uint64(fsMinFullBlocks) {
+				pivot = height - uint64(fsMinFullBlocks)
+			}
+			if pivot <= origin {
+				pivot = origin + 1
+			}
+		} else {
+			// Pivot point locked in, use this and do not pick a new one!
+			pivot = d.fsPivotLock.Number.Uint64()
+		}
+		log.Debug("Checkpoint syncing headers until pivot block", "checkpoint", d.checkpoint.Number, "pivot", pivot)
+	}
+	if d.mode == FastSync || d.mode == CheckpointSync {
+		d.trackPivot(pivot)
+	}
+	if d.mode == CheckpointSync {
+		// No content is retrieved below the pivot, start collecting results there
+		d.queue.Prepare(pivot, d.mode, pivot, latest)
+	} else {
+		d.queue.Prepare(origin+1, d.mode, pivot, latest)
 	}
-	d.queue.Prepare(origin+1, d.mode, pivot, latest)
 	if d.syncInitHook != nil {
 		d.syncInitHook(origin, height)
 	}
@@ -476,13 +546,13 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		func() error { return d.fetchReceipts(origin + 1) }, // Receipts are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, td) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == CheckpointSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
 	}
 	err = d.spawnSync(fetchers)
-	if err != nil && d.mode == FastSync && d.fsPivotLock != nil {
+	if err != nil && (d.mode == FastSync || d.mode == CheckpointSync) && d.fsPivotLock != nil {
 		// If sync failed in the critical section, bump the fail counter.
 		atomic.AddUint32(&d.fsPivotFails, 1)
 	}
@@ -593,6 +663,60 @@ func (d *Downloader) fetchHeight(p *peerConnection) (*types.Header, error) {
 	}
 }
 
+// fetchCheckpoint ensures that the local header chain contains the trusted
+// checkpoint, retrieving its header from the remote peer and injecting it as the
+// head header if the local chain has not reached it yet.
+func (d *Downloader) fetchCheckpoint(p *peerConnection) error {
+	checkpoint := d.checkpoint
+	if d.lightchain.HasHeader(checkpoint.Hash, checkpoint.Number) {
+		return nil
+	}
+	if head := d.lightchain.CurrentHeader().Number.Uint64(); head >= checkpoint.Number {
+		log.Error("Local chain conflicts with trusted checkpoint", "head", head, "checkpoint", checkpoint.Number, "hash", checkpoint.Hash)
+		return errCheckpointMismatch
+	}
+	p.log.Debug("Retrieving trusted checkpoint header", "number", checkpoint.Number, "hash", checkpoint.Hash)
+
+	// Request the checkpoint header and wait for the response
+	go p.peer.RequestHeadersByHash(checkpoint.Hash, 1, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return errCancelBlockFetch
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			// Make sure the peer actually gave the checkpoint
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 {
+				p.log.Debug("Invalid checkpoint header reply", "headers", len(headers))
+				return errBadPeer
+			}
+			header := headers[0]
+			if header.Hash() != checkpoint.Hash || header.Number.Uint64() != checkpoint.Number {
+				p.log.Debug("Checkpoint header mismatch", "number", header.Number, "hash", header.Hash())
+				return errBadPeer
+			}
+			return d.blockchain.InsertCheckpointHeader(header, checkpoint.Td)
+
+		case <-timeout:
+			p.log.Debug("Waiting for checkpoint header timed out", "elapsed", ttl)
+			return errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // findAncestor tries to locate the common ancestor link of the local chain and
 // a remote peers blockchain. In the general case when our node was in sync and
 // on the correct chain, checking the top N links should already get us a match.
@@ -611,6 +735,11 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	if ceil >= MaxForkAncestry {
 		floor = int64(ceil - MaxForkAncestry)
 	}
+	// During checkpoint sync nothing below the checkpoint is known, reject any
+	// ancestor that is not on top of it
+	if d.mode == CheckpointSync && int64(d.checkpoint.Number)-1 > floor {
+		floor = int64(d.checkpoint.Number) - 1
+	}
 	// Request the topmost blocks to short circuit binary ancestor lookup
 	head := ceil
 	if head > height {
@@ -620,6 +749,9 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	if from < 0 {
 		from = 0
 	}
+	if from <= floor {
+		from = floor + 1
+	}
 	// Span out with 15 block gaps into the future to catch bad head reports
 	limit := 2 * MaxHeaderFetch / 16
 	count := 1 + int((int64(ceil)-from)/16)
@@ -1173,7 +1305,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				"block", fmt.Sprintf("%d->%d", lastBlock, curBlock))
 
 			// If we're already past the pivot point, this could be an attack, thread carefully
-			if rollback[len(rollback)-1].Number.Uint64() > pivot {
+			if (d.mode == FastSync || d.mode == CheckpointSync) && rollback[len(rollback)-1].Number.Uint64() > pivot {
 				// If we didn't ever fail, lock in the pivot header (must! not! change!)
 				if atomic.LoadUint32(&d.fsPivotFails) == 0 {
 					for _, header := range rollback {
@@ -1229,7 +1361,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us something useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == LightSync || d.mode == CheckpointSync {
 					if td.Cmp(d.lightchain.GetTdByHash(d.lightchain.CurrentHeader().Hash())) > 0 {
 						return errStallingPeer
 					}
@@ -1256,7 +1388,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == LightSync || d.mode == CheckpointSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1284,14 +1416,14 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 					}
 				}
 				// If we're fast syncing and just pulled in the pivot, make sure it's the one locked in
-				if d.mode == FastSync && d.fsPivotLock != nil && chunk[0].Number.Uint64() <= pivot && chunk[len(chunk)-1].Number.Uint64() >= pivot {
+				if (d.mode == FastSync || d.mode == CheckpointSync) && d.fsPivotLock != nil && chunk[0].Number.Uint64() <= pivot && chunk[len(chunk)-1].Number.Uint64() >= pivot {
 					if pivot := chunk[int(pivot-chunk[0].Number.Uint64())]; pivot.Hash() != d.fsPivotLock.Hash() {
 						log.Warn("Pivot doesn't match locked in one", "remoteNumber", pivot.Number, "remoteHash", pivot.Hash(), "localNumber", d.fsPivotLock.Number, "localHash", d.fsPivotLock.Hash())
 						return errInvalidChain
 					}
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode == FastSync || d.mode == CheckpointSync {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1307,6 +1439,8 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 						return errBadPeer
 					}
 				}
+				d.trackHeaders(limit)
+
 				headers = headers[limit:]
 				origin += uint64(limit)
 			}
@@ -1328,6 +1462,7 @@ func (d *Downloader) processFullSyncContent() error {
 		if len(results) == 0 {
 			return nil
 		}
+		d.trackResults(results)
 		if d.chainInsertHook != nil {
 			d.chainInsertHook(results)
 		}
@@ -1385,6 +1520,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 		if len(results) == 0 {
 			return stateSync.Cancel()
 		}
+		d.trackResults(results)
 		if d.chainInsertHook != nil {
 			d.chainInsertHook(results)
 		}
@@ -1465,9 +1601,191 @@ func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{b}, []types.Receipts{result.Receipts}); err != nil {
 		return err
 	}
+	// Checkpoint sync skipped the content below the pivot, mark it for backfilling
+	if d.mode == CheckpointSync {
+		core.WriteBackfillTail(d.stateDB, b.Hash())
+	}
 	return d.blockchain.FastSyncCommitHead(b.Hash())
 }
 
+// Backfill retrieves the bodies and receipts of the next batch of blocks below
+// the pivot of a checkpoint sync from the given peer, so that the history between
+// the checkpoint and the pivot can be served after synchronisation. It returns
+// errNoBackfill once all the blocks down to the checkpoint are retrieved.
+func (d *Downloader) Backfill(id string) error {
+	tail := core.GetBackfillTail(d.stateDB)
+	if tail == (common.Hash{}) {
+		return errNoBackfill
+	}
+	// Collect the headers of the batch, newest first, down to the checkpoint
+	var headers []*types.Header
+	if header := d.lightchain.GetHeaderByHash(tail); header != nil {
+		for parent := d.lightchain.GetHeaderByHash(header.ParentHash); parent != nil && len(headers) < MaxBlockFetch; parent = d.lightchain.GetHeaderByHash(parent.ParentHash) {
+			headers = append(headers, parent)
+		}
+	}
+	if len(headers) == 0 {
+		log.Info("Checkpoint sync backfill complete")
+		core.DeleteBackfillTail(d.stateDB)
+		return errNoBackfill
+	}
+	p := d.peers.Peer(id)
+	if p == nil {
+		return errUnknownPeer
+	}
+	// Register the backfill, yielding to any running synchronisation
+	cancel, err := d.startBackfill(id)
+	if err != nil {
+		return err
+	}
+	defer d.stopBackfill(cancel)
+
+	hashes := make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash()
+	}
+	p.log.Debug("Backfilling blocks", "count", len(headers), "from", headers[0].Number, "to", headers[len(headers)-1].Number)
+
+	// Retrieve the bodies, followed by the receipts of the blocks delivered
+	go p.peer.RequestBodies(hashes)
+	packet, err := d.awaitBackfill(p, d.backfillBodyCh, cancel)
+	if err != nil {
+		return err
+	}
+	bodies := packet.(*bodyPack)
+	for i := 0; i < len(bodies.transactions) && i < len(headers); i++ {
+		if types.DeriveSha(types.Transactions(bodies.transactions[i])) != headers[i].TxHash || types.CalcUncleHash(bodies.uncles[i]) != headers[i].UncleHash {
+			d.dropPeer(id)
+			return errInvalidBody
+		}
+	}
+	count := len(bodies.transactions)
+	if count > len(headers) {
+		count = len(headers)
+	}
+	if count == 0 {
+		return errEmptyBackfill
+	}
+	go p.peer.RequestReceipts(hashes[:count])
+	if packet, err = d.awaitBackfill(p, d.backfillReceiptCh, cancel); err != nil {
+		return err
+	}
+	receipts := packet.(*receiptPack).receipts
+	for i := 0; i < len(receipts) && i < count; i++ {
+		if types.DeriveSha(types.Receipts(receipts[i])) != headers[i].ReceiptHash {
+			d.dropPeer(id)
+			return errInvalidReceipt
+		}
+	}
+	if len(receipts) < count {
+		count = len(receipts)
+	}
+	if count == 0 {
+		return errEmptyBackfill
+	}
+	// Import the contiguous run of complete blocks, oldest first
+	blocks := make(types.Blocks, count)
+	for i := 0; i < count; i++ {
+		blocks[count-1-i] = types.NewBlockWithHeader(headers[i]).WithBody(bodies.transactions[i], bodies.uncles[i])
+	}
+	ordered := make([]types.Receipts, count)
+	for i := 0; i < count; i++ {
+		ordered[count-1-i] = receipts[i]
+	}
+	if _, err := d.blockchain.InsertReceiptChain(blocks, ordered); err != nil {
+		return err
+	}
+	return core.WriteBackfillTail(d.stateDB, headers[count-1].Hash())
+}
+
+// startBackfill marks a backfill from the given peer as running, routing the
+// peer's deliveries to it. It fails if a backfill or synchronisation is running.
+func (d *Downloader) startBackfill(id string) (chan struct{}, error) {
+	d.backfillLock.Lock()
+	if d.backfillCancel != nil {
+		d.backfillLock.Unlock()
+		return nil, errBusy
+	}
+	cancel := make(chan struct{})
+	d.backfillCancel, d.backfillPeer = cancel, id
+
+	for _, ch := range []chan dataPack{d.backfillBodyCh, d.backfillReceiptCh} {
+		for empty := false; !empty; {
+			select {
+			case <-ch:
+			default:
+				empty = true
+			}
+		}
+	}
+	d.backfillLock.Unlock()
+
+	// Synchronisation cancels backfills after starting, check only once registered
+	if atomic.LoadInt32(&d.synchronising) != 0 {
+		d.stopBackfill(cancel)
+		return nil, errBusy
+	}
+	return cancel, nil
+}
+
+// stopBackfill unregisters a backfill, unless it was already cancelled.
+func (d *Downloader) stopBackfill(cancel chan struct{}) {
+	d.backfillLock.Lock()
+	defer d.backfillLock.Unlock()
+
+	if d.backfillCancel == cancel {
+		close(cancel)
+		d.backfillCancel, d.backfillPeer = nil, ""
+	}
+}
+
+// cancelBackfill aborts any running backfill.
+func (d *Downloader) cancelBackfill() {
+	d.backfillLock.Lock()
+	defer d.backfillLock.Unlock()
+
+	if d.backfillCancel != nil {
+		close(d.backfillCancel)
+		d.backfillCancel, d.backfillPeer = nil, ""
+	}
+}
+
+// deliverBackfill injects a packet into a running backfill if the packet is from
+// the peer being backfilled from, reporting whether it was consumed.
+func (d *Downloader) deliverBackfill(id string, destCh chan dataPack, packet dataPack) bool {
+	d.backfillLock.Lock()
+	defer d.backfillLock.Unlock()
+
+	if d.backfillCancel == nil || d.backfillPeer != id {
+		return false
+	}
+	select {
+	case destCh <- packet:
+	default:
+		// Unrequested duplicate, drop it
+	}
+	return true
+}
+
+// awaitBackfill waits for the reply of the peer to a backfill request.
+func (d *Downloader) awaitBackfill(p *peerConnection, ch chan dataPack, cancel chan struct{}) (dataPack, error) {
+	timeout := time.After(d.requestTTL())
+	for {
+		select {
+		case <-cancel:
+			return nil, errCancelBlockFetch
+		case <-d.quitCh:
+			return nil, errCancelBlockFetch
+		case packet := <-ch:
+			if packet.PeerId() == p.id {
+				return packet, nil
+			}
+		case <-timeout:
+			return nil, errTimeout
+		}
+	}
+}
+
 // DeliverHeaders injects a new batch of block headers received from a remote
 // node into the download schedule.
 func (d *Downloader) DeliverHeaders(id string, headers []*types.Header) (err error) {
@@ -1476,11 +1794,19 @@ func (d *Downloader) DeliverHeaders(id string, headers []*types.Header) (err err
 
 // DeliverBodies injects a new batch of block bodies received from a remote node.
 func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transaction, uncles [][]*types.Header) (err error) {
+	if d.deliverBackfill(id, d.backfillBodyCh, &bodyPack{id, transactions, uncles}) {
+		bodyInMeter.Mark(int64(len(transactions)))
+		return nil
+	}
 	return d.deliver(id, d.bodyCh, &bodyPack{id, transactions, uncles}, bodyInMeter, bodyDropMeter)
 }
 
 // DeliverReceipts injects a new batch of receipts received from a remote node.
 func (d *Downloader) DeliverReceipts(id string, receipts [][]*types.Receipt) (err error) {
+	if d.deliverBackfill(id, d.backfillReceiptCh, &receiptPack{id, receipts}) {
+		receiptInMeter.Mark(int64(len(receipts)))
+		return nil
+	}
 	return d.deliver(id, d.receiptCh, &receiptPack{id, receipts}, receiptInMeter, receiptDropMeter)
 }
 
@@ -70,6 +70,8 @@ type downloadTester struct {
 
 	peerMissingStates map[string]map[common.Hash]bool // State entries that fast sync should not return
 
+	checkpointed bool // Whether the chain was anchored at a checkpoint (pruned history)
+
 	lock sync.RWMutex
 }
 
@@ -335,6 +337,22 @@ func (dl *downloadTester) InsertHeaderChain(headers []*types.Header, checkFreq i
 	return len(headers), nil
 }
 
+// InsertCheckpointHeader injects a trusted checkpoint header into the simulated
+// chain without requiring its ancestors.
+func (dl *downloadTester) InsertCheckpointHeader(header *types.Header, td *big.Int) error {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if _, ok := dl.ownHeaders[header.Hash()]; ok {
+		return nil
+	}
+	dl.ownHashes = append(dl.ownHashes, header.Hash())
+	dl.ownHeaders[header.Hash()] = header
+	dl.ownChainTd[header.Hash()] = new(big.Int).Set(td)
+	dl.checkpointed = true
+	return nil
+}
+
 // InsertChain injects a new batch of blocks into the simulated chain.
 func (dl *downloadTester) InsertChain(blocks types.Blocks) (int, error) {
 	dl.lock.Lock()
@@ -366,7 +384,8 @@ func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []typ
 		if _, ok := dl.ownHeaders[blocks[i].Hash()]; !ok {
 			return i, errors.New("unknown owner")
 		}
-		if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok {
+		// Checkpointed chains only have the history above the checkpoint
+		if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok && !dl.checkpointed {
 			return i, errors.New("unknown parent")
 		}
 		dl.ownBlocks[blocks[i].Hash()] = blocks[i]
@@ -695,6 +714,86 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// Tests that checkpoint synchronisation retrieves only the headers above the
+// trusted checkpoint and the blocks starting at the pivot.
+func TestCheckpointSynchronisation63(t *testing.T) { testCheckpointSynchronisation(t, 63) }
+func TestCheckpointSynchronisation64(t *testing.T) { testCheckpointSynchronisation(t, 64) }
+
+func testCheckpointSynchronisation(t *testing.T, protocol int) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Create a small enough block chain to download and checkpoint it midway
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+
+	// Syncing without a checkpoint should be refused
+	if err := tester.sync("peer", nil, CheckpointSync); err != errNoCheckpoint {
+		t.Fatalf("checkpointless sync error mismatch: have %v, want %v", err, errNoCheckpoint)
+	}
+	number := uint64(targetBlocks / 2)
+	checkpoint := hashes[len(hashes)-1-int(number)]
+	tester.downloader.SetCheckpoint(&params.TrustedCheckpoint{
+		Number: number,
+		Hash:   checkpoint,
+		Td:     tester.peerChainTds["peer"][checkpoint],
+	})
+	if err := tester.sync("peer", nil, CheckpointSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	// Headers below the checkpoint must not be retrieved, all above must be
+	for i := uint64(1); i < number; i++ {
+		if tester.HasHeader(hashes[len(hashes)-1-int(i)], i) {
+			t.Fatalf("header #%d below checkpoint retrieved", i)
+		}
+	}
+	for i := number; i <= uint64(targetBlocks); i++ {
+		if !tester.HasHeader(hashes[len(hashes)-1-int(i)], i) {
+			t.Fatalf("header #%d above checkpoint missing", i)
+		}
+	}
+	// The head block should be fully available, bodies below the pivot not
+	if head := tester.CurrentBlock().Hash(); head != hashes[0] {
+		t.Fatalf("head block mismatch: have %x, want %x", head, hashes[0])
+	}
+	if tester.GetBlockByHash(checkpoint) != nil {
+		t.Fatalf("checkpoint block body retrieved")
+	}
+	// Backfilling must yield to synchronisation
+	atomic.StoreInt32(&tester.downloader.synchronising, 1)
+	if err := tester.downloader.Backfill("peer"); err != errBusy {
+		t.Fatalf("backfill during sync error mismatch: have %v, want %v", err, errBusy)
+	}
+	atomic.StoreInt32(&tester.downloader.synchronising, 0)
+
+	// Backfill the blocks below the pivot and ensure they're all available
+	for i := 0; ; i++ {
+		err := tester.downloader.Backfill("peer")
+		if err == errNoBackfill {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to backfill blocks: %v", err)
+		}
+		if i > targetBlocks/MaxBlockFetch {
+			t.Fatalf("backfill not terminating")
+		}
+	}
+	for i := number; i <= uint64(targetBlocks); i++ {
+		hash := hashes[len(hashes)-1-int(i)]
+		if tester.GetBlockByHash(hash) == nil {
+			t.Fatalf("block #%d above checkpoint not backfilled", i)
+		}
+	}
+	if tester.GetBlockByHash(hashes[len(hashes)-int(number)]) != nil {
+		t.Fatalf("block below checkpoint backfilled")
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling62(t *testing.T)     { testThrottling(t, 62, FullSync) }
@@ -1431,6 +1530,55 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}
 }
 
+// Tests that the detailed synchronisation progress accounts for the items
+// retrieved in each sync phase.
+func TestDetailedSyncProgress63Full(t *testing.T)  { testDetailedSyncProgress(t, 63, FullSync) }
+func TestDetailedSyncProgress63Fast(t *testing.T)  { testDetailedSyncProgress(t, 63, FastSync) }
+func TestDetailedSyncProgress64Light(t *testing.T) { testDetailedSyncProgress(t, 64, LightSync) }
+
+func testDetailedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Create a small enough block chain to download
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	progress := tester.downloader.DetailedProgress()
+	if progress.Syncing {
+		t.Errorf("sync reported running after completion")
+	}
+	if progress.Mode != mode.String() {
+		t.Errorf("mode mismatch: have %s, want %s", progress.Mode, mode)
+	}
+	if progress.Headers.Done != uint64(targetBlocks) || progress.Headers.Total != uint64(targetBlocks) {
+		t.Errorf("header progress mismatch: have %v, want %d/%d", progress.Headers, targetBlocks, targetBlocks)
+	}
+	bodies := uint64(targetBlocks)
+	if mode == LightSync {
+		bodies = 0
+	}
+	if progress.Bodies.Done != bodies || progress.Bodies.Total != bodies {
+		t.Errorf("body progress mismatch: have %v, want %d/%d", progress.Bodies, bodies, bodies)
+	}
+	if mode == FastSync {
+		if progress.PivotBlock == 0 || progress.Receipts.Done != progress.PivotBlock || progress.Receipts.Total != progress.PivotBlock {
+			t.Errorf("receipt progress mismatch: have %v, want %d/%d", progress.Receipts, progress.PivotBlock, progress.PivotBlock)
+		}
+	} else if progress.Receipts.Done != 0 || progress.PivotBlock != 0 {
+		t.Errorf("unexpected receipt progress: have %v, pivot %d", progress.Receipts, progress.PivotBlock)
+	}
+	if len(progress.Peers) != 1 || progress.Peers[0].Id != "peer" {
+		t.Errorf("peer throughput mismatch: have %v", progress.Peers)
+	}
+}
+
 // Tests that synchronisation progress (origin block number and highest block
 // number) is tracked and updated correctly in case of a fork (or manual head
 // revertal).
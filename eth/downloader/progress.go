@@ -0,0 +1,263 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/log"
+)
+
+// progressReportInterval is the frequency at which the progress of an active
+// synchronisation is logged (and the default for progress subscriptions).
+var progressReportInterval = 8 * time.Second
+
+// syncProgress contains the counters of the running (or last) sync cycle, used
+// to derive the per-phase progress, throughput and completion estimates.
+type syncProgress struct {
+	mode    SyncMode  // Synchronisation mode of the cycle
+	started time.Time // Time instance when the sync cycle started
+
+	headers  uint64 // Number of headers processed in this sync cycle
+	bodies   uint64 // Number of blocks completed with their bodies in this sync cycle
+	receipts uint64 // Number of blocks completed with their receipts in this sync cycle
+	states   uint64 // Number of state entries processed before this sync cycle
+
+	pivot      uint64 // Pivot block of the current (or last) fast or checkpoint sync
+	pivotMoves uint64 // Number of times the pivot block changed between sync cycles
+}
+
+// PhaseProgress is the progress of a single phase of the synchronisation.
+type PhaseProgress struct {
+	Total  uint64  `json:"total"`  // Number of items expected to be retrieved (known so far for state)
+	Done   uint64  `json:"done"`   // Number of items completed in the current sync cycle
+	Queued uint64  `json:"queued"` // Number of items scheduled, but not yet retrieved
+	Rate   float64 `json:"rate"`   // Average number of items completed per second
+	ETA    float64 `json:"eta"`    // Estimated seconds until the phase completes (0 if unknown)
+}
+
+// estimate fills in the retrieval rate and the completion estimate of a phase
+// based on the time elapsed since the sync cycle started.
+func (p *PhaseProgress) estimate(elapsed time.Duration) {
+	if elapsed <= 0 || p.Done == 0 {
+		return
+	}
+	p.Rate = float64(p.Done) / elapsed.Seconds()
+	if p.Total > p.Done {
+		p.ETA = float64(p.Total-p.Done) / p.Rate
+	}
+}
+
+// String implements fmt.Stringer, formatting the phase for log output.
+func (p PhaseProgress) String() string {
+	return fmt.Sprintf("%d/%d", p.Done, p.Total)
+}
+
+// PeerProgress contains the measured retrieval throughput of a single peer.
+type PeerProgress struct {
+	Id       string  `json:"id"`       // Unique identifier of the peer
+	Headers  float64 `json:"headers"`  // Number of headers retrievable per second
+	Blocks   float64 `json:"blocks"`   // Number of block bodies retrievable per second
+	Receipts float64 `json:"receipts"` // Number of receipt sets retrievable per second
+	States   float64 `json:"states"`   // Number of state entries retrievable per second
+	RTT      float64 `json:"rtt"`      // Request round trip time in seconds
+}
+
+// peerProgressById implements sort.Interface to order peer measurements by id.
+type peerProgressById []PeerProgress
+
+func (p peerProgressById) Len() int           { return len(p) }
+func (p peerProgressById) Less(i, j int) bool { return p[i].Id < p[j].Id }
+func (p peerProgressById) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// DetailedProgress is a breakdown of the synchronisation progress, containing
+// the state of each sync phase, the throughput of the individual peers and an
+// estimate of the time remaining until the node is synchronised.
+type DetailedProgress struct {
+	Syncing bool   `json:"syncing"` // Whether a sync cycle is currently running
+	Mode    string `json:"mode"`    // Synchronisation mode of the current (or last) cycle
+
+	StartingBlock uint64 `json:"startingBlock"` // Block number where sync began
+	CurrentBlock  uint64 `json:"currentBlock"`  // Current block number where sync is at
+	HighestBlock  uint64 `json:"highestBlock"`  // Highest alleged block number in the chain
+	PivotBlock    uint64 `json:"pivotBlock"`    // Pivot block of fast or checkpoint sync (0 otherwise)
+	PivotMoves    uint64 `json:"pivotMoves"`    // Number of times the pivot changed between cycles
+
+	Headers  PhaseProgress `json:"headers"`  // Header retrieval and verification
+	Bodies   PhaseProgress `json:"bodies"`   // Block body retrieval
+	Receipts PhaseProgress `json:"receipts"` // Receipt retrieval
+	States   PhaseProgress `json:"states"`   // State trie retrieval
+
+	Peers []PeerProgress `json:"peers"` // Throughput of the individual peers
+
+	Elapsed float64 `json:"elapsed"` // Seconds since the current (or last) cycle started
+	ETA     float64 `json:"eta"`     // Estimated seconds until the sync completes (0 if unknown)
+}
+
+// resetProgress starts tracking the progress of a new sync cycle.
+func (d *Downloader) resetProgress(mode SyncMode) {
+	d.syncStatsLock.Lock()
+	defer d.syncStatsLock.Unlock()
+
+	d.syncStatsProgress.mode = mode
+	d.syncStatsProgress.started = time.Now()
+	d.syncStatsProgress.headers = 0
+	d.syncStatsProgress.bodies = 0
+	d.syncStatsProgress.receipts = 0
+	d.syncStatsProgress.states = d.syncStatsState.processed
+}
+
+// trackPivot records the pivot block selected for a sync cycle, counting any
+// deviation from the one used by the previous cycle.
+func (d *Downloader) trackPivot(pivot uint64) {
+	d.syncStatsLock.Lock()
+	defer d.syncStatsLock.Unlock()
+
+	if d.syncStatsProgress.pivot != 0 && d.syncStatsProgress.pivot != pivot {
+		d.syncStatsProgress.pivotMoves++
+	}
+	d.syncStatsProgress.pivot = pivot
+}
+
+// trackHeaders accounts a batch of headers processed in the current sync cycle.
+func (d *Downloader) trackHeaders(headers int) {
+	d.syncStatsLock.Lock()
+	defer d.syncStatsLock.Unlock()
+
+	d.syncStatsProgress.headers += uint64(headers)
+}
+
+// trackResults accounts a batch of completed fetch results, i.e. blocks with
+// their bodies (and receipts if needed) retrieved, in the current sync cycle.
+func (d *Downloader) trackResults(results []*fetchResult) {
+	d.syncStatsLock.Lock()
+	defer d.syncStatsLock.Unlock()
+
+	stats := &d.syncStatsProgress
+	stats.bodies += uint64(len(results))
+	for _, result := range results {
+		number := result.Header.Number.Uint64()
+		if (stats.mode == FastSync && number <= stats.pivot) || (stats.mode == CheckpointSync && number == stats.pivot) {
+			stats.receipts++
+		}
+	}
+}
+
+// DetailedProgress retrieves a breakdown of the synchronisation progress of the
+// current (or last) sync cycle. The estimates are based on the average rates of
+// the cycle so far, so they are inaccurate until the sync has run for a while.
+func (d *Downloader) DetailedProgress() *DetailedProgress {
+	// Collect the basic chain progress and the queue sizes
+	base := d.Progress()
+	queued := [3]int{d.queue.PendingHeaders(), d.queue.PendingBlocks(), d.queue.PendingReceipts()}
+
+	d.syncStatsLock.RLock()
+	stats := d.syncStatsProgress
+	states, pending := d.syncStatsState.processed, d.syncStatsState.pending
+	d.syncStatsLock.RUnlock()
+
+	progress := &DetailedProgress{
+		Syncing:       atomic.LoadInt32(&d.synchronising) > 0,
+		Mode:          stats.mode.String(),
+		StartingBlock: base.StartingBlock,
+		CurrentBlock:  base.CurrentBlock,
+		HighestBlock:  base.HighestBlock,
+		PivotMoves:    stats.pivotMoves,
+	}
+	if stats.mode == FastSync || stats.mode == CheckpointSync {
+		progress.PivotBlock = stats.pivot
+	}
+	// Calculate the number of items each phase needs to retrieve
+	var span uint64
+	if base.HighestBlock > base.StartingBlock {
+		span = base.HighestBlock - base.StartingBlock
+	}
+	progress.Headers = PhaseProgress{Total: span, Done: stats.headers, Queued: uint64(queued[0])}
+
+	switch stats.mode {
+	case FullSync:
+		progress.Bodies.Total = span
+	case FastSync:
+		progress.Bodies.Total = span
+		if stats.pivot > base.StartingBlock {
+			progress.Receipts.Total = stats.pivot - base.StartingBlock
+		}
+	case CheckpointSync:
+		if base.HighestBlock >= stats.pivot {
+			progress.Bodies.Total = base.HighestBlock - stats.pivot + 1
+		}
+		progress.Receipts.Total = 1
+	}
+	progress.Bodies.Done, progress.Bodies.Queued = stats.bodies, uint64(queued[1])
+	progress.Receipts.Done, progress.Receipts.Queued = stats.receipts, uint64(queued[2])
+	progress.States = PhaseProgress{Done: states - stats.states, Queued: pending}
+	progress.States.Total = progress.States.Done + pending
+
+	// Derive the rates and the completion estimates from the elapsed time
+	if !stats.started.IsZero() {
+		elapsed := time.Since(stats.started)
+		progress.Elapsed = elapsed.Seconds()
+
+		for _, phase := range []*PhaseProgress{&progress.Headers, &progress.Bodies, &progress.Receipts, &progress.States} {
+			phase.estimate(elapsed)
+			if phase.ETA > progress.ETA {
+				progress.ETA = phase.ETA
+			}
+		}
+	}
+	// Gather the throughput measurements of all the peers
+	for _, p := range d.peers.AllPeers() {
+		p.lock.RLock()
+		progress.Peers = append(progress.Peers, PeerProgress{
+			Id:       p.id,
+			Headers:  p.headerThroughput,
+			Blocks:   p.blockThroughput,
+			Receipts: p.receiptThroughput,
+			States:   p.stateThroughput,
+			RTT:      p.rtt.Seconds(),
+		})
+		p.lock.RUnlock()
+	}
+	sort.Sort(peerProgressById(progress.Peers))
+
+	return progress
+}
+
+// reportProgress periodically logs the progress of the running sync cycle until
+// the done channel is closed.
+func (d *Downloader) reportProgress(done chan struct{}) {
+	ticker := time.NewTicker(progressReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			progress := d.DetailedProgress()
+			log.Info("Synchronisation progress", "mode", progress.Mode, "current", progress.CurrentBlock, "highest", progress.HighestBlock,
+				"headers", progress.Headers, "bodies", progress.Bodies, "receipts", progress.Receipts,
+				"states", progress.States.Done, "pending", progress.States.Queued, "pivot", progress.PivotBlock, "peers", len(progress.Peers),
+				"eta", common.PrettyDuration(time.Duration(progress.ETA*float64(time.Second))))
+
+		case <-done:
+			return
+		}
+	}
+}
@@ -214,6 +214,20 @@ func (q *queue) Idle() bool {
 	return (queued + pending + cached) == 0
 }
 
+// needsReceipts reports whether the receipts of a block need to be retrieved in
+// addition to its body: for all blocks up to the pivot during fast sync, and for
+// the pivot block alone during checkpoint sync.
+func (q *queue) needsReceipts(header *types.Header) bool {
+	switch q.mode {
+	case FastSync:
+		return header.Number.Uint64() <= q.fastSyncPivot
+	case CheckpointSync:
+		return header.Number.Uint64() == q.fastSyncPivot
+	default:
+		return false
+	}
+}
+
 // FastSyncPivot retrieves the currently used fast sync pivot point.
 func (q *queue) FastSyncPivot() uint64 {
 	q.lock.Lock()
@@ -319,12 +333,19 @@ func (q *queue) Schedule(headers []*types.Header, from uint64) []*types.Header {
 			log.Warn("Header already scheduled for receipt fetch", "number", header.Number, "hash", hash)
 			continue
 		}
+		// Checkpoint sync needs no content below the pivot, only track the ancestry
+		if q.mode == CheckpointSync && header.Number.Uint64() < q.fastSyncPivot {
+			inserts = append(inserts, header)
+			q.headerHead = hash
+			from++
+			continue
+		}
 		// Queue the header for content retrieval
 		q.blockTaskPool[hash] = header
 		q.blockTaskQueue.Push(header, -float32(header.Number.Uint64()))
 
-		if q.mode == FastSync && header.Number.Uint64() <= q.fastSyncPivot {
-			// Fast phase of the fast sync, retrieve receipts too
+		if q.needsReceipts(header) {
+			// Block committed without execution, retrieve receipts too
 			q.receiptTaskPool[hash] = header
 			q.receiptTaskQueue.Push(header, -float32(header.Number.Uint64()))
 		}
@@ -378,7 +399,7 @@ func (q *queue) countProcessableItems() int {
 		// resultCache has space for fsHeaderForceVerify items. Not
 		// doing this could leave us unable to download the required
 		// amount of headers.
-		if q.mode == FastSync && result.Header.Number.Uint64() == q.fastSyncPivot {
+		if (q.mode == FastSync || q.mode == CheckpointSync) && result.Header.Number.Uint64() == q.fastSyncPivot {
 			for j := 0; j < fsHeaderForceVerify; j++ {
 				if i+j+1 >= len(q.resultCache) || q.resultCache[i+j+1] == nil {
 					return i
@@ -493,7 +514,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 		}
 		if q.resultCache[index] == nil {
 			components := 1
-			if q.mode == FastSync && header.Number.Uint64() <= q.fastSyncPivot {
+			if q.needsReceipts(header) {
 				components = 2
 			}
 			q.resultCache[index] = &fetchResult{
@@ -40,4 +40,12 @@ var (
 	headerFilterOutMeter = metrics.NewMeter("eth/fetcher/filter/headers/out")
 	bodyFilterInMeter    = metrics.NewMeter("eth/fetcher/filter/bodies/in")
 	bodyFilterOutMeter   = metrics.NewMeter("eth/fetcher/filter/bodies/out")
+
+	txAnnounceInMeter  = metrics.NewMeter("eth/fetcher/prop/txannounces/in")
+	txAnnounceDOSMeter = metrics.NewMeter("eth/fetcher/prop/txannounces/dos")
+	txBroadcastInMeter = metrics.NewMeter("eth/fetcher/prop/txbroadcasts/in")
+	txReplyInMeter     = metrics.NewMeter("eth/fetcher/fetch/txs/in")
+
+	txFetchMeter        = metrics.NewMeter("eth/fetcher/fetch/txs")
+	txFetchTimeoutMeter = metrics.NewMeter("eth/fetcher/fetch/txs/timeout")
 )
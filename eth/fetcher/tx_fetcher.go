@@ -0,0 +1,407 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/log"
+)
+
+const (
+	MaxTxFetch = 256 // Amount of transactions to be fetched per retrieval request
+
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	txHashLimit     = 4096                   // Maximum number of unique transactions a peer may have announced
+)
+
+// txHasFn is a callback type for checking whether a transaction is already
+// known locally.
+type txHasFn func(common.Hash) bool
+
+// txAddFn is a callback type for injecting a batch of transactions into the
+// local transaction pool.
+type txAddFn func([]*types.Transaction) []error
+
+// txRequesterFn is a callback type for sending a transaction retrieval request.
+type txRequesterFn func([]common.Hash) error
+
+// txAnnounce is the hash notification of the availability of a transaction in
+// the network.
+type txAnnounce struct {
+	hash   common.Hash // Hash of the transaction being announced
+	time   time.Time   // Timestamp of the announcement
+	origin string      // Identifier of the peer originating the notification
+
+	fetchTxs txRequesterFn // Fetcher function to retrieve the announced transaction
+}
+
+// txNotification is a batch of transaction announcements from a single peer.
+type txNotification struct {
+	origin   string        // Identifier of the peer originating the notification
+	hashes   []common.Hash // Hashes of the transactions being announced
+	time     time.Time     // Timestamp of the announcement
+	fetchTxs txRequesterFn // Fetcher function to retrieve the announced transactions
+}
+
+// txDelivery is a batch of transactions arrived from a peer, either broadcast
+// directly or as the reply to an explicit retrieval request.
+type txDelivery struct {
+	origin string        // Identifier of the peer delivering the transactions
+	hashes []common.Hash // Hashes of the delivered transactions
+	direct bool          // Whether the transactions were broadcast or requested
+}
+
+// txRequest is a transaction retrieval request in flight to a single peer.
+type txRequest struct {
+	hashes []common.Hash // Hashes of the transactions requested
+	time   time.Time     // Timestamp when the request was sent
+}
+
+// TxFetcher is responsible for accumulating transaction announcements from
+// various peers and scheduling them for retrieval. Every announced transaction
+// is requested from a single peer at a time, falling back to other announcers
+// if the request times out or the transaction is missing from the reply.
+type TxFetcher struct {
+	// Various event channels
+	notify  chan *txNotification
+	cleanup chan *txDelivery
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states
+	announces map[string]int                // Per peer announce counts to prevent memory exhaustion
+	announced map[common.Hash][]*txAnnounce // Announced transactions, in announcement order per hash
+	fetching  map[common.Hash]*txAnnounce   // Announced transactions, currently fetching
+	requests  map[string]*txRequest         // Retrieval requests currently in flight per peer
+
+	// Callbacks
+	hasTx  txHasFn // Checks whether a transaction is already known locally
+	addTxs txAddFn // Injects a batch of transactions into the local pool
+
+	// Testing hooks
+	fetchingHook func(string, []common.Hash) // Method to call upon starting a transaction retrieval
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txHasFn, addTxs txAddFn) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txNotification),
+		cleanup:   make(chan *txDelivery),
+		drop:      make(chan string),
+		quit:      make(chan struct{}),
+		announces: make(map[string]int),
+		announced: make(map[common.Hash][]*txAnnounce),
+		fetching:  make(map[common.Hash]*txAnnounce),
+		requests:  make(map[string]*txRequest),
+		hasTx:     hasTx,
+		addTxs:    addTxs,
+	}
+}
+
+// Start boots up the announcement based transaction retriever, accepting and
+// processing hash notifications and transaction deliveries until termination
+// requested.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retriever, canceling all
+// pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of
+// transactions in the network. Transactions already known locally are filtered
+// out before scheduling.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash, time time.Time, fetchTxs txRequesterFn) error {
+	txAnnounceInMeter.Mark(int64(len(hashes)))
+
+	unknown := make([]common.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		if !f.hasTx(hash) {
+			unknown = append(unknown, hash)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	notification := &txNotification{
+		origin:   peer,
+		hashes:   unknown,
+		time:     time,
+		fetchTxs: fetchTxs,
+	}
+	select {
+	case f.notify <- notification:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue injects a batch of transactions received from a peer into the local
+// pool and cancels any pending retrievals for them. The direct flag signals
+// whether the transactions were broadcast or arrived as a retrieval reply, in
+// which case any requested but missing transactions are rescheduled to be
+// fetched from a different announcer.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	if direct {
+		txBroadcastInMeter.Mark(int64(len(txs)))
+	} else {
+		txReplyInMeter.Mark(int64(len(txs)))
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	f.addTxs(txs)
+
+	select {
+	case f.cleanup <- &txDelivery{origin: peer, hashes: hashes, direct: direct}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all the announcements of a disconnected peer, rescheduling any
+// transactions being retrieved from it to be fetched from other announcers.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// loop is the main fetcher loop, checking and processing various notification
+// events.
+func (f *TxFetcher) loop() {
+	fetchTimer := time.NewTimer(0)
+	defer fetchTimer.Stop()
+
+	for {
+		select {
+		case <-f.quit:
+			// TxFetcher terminating, abort all operations
+			return
+
+		case notification := <-f.notify:
+			// A batch of transactions was announced, make sure the peer isn't DOSing us
+			count := f.announces[notification.origin]
+			for _, hash := range notification.hashes {
+				if count >= txHashLimit {
+					log.Debug("Peer exceeded outstanding transaction announces", "peer", notification.origin, "limit", txHashLimit)
+					txAnnounceDOSMeter.Mark(1)
+					break
+				}
+				// Schedule the transaction, deduplicating repeated announcements
+				if f.announcedBy(hash, notification.origin) {
+					continue
+				}
+				f.announced[hash] = append(f.announced[hash], &txAnnounce{
+					hash:     hash,
+					time:     notification.time,
+					origin:   notification.origin,
+					fetchTxs: notification.fetchTxs,
+				})
+				count++
+			}
+			if count > 0 {
+				f.announces[notification.origin] = count
+			}
+			f.rescheduleFetch(fetchTimer)
+
+		case delivery := <-f.cleanup:
+			// A batch of transactions arrived, stop tracking all of them
+			delivered := make(map[common.Hash]struct{})
+			for _, hash := range delivery.hashes {
+				delivered[hash] = struct{}{}
+				f.forgetHash(hash)
+			}
+			// If it was a reply, anything not delivered is missing from the peer
+			if req := f.requests[delivery.origin]; req != nil && !delivery.direct {
+				for _, hash := range req.hashes {
+					if _, ok := delivered[hash]; !ok {
+						f.forgetAnnounce(hash, delivery.origin)
+					}
+				}
+				delete(f.requests, delivery.origin)
+			}
+			f.rescheduleFetch(fetchTimer)
+
+		case peer := <-f.drop:
+			// A peer disconnected, forget everything it announced
+			for hash := range f.announced {
+				f.forgetAnnounce(hash, peer)
+			}
+			delete(f.requests, peer)
+			f.rescheduleFetch(fetchTimer)
+
+		case <-fetchTimer.C:
+			// Expire all the retrievals that timed out, falling back to other announcers
+			for peer, req := range f.requests {
+				if time.Since(req.time) > txFetchTimeout {
+					log.Trace("Transaction retrieval timed out", "peer", peer, "count", len(req.hashes))
+					txFetchTimeoutMeter.Mark(int64(len(req.hashes)))
+
+					for _, hash := range req.hashes {
+						f.forgetAnnounce(hash, peer)
+					}
+					delete(f.requests, peer)
+				}
+			}
+			// Gather the transactions waiting for long enough, at most one request per peer
+			request := make(map[string][]*txAnnounce)
+			for hash, announces := range f.announced {
+				if _, ok := f.fetching[hash]; ok {
+					continue
+				}
+				if time.Since(announces[0].time) <= txArriveTimeout-gatherSlack {
+					continue
+				}
+				if f.hasTx(hash) {
+					f.forgetHash(hash)
+					continue
+				}
+				// Pick the earliest announcer not busy with a previous request
+				for _, announce := range announces {
+					if _, busy := f.requests[announce.origin]; busy {
+						continue
+					}
+					if len(request[announce.origin]) >= MaxTxFetch {
+						continue
+					}
+					request[announce.origin] = append(request[announce.origin], announce)
+					f.fetching[hash] = announce
+					break
+				}
+			}
+			// Send out all the transaction requests
+			for peer, announces := range request {
+				hashes := make([]common.Hash, len(announces))
+				for i, announce := range announces {
+					hashes[i] = announce.hash
+				}
+				f.requests[peer] = &txRequest{hashes: hashes, time: time.Now()}
+
+				log.Trace("Fetching scheduled transactions", "peer", peer, "count", len(hashes))
+				txFetchMeter.Mark(int64(len(hashes)))
+
+				if f.fetchingHook != nil {
+					f.fetchingHook(peer, hashes)
+				}
+				fetchTxs := announces[0].fetchTxs
+				go func(peer string) {
+					if err := fetchTxs(hashes); err != nil {
+						log.Debug("Failed to request transactions", "peer", peer, "err", err)
+					}
+				}(peer)
+			}
+			f.rescheduleFetch(fetchTimer)
+		}
+	}
+}
+
+// rescheduleFetch resets the specified fetch timer to the next announce or
+// request timeout. Transactions whose announcers are all busy are skipped, as
+// they will be reconsidered when one of the pending requests finishes.
+func (f *TxFetcher) rescheduleFetch(fetch *time.Timer) {
+	var earliest time.Time
+	for hash, announces := range f.announced {
+		if _, ok := f.fetching[hash]; ok {
+			continue
+		}
+		for _, announce := range announces {
+			if _, busy := f.requests[announce.origin]; !busy {
+				if deadline := announces[0].time.Add(txArriveTimeout); earliest.IsZero() || deadline.Before(earliest) {
+					earliest = deadline
+				}
+				break
+			}
+		}
+	}
+	for _, req := range f.requests {
+		if deadline := req.time.Add(txFetchTimeout); earliest.IsZero() || deadline.Before(earliest) {
+			earliest = deadline
+		}
+	}
+	if earliest.IsZero() {
+		return
+	}
+	fetch.Reset(earliest.Sub(time.Now()))
+}
+
+// announcedBy checks whether a transaction was already announced by a peer.
+func (f *TxFetcher) announcedBy(hash common.Hash, peer string) bool {
+	for _, announce := range f.announced[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetHash removes all traces of a transaction announcement from the fetcher's
+// internal state.
+func (f *TxFetcher) forgetHash(hash common.Hash) {
+	announces, ok := f.announced[hash]
+	if !ok {
+		return
+	}
+	for _, announce := range announces {
+		f.decAnnounces(announce.origin)
+	}
+	delete(f.announced, hash)
+	delete(f.fetching, hash)
+}
+
+// forgetAnnounce removes a single peer's announcement of a transaction, dropping
+// the transaction altogether if no other peer announced it.
+func (f *TxFetcher) forgetAnnounce(hash common.Hash, peer string) {
+	announces := f.announced[hash]
+	for i, announce := range announces {
+		if announce.origin != peer {
+			continue
+		}
+		if fetching, ok := f.fetching[hash]; ok && fetching == announce {
+			delete(f.fetching, hash)
+		}
+		f.decAnnounces(peer)
+
+		if len(announces) == 1 {
+			delete(f.announced, hash)
+			return
+		}
+		f.announced[hash] = append(announces[:i], announces[i+1:]...)
+		return
+	}
+}
+
+// decAnnounces decrements the outstanding announce count of a peer.
+func (f *TxFetcher) decAnnounces(peer string) {
+	if f.announces[peer]--; f.announces[peer] <= 0 {
+		delete(f.announces, peer)
+	}
+}
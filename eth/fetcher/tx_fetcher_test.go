@@ -0,0 +1,278 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+)
+
+// makeTxs creates a batch of n distinct (unsigned) transactions, the fetcher
+// not caring about their validity.
+func makeTxs(n int, seed byte) ([]common.Hash, map[common.Hash]*types.Transaction) {
+	hashes := make([]common.Hash, n)
+	txs := make(map[common.Hash]*types.Transaction, n)
+	for i := 0; i < n; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{seed}, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil)
+		hashes[i] = tx.Hash()
+		txs[tx.Hash()] = tx
+	}
+	return hashes, txs
+}
+
+// txFetcherTester is a test simulator for mocking out the local transaction pool.
+type txFetcherTester struct {
+	fetcher *TxFetcher
+
+	pool     map[common.Hash]*types.Transaction // Transactions injected into the tester's pool
+	requests map[string]int                     // Number of transactions requested per peer
+
+	lock sync.RWMutex
+}
+
+// newTxTester creates a new transaction fetcher test mocker.
+func newTxTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		pool:     make(map[common.Hash]*types.Transaction),
+		requests: make(map[string]int),
+	}
+	tester.fetcher = NewTxFetcher(tester.hasTx, tester.addTxs)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) {
+		tester.lock.Lock()
+		defer tester.lock.Unlock()
+
+		tester.requests[peer] += len(hashes)
+	}
+	tester.fetcher.Start()
+
+	return tester
+}
+
+// hasTx checks whether a transaction is already in the tester's pool.
+func (f *txFetcherTester) hasTx(hash common.Hash) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	_, ok := f.pool[hash]
+	return ok
+}
+
+// addTxs injects a batch of transactions into the tester's pool.
+func (f *txFetcherTester) addTxs(txs []*types.Transaction) []error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, tx := range txs {
+		f.pool[tx.Hash()] = tx
+	}
+	return make([]error, len(txs))
+}
+
+// makeTxFetcher retrieves a transaction fetcher associated with a simulated peer
+// which replies with the subset of the requested transactions it has.
+func (f *txFetcherTester) makeTxFetcher(peer string, txs map[common.Hash]*types.Transaction) txRequesterFn {
+	return func(hashes []common.Hash) error {
+		reply := make([]*types.Transaction, 0, len(hashes))
+		for _, hash := range hashes {
+			if tx, ok := txs[hash]; ok {
+				reply = append(reply, tx)
+			}
+		}
+		go f.fetcher.Enqueue(peer, reply, false)
+		return nil
+	}
+}
+
+// makeMuteTxFetcher retrieves a transaction fetcher associated with a simulated
+// peer which never replies to requests.
+func (f *txFetcherTester) makeMuteTxFetcher() txRequesterFn {
+	return func(hashes []common.Hash) error {
+		return nil
+	}
+}
+
+// pooled returns the number of transactions in the tester's pool.
+func (f *txFetcherTester) pooled() int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return len(f.pool)
+}
+
+// requested returns the number of transactions requested from a peer.
+func (f *txFetcherTester) requested(peer string) int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.requests[peer]
+}
+
+// waitPooled waits until the given number of transactions are in the tester's
+// pool, failing the test after the timeout.
+func waitPooled(t *testing.T, tester *txFetcherTester, count int, timeout time.Duration) {
+	for deadline := time.Now().Add(timeout); tester.pooled() < count; {
+		if time.Now().After(deadline) {
+			t.Fatalf("pooled transaction count mismatch: have %d, want %d", tester.pooled(), count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that announced transactions are retrieved after the arrival timeout.
+func TestTxAnnouncementFetch(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	start := time.Now()
+	tester.fetcher.Notify("valid", hashes, start, tester.makeTxFetcher("valid", txs))
+
+	waitPooled(t, tester, len(hashes), time.Second)
+	if elapsed := time.Since(start); elapsed < txArriveTimeout-gatherSlack {
+		t.Errorf("transactions fetched too early: after %v, arrive timeout %v", elapsed, txArriveTimeout)
+	}
+	if have := tester.requested("valid"); have != len(hashes) {
+		t.Errorf("requested transaction count mismatch: have %d, want %d", have, len(hashes))
+	}
+}
+
+// Tests that transactions announced by multiple peers are only retrieved once.
+func TestTxAnnouncementDeduplication(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.fetcher.Notify("first", hashes, time.Now(), tester.makeTxFetcher("first", txs))
+	tester.fetcher.Notify("second", hashes, time.Now(), tester.makeTxFetcher("second", txs))
+	tester.fetcher.Notify("first", hashes, time.Now(), tester.makeTxFetcher("first", txs))
+
+	waitPooled(t, tester, len(hashes), time.Second)
+	time.Sleep(txArriveTimeout)
+
+	if have := tester.requested("first") + tester.requested("second"); have != len(hashes) {
+		t.Errorf("requested transaction count mismatch: have %d, want %d", have, len(hashes))
+	}
+}
+
+// Tests that announced transactions already known locally are not retrieved.
+func TestTxAnnouncementKnown(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.addTxs([]*types.Transaction{txs[hashes[0]], txs[hashes[1]]})
+
+	tester.fetcher.Notify("valid", hashes, time.Now(), tester.makeTxFetcher("valid", txs))
+	waitPooled(t, tester, len(hashes), time.Second)
+
+	if have := tester.requested("valid"); have != len(hashes)-2 {
+		t.Errorf("requested transaction count mismatch: have %d, want %d", have, len(hashes)-2)
+	}
+}
+
+// Tests that directly broadcast transactions cancel pending announcements.
+func TestTxBroadcastCancelsAnnouncement(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.fetcher.Notify("announcer", hashes, time.Now(), tester.makeTxFetcher("announcer", txs))
+
+	broadcast := make([]*types.Transaction, 0, len(hashes))
+	for _, hash := range hashes {
+		broadcast = append(broadcast, txs[hash])
+	}
+	tester.fetcher.Enqueue("broadcaster", broadcast, true)
+
+	time.Sleep(2 * txArriveTimeout)
+	if have := tester.requested("announcer"); have != 0 {
+		t.Errorf("requested transaction count mismatch: have %d, want %d", have, 0)
+	}
+}
+
+// Tests that transactions missing from a retrieval reply are fetched from an
+// alternate announcer.
+func TestTxAnnouncementMissingReply(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.fetcher.Notify("empty", hashes, time.Now(), tester.makeTxFetcher("empty", nil))
+	tester.fetcher.Notify("valid", hashes, time.Now().Add(10*time.Millisecond), tester.makeTxFetcher("valid", txs))
+
+	waitPooled(t, tester, len(hashes), 2*time.Second)
+}
+
+// Tests that timed out transaction retrievals are rescheduled to alternate
+// announcers.
+func TestTxAnnouncementTimeout(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.fetcher.Notify("mute", hashes, time.Now(), tester.makeMuteTxFetcher())
+	time.Sleep(txArriveTimeout)
+	tester.fetcher.Notify("valid", hashes, time.Now(), tester.makeTxFetcher("valid", txs))
+
+	waitPooled(t, tester, len(hashes), txFetchTimeout+time.Second)
+	if have := tester.requested("mute"); have != len(hashes) {
+		t.Errorf("mute peer request count mismatch: have %d, want %d", have, len(hashes))
+	}
+}
+
+// Tests that retrievals from a dropped peer are rescheduled to alternate
+// announcers without waiting for the timeout.
+func TestTxAnnouncementDrop(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(5, 0x01)
+	tester.fetcher.Notify("mute", hashes, time.Now(), tester.makeMuteTxFetcher())
+	time.Sleep(txArriveTimeout)
+	tester.fetcher.Notify("valid", hashes, time.Now(), tester.makeTxFetcher("valid", txs))
+
+	for tester.requested("mute") == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tester.fetcher.Drop("mute")
+	waitPooled(t, tester, len(hashes), time.Second)
+}
+
+// Tests that a peer is unable to use unbounded memory with announcing a lot of
+// transactions.
+func TestTxAnnouncementDOSProtection(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	hashes, txs := makeTxs(txHashLimit+MaxTxFetch, 0x01)
+	tester.fetcher.Notify("attacker", hashes, time.Now(), tester.makeTxFetcher("attacker", txs))
+
+	waitPooled(t, tester, txHashLimit, 5*time.Second)
+	time.Sleep(txArriveTimeout)
+
+	if have := tester.pooled(); have != txHashLimit {
+		t.Errorf("pooled transaction count mismatch: have %d, want %d", have, txHashLimit)
+	}
+	if have := tester.requested("attacker"); have != txHashLimit {
+		t.Errorf("requested transaction count mismatch: have %d, want %d", have, txHashLimit)
+	}
+}
@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core"
 	"github.com/teamnsrg/ethereum-p2p/core/types"
 	"github.com/teamnsrg/ethereum-p2p/ethdb"
 	"github.com/teamnsrg/ethereum-p2p/event"
@@ -37,6 +39,40 @@ var (
 	deadline = 5 * time.Minute // consider a filter inactive if it has not been polled for within deadline
 )
 
+// defaultPageSize is the number of logs returned in a page of a log query, if
+// the client doesn't request a specific limit.
+const defaultPageSize = 1000
+
+var (
+	// errInvalidCursor is returned if a log query is attempted to be resumed from
+	// a malformed continuation cursor.
+	errInvalidCursor = errors.New("invalid log query cursor")
+
+	// errQueryTimeout is returned if a non-paginated log query could not finish
+	// within the time budget configured by the operator.
+	errQueryTimeout = errors.New("log query timed out, use pagination")
+
+	// errDurableToBlock is returned if a durable filter is requested with an end
+	// block, as durable filters always follow the chain head.
+	errDurableToBlock = errors.New("durable filters cannot have a toBlock")
+
+	// errDurableDisabled is returned if a durable filter is requested from a node
+	// whose operator didn't allow any.
+	errDurableDisabled = errors.New("durable filters disabled (enable with --logs.maxdurable)")
+
+	// errDurableLimit is returned if a durable filter is requested while the number
+	// allowed by the operator is already installed.
+	errDurableLimit = errors.New("too many durable filters")
+)
+
+// Config contains the operator imposed limits of the log queries.
+type Config struct {
+	MaxResults int           `toml:",omitempty"` // Maximum number of logs returned by a single query (0 = unlimited)
+	MaxRange   uint64        `toml:",omitempty"` // Maximum number of blocks searched by a single query (0 = unlimited)
+	Timeout    time.Duration `toml:",omitempty"` // Time budget of a single query (0 = unlimited)
+	MaxDurable int           `toml:",omitempty"` // Maximum number of durable filters installed (0 = disabled)
+}
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -52,18 +88,21 @@ type filter struct {
 // information related to the Ethereum protocol such als blocks, transactions and logs.
 type PublicFilterAPI struct {
 	backend   Backend
+	config    Config
 	mux       *event.TypeMux
 	quit      chan struct{}
 	chainDb   ethdb.Database
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+	durableMu sync.Mutex // Serializes the polls of the durable filters
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
+func NewPublicFilterAPI(backend Backend, lightMode bool, config Config) *PublicFilterAPI {
 	api := &PublicFilterAPI{
 		backend: backend,
+		config:  config,
 		mux:     backend.EventMux(),
 		chainDb: backend.ChainDb(),
 		events:  NewEventSystem(backend.EventMux(), backend, lightMode),
@@ -228,6 +267,76 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// reorgResult is the notification sent to reorg subscribers, describing a chain
+// reorganisation. Blocks are ordered by number, starting after the common ancestor.
+type reorgResult struct {
+	CommonAncestor *types.Header   `json:"commonAncestor"`
+	Dropped        []*types.Header `json:"dropped"`
+	Added          []*types.Header `json:"added"`
+	DroppedTxs     []common.Hash   `json:"droppedTransactions"`
+	AddedTxs       []common.Hash   `json:"addedTransactions"`
+	DroppedLogs    []*types.Log    `json:"droppedLogs"`
+	AddedLogs      []*types.Log    `json:"addedLogs"`
+}
+
+// newReorgResult converts a reorg event into its RPC representation.
+func newReorgResult(ev *core.ReorgEvent) *reorgResult {
+	result := &reorgResult{
+		CommonAncestor: ev.CommonBlock.Header(),
+		Dropped:        make([]*types.Header, len(ev.OldChain)),
+		Added:          make([]*types.Header, len(ev.NewChain)),
+		DroppedTxs:     make([]common.Hash, len(ev.DroppedTxs)),
+		AddedTxs:       make([]common.Hash, len(ev.AddedTxs)),
+		DroppedLogs:    ev.DroppedLogs,
+		AddedLogs:      ev.AddedLogs,
+	}
+	for i, block := range ev.OldChain {
+		result.Dropped[i] = block.Header()
+	}
+	for i, block := range ev.NewChain {
+		result.Added[i] = block.Header()
+	}
+	for i, tx := range ev.DroppedTxs {
+		result.DroppedTxs[i] = tx.Hash()
+	}
+	for i, tx := range ev.AddedTxs {
+		result.AddedTxs[i] = tx.Hash()
+	}
+	return result
+}
+
+// Reorgs sends a notification each time the canonical chain is reorganised,
+// containing the common ancestor along with all the dropped and added blocks,
+// transactions and logs.
+func (api *PublicFilterAPI) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan *core.ReorgEvent)
+		reorgsSub := api.events.SubscribeReorgs(reorgs)
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, newReorgResult(ev))
+			case <-rpcSub.Err():
+				reorgsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -319,25 +428,129 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 	return logsSub.ID, nil
 }
 
-// GetLogs returns logs matching the given argument that are stored within the state.
+// NewDurableFilter creates a log filter persisted in the chain database, which
+// doesn't expire and survives node restarts. Its changes are polled through
+// GetFilterChanges the same way as for regular filters, but it also delivers the
+// logs of blocks imported while the client wasn't polling and, on chain reorgs,
+// redelivers the logs of the reverted blocks with the removed flag set.
 //
-// https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
-func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	// Convert the RPC block numbers into internal representations
-	if crit.FromBlock == nil {
-		crit.FromBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
+// The logs are delivered starting from the "fromBlock" of the criteria (latest
+// if omitted), in batches capped by the operator's log query limits. As durable
+// filters are never expired, only as many can be installed as the operator allows.
+func (api *PublicFilterAPI) NewDurableFilter(ctx context.Context, crit FilterCriteria) (rpc.ID, error) {
+	if api.config.MaxDurable <= 0 {
+		return "", errDurableDisabled
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Int64() != rpc.LatestBlockNumber.Int64() {
+		return "", errDurableToBlock
+	}
+	begin, _, err := api.resolveRange(ctx, crit)
+	if err != nil {
+		return "", err
+	}
+	filter := &durableFilter{
+		Begin:     begin,
+		Addresses: crit.Addresses,
+		Topics:    crit.Topics,
+		Next:      begin,
 	}
-	if crit.ToBlock == nil {
-		crit.ToBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
+	if begin > 0 {
+		filter.Parent = core.GetCanonicalHash(api.chainDb, begin-1)
 	}
-	// Create and run the filter to get all the logs
-	filter := New(api.backend, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit.Addresses, crit.Topics)
+	api.durableMu.Lock()
+	defer api.durableMu.Unlock()
 
-	logs, err := filter.Logs(ctx)
+	ids := readDurableFilterIndex(api.chainDb)
+	if len(ids) >= api.config.MaxDurable {
+		return "", errDurableLimit
+	}
+	id := rpc.NewID()
+	if err := writeDurableFilter(api.chainDb, id, filter); err != nil {
+		return "", err
+	}
+	if err := writeDurableFilterIndex(api.chainDb, append(ids, id)); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// durableChanges rewinds a durable filter to the canonical chain, and delivers
+// its logs up to the current head (or the operator's query limits). The filter
+// position is persisted after each delivery.
+func (api *PublicFilterAPI) durableChanges(ctx context.Context, id rpc.ID, f *durableFilter) ([]*types.Log, error) {
+	removed, err := f.rewind(ctx, api.backend)
 	if err != nil {
 		return nil, err
 	}
-	return returnLogs(logs), err
+	header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	var (
+		head = header.Number.Uint64()
+		logs []*types.Log
+	)
+	if f.Next <= head {
+		last := head
+		if api.config.MaxRange > 0 && last-f.Next+1 > api.config.MaxRange {
+			last = f.Next + api.config.MaxRange - 1
+		}
+		limit := defaultPageSize
+		if api.config.MaxResults > 0 {
+			limit = api.config.MaxResults
+		}
+		filter := New(api.backend, int64(f.Next), int64(last), f.Addresses, f.Topics)
+		filter.SetLimit(limit, int(f.Skip))
+		if api.config.Timeout > 0 {
+			filter.SetDeadline(time.Now().Add(api.config.Timeout))
+		}
+		if logs, err = filter.Logs(ctx); err != nil {
+			return nil, err
+		}
+		next, skip := filter.Cursor()
+
+		f.Next, f.Skip, f.Partial = next, uint64(skip), common.Hash{}
+		if next > 0 {
+			f.Parent = core.GetCanonicalHash(api.chainDb, next-1)
+		}
+		if skip > 0 {
+			f.Partial = core.GetCanonicalHash(api.chainDb, next)
+		}
+	}
+	if err := writeDurableFilter(api.chainDb, id, f); err != nil {
+		return nil, err
+	}
+	return returnLogs(append(removed, logs...)), nil
+}
+
+// GetLogs returns logs matching the given argument that are stored within the state.
+// If the query exceeds the limits configured by the operator, an error is returned
+// and GetLogsPage should be used instead.
+//
+// https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
+func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	return api.logs(ctx, crit)
+}
+
+// LogsPage is a chunk of the results of a log query, along with the cursor to
+// retrieve the next chunk with.
+type LogsPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor string       `json:"cursor,omitempty"` // Continuation token, empty if the query finished
+}
+
+// PageOptions are the pagination parameters of a log query.
+type PageOptions struct {
+	Limit  int    `json:"limit"`  // Maximum number of logs to return (capped by the operator)
+	Cursor string `json:"cursor"` // Continuation token of a previous page, empty to start
+}
+
+// GetLogsPage returns a page of logs matching the given argument. The query is
+// continued by passing the returned cursor back along with the same criteria,
+// until no more cursor is returned. Pages may contain fewer logs than the limit
+// (even none) if the operator's time budget or block range cap is hit.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, opts *PageOptions) (*LogsPage, error) {
+	return api.logsPage(ctx, crit, opts)
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -352,9 +565,26 @@ func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Unlock()
 	if found {
 		f.s.Unsubscribe()
+		return true
 	}
+	// Not a regular filter, try the durable ones
+	api.durableMu.Lock()
+	defer api.durableMu.Unlock()
 
-	return found
+	if durable, _ := readDurableFilter(api.chainDb, id); durable != nil {
+		if err := deleteDurableFilter(api.chainDb, id); err != nil {
+			return false
+		}
+		ids := readDurableFilterIndex(api.chainDb)
+		for i, have := range ids {
+			if have == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		return writeDurableFilterIndex(api.chainDb, ids) == nil
+	}
+	return false
 }
 
 // GetFilterLogs returns the logs for the filter with the given id.
@@ -362,32 +592,177 @@ func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getfilterlogs
 func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Log, error) {
+	crit, err := api.filterCriteria(id)
+	if err != nil {
+		return nil, err
+	}
+	return api.logs(ctx, crit)
+}
+
+// GetFilterLogsPage returns a page of logs for the filter with the given id. See
+// GetLogsPage for the pagination semantics.
+func (api *PublicFilterAPI) GetFilterLogsPage(ctx context.Context, id rpc.ID, opts *PageOptions) (*LogsPage, error) {
+	crit, err := api.filterCriteria(id)
+	if err != nil {
+		return nil, err
+	}
+	return api.logsPage(ctx, crit, opts)
+}
+
+// filterCriteria retrieves the criteria of a regular or durable log filter.
+func (api *PublicFilterAPI) filterCriteria(id rpc.ID) (FilterCriteria, error) {
 	api.filtersMu.Lock()
 	f, found := api.filters[id]
 	api.filtersMu.Unlock()
 
-	if !found || f.typ != LogsSubscription {
-		return nil, fmt.Errorf("filter not found")
+	if found && f.typ == LogsSubscription {
+		return f.crit, nil
 	}
+	if !found {
+		durable, err := readDurableFilter(api.chainDb, id)
+		if err != nil {
+			return FilterCriteria{}, err
+		}
+		if durable != nil {
+			return FilterCriteria{
+				FromBlock: new(big.Int).SetUint64(durable.Begin),
+				Addresses: durable.Addresses,
+				Topics:    durable.Topics,
+			}, nil
+		}
+	}
+	return FilterCriteria{}, fmt.Errorf("filter not found")
+}
 
-	begin := rpc.LatestBlockNumber.Int64()
-	if f.crit.FromBlock != nil {
-		begin = f.crit.FromBlock.Int64()
+// logs runs a log query in its entirety, failing if the results would exceed the
+// limits configured by the operator.
+func (api *PublicFilterAPI) logs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	begin, end, err := api.resolveRange(ctx, crit)
+	if err != nil {
+		return nil, err
 	}
-	end := rpc.LatestBlockNumber.Int64()
-	if f.crit.ToBlock != nil {
-		end = f.crit.ToBlock.Int64()
+	if api.config.MaxRange > 0 && end >= begin && end-begin+1 > api.config.MaxRange {
+		return nil, fmt.Errorf("log query exceeds %d blocks, use pagination", api.config.MaxRange)
+	}
+	// Create and run the filter to get all the logs, detecting any overflow
+	filter := New(api.backend, int64(begin), int64(end), crit.Addresses, crit.Topics)
+	if api.config.MaxResults > 0 {
+		filter.SetLimit(api.config.MaxResults+1, 0)
+	}
+	if api.config.Timeout > 0 {
+		filter.SetDeadline(time.Now().Add(api.config.Timeout))
 	}
-	// Create and run the filter to get all the logs
-	filter := New(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
-
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if api.config.MaxResults > 0 && len(logs) > api.config.MaxResults {
+		return nil, fmt.Errorf("log query exceeds %d results, use pagination", api.config.MaxResults)
+	}
+	if filter.TimedOut() {
+		return nil, errQueryTimeout
+	}
 	return returnLogs(logs), nil
 }
 
+// logsPage runs a log query from its start or the given cursor, until the page
+// is full or the limits configured by the operator are reached.
+func (api *PublicFilterAPI) logsPage(ctx context.Context, crit FilterCriteria, opts *PageOptions) (*LogsPage, error) {
+	if opts == nil {
+		opts = new(PageOptions)
+	}
+	// Resolve the range of the query, or where to resume it from
+	var (
+		begin, end uint64
+		skip       int
+		err        error
+	)
+	if opts.Cursor != "" {
+		begin, skip, end, err = decodeCursor(opts.Cursor)
+	} else {
+		begin, end, err = api.resolveRange(ctx, crit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultPageSize
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+	if api.config.MaxResults > 0 && limit > api.config.MaxResults {
+		limit = api.config.MaxResults
+	}
+	last := end
+	if api.config.MaxRange > 0 && end >= begin && end-begin+1 > api.config.MaxRange {
+		last = begin + api.config.MaxRange - 1
+	}
+	// Run the filter over the page's range and return the logs with the cursor
+	filter := New(api.backend, int64(begin), int64(last), crit.Addresses, crit.Topics)
+	filter.SetLimit(limit, skip)
+	if api.config.Timeout > 0 {
+		filter.SetDeadline(time.Now().Add(api.config.Timeout))
+	}
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogsPage{Logs: returnLogs(logs)}
+	if next, skip := filter.Cursor(); next <= end {
+		page.Cursor = encodeCursor(next, skip, end)
+	}
+	return page, nil
+}
+
+// resolveRange converts the block range of the filter criteria into absolute
+// block numbers, defaulting to and resolving "latest" to the current head. The
+// end of the range is capped at the current head.
+func (api *PublicFilterAPI) resolveRange(ctx context.Context, crit FilterCriteria) (uint64, uint64, error) {
+	header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, 0, err
+	}
+	if header == nil {
+		return 0, 0, errors.New("unknown head block")
+	}
+	head := header.Number.Uint64()
+
+	begin, end := head, head
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		begin = crit.FromBlock.Uint64()
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 {
+		end = crit.ToBlock.Uint64()
+	}
+	if end > head {
+		end = head
+	}
+	return begin, end, nil
+}
+
+// encodeCursor packs the position of a log query into a continuation token.
+func encodeCursor(next uint64, skip int, end uint64) string {
+	blob := make([]byte, 24)
+	binary.BigEndian.PutUint64(blob[0:], next)
+	binary.BigEndian.PutUint64(blob[8:], uint64(skip))
+	binary.BigEndian.PutUint64(blob[16:], end)
+	return hexutil.Encode(blob)
+}
+
+// decodeCursor unpacks the position of a log query from a continuation token.
+func decodeCursor(cursor string) (uint64, int, uint64, error) {
+	blob, err := hexutil.Decode(cursor)
+	if err != nil || len(blob) != 24 {
+		return 0, 0, 0, errInvalidCursor
+	}
+	next := binary.BigEndian.Uint64(blob[0:])
+	skip := binary.BigEndian.Uint64(blob[8:])
+	end := binary.BigEndian.Uint64(blob[16:])
+	if next > end || skip > uint64(^uint32(0)) {
+		return 0, 0, 0, errInvalidCursor
+	}
+	return next, int(skip), end, nil
+}
+
 // GetFilterChanges returns the logs for the filter with the given id since
 // last time it was called. This can be used for polling.
 //
@@ -396,6 +771,23 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getfilterchanges
 func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
+	// If the filter is not a regular one, try the durable filters
+	api.filtersMu.Lock()
+	_, found := api.filters[id]
+	api.filtersMu.Unlock()
+
+	if !found {
+		api.durableMu.Lock()
+		defer api.durableMu.Unlock()
+
+		durable, err := readDurableFilter(api.chainDb, id)
+		if err != nil {
+			return nil, err
+		}
+		if durable != nil {
+			return api.durableChanges(context.Background(), id, durable)
+		}
+	}
 	api.filtersMu.Lock()
 	defer api.filtersMu.Unlock()
 
@@ -0,0 +1,173 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/rlp"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+)
+
+var (
+	// durableFilterPrefix is the database key prefix of the durable log filters,
+	// followed by the filter id.
+	durableFilterPrefix = []byte("durable-filter-")
+
+	// durableFilterIndexKey tracks the ids of all installed durable filters.
+	durableFilterIndexKey = []byte("DurableFilters")
+)
+
+// errMissingReorgBlock is returned if a durable filter cannot be rewound past a
+// chain reorganisation because a previously delivered block is not available.
+var errMissingReorgBlock = errors.New("missing reorganised block")
+
+// durableFilter is a log filter persisted into the chain database along with the
+// position up to which its logs were delivered, allowing it to be polled across
+// node restarts and chain reorganisations.
+type durableFilter struct {
+	Begin     uint64           // First block the filter was requested from
+	Addresses []common.Address // Contract addresses to match logs of
+	Topics    [][]common.Hash  // Topics to match logs with
+
+	Next    uint64      // Next block to deliver the logs of
+	Parent  common.Hash // Hash of the last fully delivered block (parent of Next, zero if none yet)
+	Skip    uint64      // Number of logs already delivered from block Next
+	Partial common.Hash // Hash of the partially delivered block, if Skip > 0
+}
+
+// durableFilterKey = durableFilterPrefix + id
+func durableFilterKey(id rpc.ID) []byte {
+	return append(append([]byte{}, durableFilterPrefix...), id...)
+}
+
+// readDurableFilter retrieves a durable log filter from the database, or nil if
+// no filter exists with the given id.
+func readDurableFilter(db ethdb.Database, id rpc.ID) (*durableFilter, error) {
+	blob, err := db.Get(durableFilterKey(id))
+	if len(blob) == 0 || err != nil {
+		return nil, nil
+	}
+	filter := new(durableFilter)
+	if err := rlp.DecodeBytes(blob, filter); err != nil {
+		return nil, fmt.Errorf("corrupt durable filter %s: %v", id, err)
+	}
+	return filter, nil
+}
+
+// writeDurableFilter stores a durable log filter along with its delivery position.
+func writeDurableFilter(db ethdb.Database, id rpc.ID, filter *durableFilter) error {
+	blob, err := rlp.EncodeToBytes(filter)
+	if err != nil {
+		return err
+	}
+	return db.Put(durableFilterKey(id), blob)
+}
+
+// deleteDurableFilter removes a durable log filter from the database.
+func deleteDurableFilter(db ethdb.Database, id rpc.ID) error {
+	return db.Delete(durableFilterKey(id))
+}
+
+// readDurableFilterIndex retrieves the ids of all installed durable filters.
+func readDurableFilterIndex(db ethdb.Database) []rpc.ID {
+	blob, err := db.Get(durableFilterIndexKey)
+	if len(blob) == 0 || err != nil {
+		return nil
+	}
+	var ids []rpc.ID
+	if err := rlp.DecodeBytes(blob, &ids); err != nil {
+		return nil
+	}
+	return ids
+}
+
+// writeDurableFilterIndex stores the ids of all installed durable filters.
+func writeDurableFilterIndex(db ethdb.Database, ids []rpc.ID) error {
+	blob, err := rlp.EncodeToBytes(ids)
+	if err != nil {
+		return err
+	}
+	return db.Put(durableFilterIndexKey, blob)
+}
+
+// rewind moves the delivery position of a durable filter back to the canonical
+// chain, returning the already delivered logs of the reorganised blocks marked
+// as removed, in chain order.
+func (f *durableFilter) rewind(ctx context.Context, backend Backend) ([]*types.Log, error) {
+	var (
+		db      = backend.ChainDb()
+		removed [][]*types.Log
+	)
+	// Revert the partially delivered block if it was reorganised
+	if f.Skip > 0 {
+		if core.GetCanonicalHash(db, f.Next) == f.Partial {
+			return nil, nil // Parent must be canonical too
+		}
+		logs, err := f.blockLogs(ctx, backend, f.Partial)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(logs)) > f.Skip {
+			logs = logs[:f.Skip]
+		}
+		removed = append(removed, logs)
+		f.Skip, f.Partial = 0, common.Hash{}
+	}
+	// Walk back the fully delivered blocks until the canonical chain is reached
+	for f.Parent != (common.Hash{}) && core.GetCanonicalHash(db, f.Next-1) != f.Parent {
+		header := core.GetHeader(db, f.Parent, f.Next-1)
+		if header == nil {
+			return nil, errMissingReorgBlock
+		}
+		logs, err := f.blockLogs(ctx, backend, f.Parent)
+		if err != nil {
+			return nil, err
+		}
+		removed = append(removed, logs)
+		f.Next, f.Parent = f.Next-1, header.ParentHash
+	}
+	// Flatten the removed logs into chain order and flag them
+	var logs []*types.Log
+	for i := len(removed) - 1; i >= 0; i-- {
+		for _, log := range removed[i] {
+			cpy := *log
+			cpy.Removed = true
+			logs = append(logs, &cpy)
+		}
+	}
+	return logs, nil
+}
+
+// blockLogs retrieves the logs of a block matching the filter's criteria.
+func (f *durableFilter) blockLogs(ctx context.Context, backend Backend, hash common.Hash) ([]*types.Log, error) {
+	receipts, err := backend.GetReceipts(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, receipt := range receipts {
+		unfiltered = append(unfiltered, receipt.Logs...)
+	}
+	return filterLogs(unfiltered, nil, nil, f.Addresses, f.Topics), nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/event"
+	"github.com/teamnsrg/ethereum-p2p/params"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+)
+
+// Tests that durable filters survive API restarts and redeliver the logs of
+// reorganised blocks as removed, including partially delivered ones.
+func TestDurableFilter(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.BytesToAddress([]byte("jeff"))
+	)
+	// makeLogs generates a chain with the requested number of logs in some blocks,
+	// importing it as the canonical one.
+	makeLogs := func(parent *types.Block, n int, counts map[int]int, tag byte) []*types.Block {
+		blocks, receipts := core.GenerateChain(params.TestChainConfig, parent, db, n, func(i int, gen *core.BlockGen) {
+			gen.SetExtra([]byte{tag})
+			if count, ok := counts[i]; ok {
+				receipt := types.NewReceipt(nil, false, new(big.Int))
+				for j := 0; j < count; j++ {
+					receipt.Logs = append(receipt.Logs, &types.Log{Address: addr, Data: []byte{tag, byte(j)}})
+				}
+				receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+				gen.AddUncheckedReceipt(receipt)
+			}
+		})
+		for i, block := range blocks {
+			for _, receipt := range receipts[i] {
+				for _, log := range receipt.Logs {
+					log.BlockNumber, log.BlockHash = block.NumberU64(), block.Hash()
+				}
+			}
+			core.WriteBlock(db, block)
+			core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			core.WriteHeadBlockHash(db, block.Hash())
+			core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		}
+		return blocks
+	}
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain := makeLogs(genesis, 10, map[int]int{2: 1, 7: 2}, 0xa)
+
+	// Create a durable filter and retrieve the logs up to the limit
+	api := NewPublicFilterAPI(backend, false, Config{MaxResults: 2, MaxDurable: 1})
+	id, err := api.NewDurableFilter(context.Background(), FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}})
+	if err != nil {
+		t.Fatalf("failed to create durable filter: %v", err)
+	}
+	changes, err := api.GetFilterChanges(id)
+	if err != nil {
+		t.Fatalf("failed to retrieve changes: %v", err)
+	}
+	logs := changes.([]*types.Log)
+	if len(logs) != 2 || logs[0].BlockNumber != 3 || logs[1].BlockNumber != 8 || logs[1].Removed {
+		t.Fatalf("initial logs mismatch: have %v", logs)
+	}
+	// Restart the API, reorg out the partially delivered block and ensure the
+	// delivered log is removed and the new ones returned
+	makeLogs(chain[6], 4, map[int]int{1: 1}, 0xb)
+
+	api = NewPublicFilterAPI(backend, false, Config{MaxResults: 2, MaxDurable: 1})
+	if changes, err = api.GetFilterChanges(id); err != nil {
+		t.Fatalf("failed to retrieve changes after reorg: %v", err)
+	}
+	logs = changes.([]*types.Log)
+	if len(logs) != 2 {
+		t.Fatalf("reorg log count mismatch: have %d, want %d", len(logs), 2)
+	}
+	if log := logs[0]; !log.Removed || log.BlockHash != chain[7].Hash() || string(log.Data) != string([]byte{0xa, 0}) {
+		t.Errorf("removed log mismatch: have %+v", log)
+	}
+	if log := logs[1]; log.Removed || log.BlockNumber != 9 || string(log.Data) != string([]byte{0xb, 0}) {
+		t.Errorf("new log mismatch: have %+v", log)
+	}
+	// Ensure nothing else is delivered and that the filter can be uninstalled
+	if changes, err = api.GetFilterChanges(id); err != nil || len(changes.([]*types.Log)) != 0 {
+		t.Errorf("unexpected changes: have %v, %v", changes, err)
+	}
+	if !api.UninstallFilter(id) {
+		t.Fatalf("failed to uninstall durable filter")
+	}
+	if _, err = api.GetFilterChanges(id); err == nil {
+		t.Errorf("uninstalled filter still polled")
+	}
+}
+
+// Tests that durable filters can only be installed if enabled by the operator,
+// and only up to the configured limit.
+func TestDurableFilterLimit(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		crit    = FilterCriteria{FromBlock: big.NewInt(0)}
+	)
+	core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+
+	disabled := NewPublicFilterAPI(backend, false, Config{})
+	if _, err := disabled.NewDurableFilter(context.Background(), crit); err != errDurableDisabled {
+		t.Fatalf("disabled filter creation error mismatch: have %v, want %v", err, errDurableDisabled)
+	}
+	api := NewPublicFilterAPI(backend, false, Config{MaxDurable: 2})
+	ids := make([]rpc.ID, 2)
+	for i := range ids {
+		id, err := api.NewDurableFilter(context.Background(), crit)
+		if err != nil {
+			t.Fatalf("failed to create filter %d: %v", i, err)
+		}
+		ids[i] = id
+	}
+	if _, err := api.NewDurableFilter(context.Background(), crit); err != errDurableLimit {
+		t.Fatalf("excess filter creation error mismatch: have %v, want %v", err, errDurableLimit)
+	}
+	// Ensure the limit is enforced across restarts and freed by uninstalls
+	api = NewPublicFilterAPI(backend, false, Config{MaxDurable: 2})
+	if _, err := api.NewDurableFilter(context.Background(), crit); err != errDurableLimit {
+		t.Fatalf("excess filter creation error mismatch after restart: have %v, want %v", err, errDurableLimit)
+	}
+	if !api.UninstallFilter(ids[0]) {
+		t.Fatalf("failed to uninstall durable filter")
+	}
+	if _, err := api.NewDurableFilter(context.Background(), crit); err != nil {
+		t.Fatalf("failed to create filter after uninstall: %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/core"
@@ -39,6 +40,7 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -53,6 +55,11 @@ type Filter struct {
 	addresses  []common.Address
 	topics     [][]common.Hash
 
+	limit    int       // Maximum number of logs to return from a single Logs call (0 = unlimited)
+	skip     int       // Number of matching logs to skip from the first block (already returned)
+	deadline time.Time // Time after which Logs returns the logs gathered so far (zero = none)
+	timedOut bool      // Whether the last Logs call was interrupted by the deadline
+
 	matcher *bloombits.Matcher
 }
 
@@ -91,9 +98,44 @@ func New(backend Backend, begin, end int64, addresses []common.Address, topics [
 	}
 }
 
+// SetLimit caps the number of logs returned by a single Logs call, skipping the
+// given number of matching logs from the first block of the range (returned by a
+// previous call). A zero limit means no limit.
+func (f *Filter) SetLimit(limit int, skip int) {
+	f.limit, f.skip = limit, skip
+}
+
+// SetDeadline makes Logs return the logs gathered so far when the given time is
+// reached, instead of finishing the entire range. The filter can be resumed from
+// its cursor afterwards.
+func (f *Filter) SetDeadline(deadline time.Time) {
+	f.deadline = deadline
+}
+
+// Cursor returns the position a subsequent Logs call would resume from: the next
+// block to search and the number of its matching logs already returned.
+func (f *Filter) Cursor() (uint64, int) {
+	return uint64(f.begin), f.skip
+}
+
+// TimedOut returns whether the last Logs call returned early because the deadline
+// was reached.
+func (f *Filter) TimedOut() bool {
+	return f.timedOut
+}
+
+// Done returns whether the entire range of the filter was searched.
+func (f *Filter) Done() bool {
+	return f.end != -1 && f.begin > f.end
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
+// If a limit or deadline is set, the search may stop early, in which case it can
+// be continued by calling Logs again.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	f.timedOut = false
+
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if header == nil {
@@ -104,10 +146,10 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
-	end := uint64(f.end)
 	if f.end == -1 {
-		end = head
+		f.end = int64(head)
 	}
+	end := uint64(f.end)
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -115,23 +157,24 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	)
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
+		last := indexed - 1
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
-		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			last = end
 		}
-		if err != nil {
+		if logs, err = f.indexedLogs(ctx, last, logs); err != nil {
 			return logs, err
 		}
+		// Stop if the limit or the deadline was hit during the indexed search
+		if f.begin <= int64(last) {
+			return logs, nil
+		}
 	}
-	rest, err := f.unindexedLogs(ctx, end)
-	logs = append(logs, rest...)
-	return logs, err
+	return f.unindexedLogs(ctx, end, logs)
 }
 
 // indexedLogs returns the logs matching the filter criteria based on the bloom
-// bits indexed available locally or via the network.
-func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+// bits indexed available locally or via the network, appended to the given ones.
+func (f *Filter) indexedLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
 	// Create a matcher session and request servicing from the backend
 	matches := make(chan uint64, 64)
 
@@ -143,9 +186,14 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 
 	f.backend.ServiceFilter(ctx, session)
 
-	// Iterate over the matches until exhausted or context closed
-	var logs []*types.Log
+	// Iterate over the matches until exhausted, context closed or deadline hit
+	var timeout <-chan time.Time
+	if !f.deadline.IsZero() {
+		timer := time.NewTimer(f.deadline.Sub(time.Now()))
+		defer timer.Stop()
 
+		timeout = timer.C
+	}
 	for {
 		select {
 		case number, ok := <-matches:
@@ -153,12 +201,10 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if !ok {
 				err := session.Error()
 				if err == nil {
-					f.begin = int64(end) + 1
+					f.begin, f.skip = int64(end)+1, 0
 				}
 				return logs, err
 			}
-			f.begin = int64(number) + 1
-
 			// Retrieve the suggested block and pull any truly matching logs
 			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
 			if header == nil || err != nil {
@@ -168,7 +214,14 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if err != nil {
 				return logs, err
 			}
-			logs = append(logs, found...)
+			var full bool
+			if logs, full = f.collect(logs, number, found); full {
+				return logs, nil
+			}
+
+		case <-timeout:
+			f.timedOut = true
+			return logs, nil
 
 		case <-ctx.Done():
 			return logs, ctx.Err()
@@ -176,27 +229,58 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 	}
 }
 
-// indexedLogs returns the logs matching the filter criteria based on raw block
-// iteration and bloom matching.
-func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	var logs []*types.Log
-
-	for ; f.begin <= int64(end); f.begin++ {
+// unindexedLogs returns the logs matching the filter criteria based on raw block
+// iteration and bloom matching, appended to the given ones.
+func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
+	for f.begin <= int64(end) {
+		if !f.deadline.IsZero() && time.Now().After(f.deadline) {
+			f.timedOut = true
+			return logs, nil
+		}
 		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
 		if header == nil || err != nil {
 			return logs, err
 		}
+		var found []*types.Log
 		if bloomFilter(header.Bloom, f.addresses, f.topics) {
-			found, err := f.checkMatches(ctx, header)
-			if err != nil {
+			if found, err = f.checkMatches(ctx, header); err != nil {
 				return logs, err
 			}
-			logs = append(logs, found...)
+		}
+		var full bool
+		if logs, full = f.collect(logs, uint64(f.begin), found); full {
+			return logs, nil
 		}
 	}
 	return logs, nil
 }
 
+// collect appends the logs found in a block to the already gathered ones, up to
+// the filter's limit, and moves the start of the filter past the collected logs.
+// The returned flag is true if the limit was reached.
+func (f *Filter) collect(logs []*types.Log, number uint64, found []*types.Log) ([]*types.Log, bool) {
+	// Drop the logs already returned from this block by a previous call
+	if int64(number) != f.begin {
+		f.skip = 0
+	}
+	if f.skip >= len(found) {
+		found = nil
+	} else {
+		found = found[f.skip:]
+	}
+	// Collect the logs, stopping mid-block if the limit is reached
+	if f.limit > 0 && len(logs)+len(found) > f.limit {
+		room := f.limit - len(logs)
+
+		f.begin, f.skip = int64(number), f.skip+room
+		return append(logs, found[:room]...), true
+	}
+	f.begin, f.skip = int64(number)+1, 0
+	logs = append(logs, found...)
+
+	return logs, f.limit > 0 && len(logs) == f.limit
+}
+
 // checkMatches checks if the receipts belonging to the given header contain any log events that
 // match the filter criteria. This function is called when the bloom filter signals a potential match.
 func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
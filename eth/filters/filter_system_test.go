@@ -43,6 +43,7 @@ type testBackend struct {
 	rmLogsFeed *event.Feed
 	logsFeed   *event.Feed
 	chainFeed  *event.Feed
+	reorgFeed  *event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -87,6 +88,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -133,8 +138,8 @@ func TestBlockSubscription(t *testing.T) {
 		rmLogsFeed  = new(event.Feed)
 		logsFeed    = new(event.Feed)
 		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api         = NewPublicFilterAPI(backend, false)
+		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api         = NewPublicFilterAPI(backend, false, Config{})
 		genesis     = new(core.Genesis).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *core.BlockGen) {})
 		chainEvents = []core.ChainEvent{}
@@ -179,6 +184,42 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestReorgSubscription tests if a reorg subscription receives the details of
+// the chain reorganisations posted by the backend.
+func TestReorgSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux         = new(event.TypeMux)
+		db, _       = ethdb.NewMemDatabase()
+		reorgFeed   = new(event.Feed)
+		backend     = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), reorgFeed}
+		api         = NewPublicFilterAPI(backend, false, Config{})
+		genesis     = new(core.Genesis).MustCommit(db)
+		oldChain, _ = core.GenerateChain(params.TestChainConfig, genesis, db, 2, func(i int, gen *core.BlockGen) {})
+		newChain, _ = core.GenerateChain(params.TestChainConfig, genesis, db, 3, func(i int, gen *core.BlockGen) { gen.SetExtra([]byte("fork")) })
+	)
+	reorgs := make(chan *core.ReorgEvent)
+	sub := api.events.SubscribeReorgs(reorgs)
+	defer sub.Unsubscribe()
+
+	time.Sleep(100 * time.Millisecond)
+	go reorgFeed.Send(core.ReorgEvent{CommonBlock: genesis, OldChain: oldChain, NewChain: newChain})
+
+	select {
+	case ev := <-reorgs:
+		if ev.CommonBlock.Hash() != genesis.Hash() || len(ev.OldChain) != len(oldChain) || len(ev.NewChain) != len(newChain) {
+			t.Errorf("reorg event mismatch: common %x, dropped %d, added %d", ev.CommonBlock.Hash(), len(ev.OldChain), len(ev.NewChain))
+		}
+		result := newReorgResult(ev)
+		if len(result.Dropped) != len(oldChain) || result.Added[2].Hash() != newChain[2].Hash() {
+			t.Errorf("reorg notification mismatch: %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reorg event received")
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -190,8 +231,8 @@ func TestPendingTxFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false, Config{})
 
 		transactions = []*types.Transaction{
 			types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), new(big.Int), new(big.Int), nil),
@@ -253,8 +294,8 @@ func TestLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false, Config{})
 
 		testCases = []struct {
 			crit    FilterCriteria
@@ -302,8 +343,8 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false, Config{})
 	)
 
 	// different situations where log filter creation should fail.
@@ -332,8 +373,8 @@ func TestLogFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false, Config{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -451,8 +492,8 @@ func TestPendingLogsSubscription(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false, Config{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/core"
@@ -55,7 +56,7 @@ func BenchmarkFilters(b *testing.B) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr1      = crypto.PubkeyToAddress(key1.PublicKey)
 		addr2      = common.BytesToAddress([]byte("jeff"))
@@ -120,7 +121,7 @@ func TestFilters(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr       = crypto.PubkeyToAddress(key1.PublicKey)
 
@@ -240,3 +241,105 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// Tests that log queries can be paginated through, resuming even from the middle
+// of a block, and that the operator limits are enforced.
+func TestLogsPagination(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		mux     = new(event.TypeMux)
+		backend = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.BytesToAddress([]byte("jeff"))
+	)
+	// Generate a chain with multiple logs in some blocks
+	counts := map[int]int{5: 3, 10: 2, 50: 1}
+
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 100, func(i int, gen *core.BlockGen) {
+		if count, ok := counts[i]; ok {
+			receipt := types.NewReceipt(nil, false, new(big.Int))
+			for j := 0; j < count; j++ {
+				receipt.Logs = append(receipt.Logs, &types.Log{Address: addr, Data: []byte{byte(i), byte(j)}})
+			}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		core.WriteHeadBlockHash(db, block.Hash())
+		core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	api := NewPublicFilterAPI(backend, false, Config{MaxResults: 4, MaxRange: 30})
+	crit := FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}}
+
+	// Ensure full queries exceeding the limits are rejected
+	if _, err := api.GetLogs(context.Background(), crit); err == nil {
+		t.Errorf("range exceeding query succeeded")
+	}
+	if _, err := api.GetLogs(context.Background(), FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(20), Addresses: []common.Address{addr}}); err == nil {
+		t.Errorf("result exceeding query succeeded")
+	}
+	if logs, err := api.GetLogs(context.Background(), FilterCriteria{FromBlock: big.NewInt(7), ToBlock: big.NewInt(20), Addresses: []common.Address{addr}}); err != nil || len(logs) != 2 {
+		t.Errorf("in-limit query mismatch: have %d logs, %v, want %d logs", len(logs), err, 2)
+	}
+	// Walk the entire range in small pages and ensure all logs are returned once
+	var (
+		logs  []*types.Log
+		opts  = &PageOptions{Limit: 2}
+		pages int
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit, opts)
+		if err != nil {
+			t.Fatalf("page %d: failed to retrieve logs: %v", pages, err)
+		}
+		if len(page.Logs) > opts.Limit {
+			t.Errorf("page %d: log count mismatch: have %d, want at most %d", pages, len(page.Logs), opts.Limit)
+		}
+		logs = append(logs, page.Logs...)
+		if pages++; page.Cursor == "" {
+			break
+		}
+		if pages > 100 {
+			t.Fatalf("pagination did not terminate")
+		}
+		opts.Cursor = page.Cursor
+	}
+	var want [][]byte
+	for _, block := range []int{5, 10, 50} {
+		for j := 0; j < counts[block]; j++ {
+			want = append(want, []byte{byte(block), byte(j)})
+		}
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("paginated log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if string(log.Data) != string(want[i]) {
+			t.Errorf("log %d mismatch: have %x, want %x", i, log.Data, want[i])
+		}
+	}
+	if _, err := api.GetLogsPage(context.Background(), crit, &PageOptions{Cursor: "0xdead"}); err != errInvalidCursor {
+		t.Errorf("invalid cursor error mismatch: have %v, want %v", err, errInvalidCursor)
+	}
+	// Ensure ranges beyond the head are capped instead of timing out or paging forever
+	future := NewPublicFilterAPI(backend, false, Config{Timeout: time.Minute})
+	if logs, err := future.GetLogs(context.Background(), FilterCriteria{FromBlock: big.NewInt(40), ToBlock: big.NewInt(1000), Addresses: []common.Address{addr}}); err != nil || len(logs) != 1 {
+		t.Errorf("beyond head query mismatch: have %d logs, %v, want %d logs", len(logs), err, 1)
+	}
+	if page, err := future.GetLogsPage(context.Background(), FilterCriteria{FromBlock: big.NewInt(40), ToBlock: big.NewInt(1000), Addresses: []common.Address{addr}}, nil); err != nil || len(page.Logs) != 1 || page.Cursor != "" {
+		t.Errorf("beyond head page mismatch: have %v, %v", page, err)
+	}
+	// Ensure an expired deadline interrupts the search, leaving it resumable
+	filter := New(backend, 0, 100, []common.Address{addr}, nil)
+	filter.SetDeadline(time.Now().Add(-time.Second))
+
+	if logs, err := filter.Logs(context.Background()); len(logs) != 0 || err != nil || filter.Done() || !filter.TimedOut() {
+		t.Errorf("expired filter mismatch: have %d logs, %v, done %v, timed out %v", len(logs), err, filter.Done(), filter.TimedOut())
+	}
+	if next, skip := filter.Cursor(); next != 0 || skip != 0 {
+		t.Errorf("expired filter cursor mismatch: have %d/%d, want 0/0", next, skip)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+)
+
+// maxFeeHistory is the maximum number of blocks a fee history can be requested for.
+const maxFeeHistory = 1024
+
+var (
+	// errInvalidPercentile is returned if the requested percentiles are out of
+	// range or not in ascending order.
+	errInvalidPercentile = errors.New("invalid reward percentile")
+
+	// errInvalidBlockCount is returned if a fee history is requested for zero blocks.
+	errInvalidBlockCount = errors.New("invalid block count")
+)
+
+// blockFees is the fee summary of a single block in a fee history.
+type blockFees struct {
+	number uint64     // Number of the block summarised
+	ratio  float64    // Ratio of the gas used to the gas limit
+	prices []*big.Int // Gas prices at the requested percentiles of the gas used
+	err    error      // Error encountered while retrieving the block
+}
+
+// txGasAndPrice is a transaction's gas usage along with its gas price.
+type txGasAndPrice struct {
+	gasUsed  uint64
+	gasPrice *big.Int
+}
+
+type txsByGasPrice []txGasAndPrice
+
+func (s txsByGasPrice) Len() int           { return len(s) }
+func (s txsByGasPrice) Less(i, j int) bool { return s[i].gasPrice.Cmp(s[j].gasPrice) < 0 }
+func (s txsByGasPrice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// FeeHistory returns the gas used ratios and the gas prices paid at the given
+// percentiles (weighted by gas used) of a range of blocks ending at lastBlock.
+// The number of the oldest block of the range is also returned, as the range
+// is truncated to the available blocks and the maximum history size.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	if blocks < 1 {
+		return nil, nil, nil, errInvalidBlockCount
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 || (i > 0 && p < percentiles[i-1]) {
+			return nil, nil, nil, fmt.Errorf("%v: #%d: %f", errInvalidPercentile, i, p)
+		}
+	}
+	// Resolve the last block of the range and truncate the range to genesis
+	if lastBlock < 0 {
+		lastBlock = rpc.LatestBlockNumber
+	}
+	header, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if header == nil || err != nil {
+		return nil, nil, nil, err
+	}
+	last := header.Number.Uint64()
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	// Summarise all the blocks concurrently and collect the results
+	results := make(chan *blockFees, blocks)
+	for number := oldest; number <= last; number++ {
+		go func(fees *blockFees) {
+			gpo.processBlock(ctx, fees, percentiles)
+			results <- fees
+		}(&blockFees{number: number})
+	}
+	var (
+		rewards [][]*big.Int
+		ratios  = make([]float64, blocks)
+	)
+	if len(percentiles) > 0 {
+		rewards = make([][]*big.Int, blocks)
+	}
+	for i := 0; i < blocks; i++ {
+		fees := <-results
+		if fees.err != nil {
+			return nil, nil, nil, fees.err
+		}
+		ratios[fees.number-oldest] = fees.ratio
+		if rewards != nil {
+			rewards[fees.number-oldest] = fees.prices
+		}
+	}
+	return new(big.Int).SetUint64(oldest), rewards, ratios, nil
+}
+
+// processBlock retrieves a block and summarises its gas usage and the prices
+// paid at the requested percentiles of the gas used.
+func (gpo *Oracle) processBlock(ctx context.Context, fees *blockFees, percentiles []float64) {
+	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(fees.number))
+	if block == nil {
+		if err == nil {
+			err = fmt.Errorf("block #%d not found", fees.number)
+		}
+		fees.err = err
+		return
+	}
+	if limit := block.GasLimit(); limit.Sign() > 0 {
+		fees.ratio, _ = new(big.Rat).SetFrac(block.GasUsed(), limit).Float64()
+	}
+	if len(percentiles) == 0 {
+		return
+	}
+	fees.prices = make([]*big.Int, len(percentiles))
+	if len(block.Transactions()) == 0 {
+		for i := range fees.prices {
+			fees.prices[i] = new(big.Int)
+		}
+		return
+	}
+	receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		fees.err = err
+		return
+	}
+	if len(receipts) != len(block.Transactions()) {
+		fees.err = fmt.Errorf("receipt count mismatch for block #%d: have %d, want %d", fees.number, len(receipts), len(block.Transactions()))
+		return
+	}
+	// Sort the transactions by price and walk their cumulative gas usage
+	txs := make(txsByGasPrice, len(receipts))
+	for i, tx := range block.Transactions() {
+		txs[i] = txGasAndPrice{gasUsed: receipts[i].GasUsed.Uint64(), gasPrice: tx.GasPrice()}
+	}
+	sort.Sort(txs)
+
+	var (
+		index   int
+		sumUsed = txs[0].gasUsed
+		gasUsed = float64(block.GasUsed().Uint64())
+	)
+	for i, p := range percentiles {
+		threshold := uint64(gasUsed * p / 100)
+		for sumUsed < threshold && index < len(txs)-1 {
+			index++
+			sumUsed += txs[index].gasUsed
+		}
+		fees.prices[i] = new(big.Int).Set(txs[index].gasPrice)
+	}
+}
+
+// SuggestInclusionPrice returns the recommended gas price for a transaction to
+// be included within the given number of blocks. On top of the price suggested
+// based on recent blocks, it considers the executable transactions of the pool:
+// if they don't fit into the given number of blocks, the price needed to outbid
+// the ones left out is suggested instead.
+func (gpo *Oracle) SuggestInclusionPrice(ctx context.Context, blocks int) (*big.Int, error) {
+	if blocks < 1 {
+		return nil, errInvalidBlockCount
+	}
+	price, err := gpo.SuggestPrice(ctx)
+	if err != nil {
+		return price, err
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil || err != nil {
+		return price, err
+	}
+	pending, err := gpo.backend.GetPoolTransactions()
+	if err != nil {
+		return price, err
+	}
+	capacity := new(big.Int).Mul(head.GasLimit, big.NewInt(int64(blocks)))
+	if cutoff := poolCutoffPrice(pending, capacity); cutoff != nil && (price == nil || cutoff.Cmp(price) > 0) {
+		price = cutoff
+	}
+	if price != nil && price.Cmp(maxPrice) > 0 {
+		price = new(big.Int).Set(maxPrice)
+	}
+	return price, nil
+}
+
+// poolCutoffPrice calculates the gas price needed to outbid the pool transactions
+// not fitting into the given gas capacity (assuming the most expensive ones are
+// included first), or nil if all of them fit.
+func poolCutoffPrice(txs types.Transactions, capacity *big.Int) *big.Int {
+	sorted := make(types.Transactions, len(txs))
+	copy(sorted, txs)
+	sort.Sort(types.TxByPrice(sorted)) // most expensive first
+
+	used := new(big.Int)
+	for _, tx := range sorted {
+		if used.Add(used, tx.Gas()); used.Cmp(capacity) > 0 {
+			return new(big.Int).Add(tx.GasPrice(), common.Big1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/internal/ethapi"
+	"github.com/teamnsrg/ethereum-p2p/rpc"
+)
+
+// testBackend is a gas price oracle backend serving a fixed set of blocks and
+// pool transactions, not implementing anything else.
+type testBackend struct {
+	ethapi.Backend
+
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	pending  types.Transactions
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block, _ := b.BlockByNumber(ctx, number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number < 0 {
+		number = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pending, nil
+}
+
+// newTestBackend creates a chain of blocks with the given transaction gas prices
+// and usages, each block having a gas limit of 100000.
+func newTestBackend(blocks [][][2]int64) *testBackend {
+	backend := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	for i, txs := range blocks {
+		var (
+			header = &types.Header{Number: big.NewInt(int64(i)), GasLimit: big.NewInt(100000), GasUsed: new(big.Int)}
+			body   types.Transactions
+			recs   types.Receipts
+		)
+		for j, tx := range txs {
+			body = append(body, types.NewTransaction(uint64(j), common.Address{}, nil, big.NewInt(tx[1]), big.NewInt(tx[0]), nil))
+			recs = append(recs, &types.Receipt{GasUsed: big.NewInt(tx[1])})
+			header.GasUsed.Add(header.GasUsed, big.NewInt(tx[1]))
+		}
+		block := types.NewBlock(header, body, nil, recs)
+		backend.blocks = append(backend.blocks, block)
+		backend.receipts[block.Hash()] = recs
+	}
+	return backend
+}
+
+// Tests that the fee history reports the gas used ratios and the gas weighted
+// price percentiles of the requested blocks.
+func TestFeeHistory(t *testing.T) {
+	backend := newTestBackend([][][2]int64{
+		{},                                    // genesis, empty
+		{{10, 21000}, {30, 21000}},            // half-half
+		{{5, 60000}, {50, 20000}, {7, 20000}}, // full, dominated by the cheap one
+	})
+	oracle := NewOracle(backend, Config{Blocks: 1, Percentile: 50})
+
+	oldest, rewards, ratios, err := oracle.FeeHistory(context.Background(), 10, rpc.LatestBlockNumber, []float64{0, 50, 75, 100})
+	if err != nil {
+		t.Fatalf("failed to retrieve fee history: %v", err)
+	}
+	if oldest.Uint64() != 0 || len(ratios) != 3 || len(rewards) != 3 {
+		t.Fatalf("range mismatch: oldest %v, ratios %d, rewards %d", oldest, len(ratios), len(rewards))
+	}
+	wantRatios := []float64{0, 0.42, 1}
+	wantRewards := [][]int64{{0, 0, 0, 0}, {10, 10, 30, 30}, {5, 5, 7, 50}}
+	for i := range ratios {
+		if ratios[i] != wantRatios[i] {
+			t.Errorf("block %d: gas used ratio mismatch: have %v, want %v", i, ratios[i], wantRatios[i])
+		}
+		for j, reward := range rewards[i] {
+			if reward.Int64() != wantRewards[i][j] {
+				t.Errorf("block %d, percentile %d: reward mismatch: have %v, want %v", i, j, reward, wantRewards[i][j])
+			}
+		}
+	}
+	// Ensure partial ranges and invalid percentiles are handled
+	if oldest, _, ratios, _ := oracle.FeeHistory(context.Background(), 1, 1, nil); oldest.Uint64() != 1 || len(ratios) != 1 {
+		t.Errorf("partial range mismatch: oldest %v, ratios %d", oldest, len(ratios))
+	}
+	if _, _, _, err := oracle.FeeHistory(context.Background(), 1, rpc.LatestBlockNumber, []float64{50, 10}); err == nil {
+		t.Errorf("unordered percentiles accepted")
+	}
+}
+
+// Tests that the inclusion price suggestion outbids the pool transactions not
+// fitting into the requested number of blocks.
+func TestSuggestInclusionPrice(t *testing.T) {
+	backend := newTestBackend([][][2]int64{{}, {{10, 21000}}})
+	oracle := NewOracle(backend, Config{Blocks: 1, Percentile: 50})
+
+	// Fill the pool with two blocks worth of transactions at different prices
+	for i := 0; i < 8; i++ {
+		backend.pending = append(backend.pending, types.NewTransaction(0, common.Address{}, nil, big.NewInt(25000), big.NewInt(int64(100-i)), nil))
+	}
+	tests := []struct {
+		blocks int
+		price  int64
+	}{
+		{1, 97},  // 4 txs fit, outbid the 5th (96)
+		{2, 10},  // all fit, fall back to the recent blocks
+		{10, 10}, // all fit, fall back to the recent blocks
+	}
+	for i, tt := range tests {
+		price, err := oracle.SuggestInclusionPrice(context.Background(), tt.blocks)
+		if err != nil {
+			t.Fatalf("test %d: failed to suggest price: %v", i, err)
+		}
+		if price.Int64() != tt.price {
+			t.Errorf("test %d: price mismatch: have %v, want %v", i, price, tt.price)
+		}
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
 	"github.com/teamnsrg/ethereum-p2p/core"
 	"github.com/teamnsrg/ethereum-p2p/eth/downloader"
+	"github.com/teamnsrg/ethereum-p2p/eth/filters"
 	"github.com/teamnsrg/ethereum-p2p/eth/gasprice"
+	"github.com/teamnsrg/ethereum-p2p/params"
 )
 
 func (c Config) MarshalTOML() (interface{}, error) {
@@ -17,15 +19,19 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		MaxPeers                int  `toml:"-"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		Checkpoint              *params.TrustedCheckpoint `toml:",omitempty"`
+		PropagationTraceSize    int                       `toml:",omitempty"`
+		PropagationTraceJournal string                    `toml:",omitempty"`
+		LightServ               int                       `toml:",omitempty"`
+		LightPeers              int                       `toml:",omitempty"`
+		MaxPeers                int                       `toml:"-"`
+		SkipBcVersionCheck      bool                      `toml:"-"`
+		DatabaseHandles         int                       `toml:"-"`
 		DatabaseCache           int
 		Etherbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
+		MinerBundles            bool           `toml:",omitempty"`
 		GasPrice                *big.Int
 		EthashCacheDir          string
 		EthashCachesInMem       int
@@ -35,6 +41,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		EthashDatasetsOnDisk    int
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
+		Filters                 filters.Config
 		EnablePreimageRecording bool
 		DocRoot                 string `toml:"-"`
 		PowFake                 bool   `toml:"-"`
@@ -45,6 +52,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.Checkpoint = c.Checkpoint
+	enc.PropagationTraceSize = c.PropagationTraceSize
+	enc.PropagationTraceJournal = c.PropagationTraceJournal
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
@@ -53,6 +63,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Etherbase = c.Etherbase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
+	enc.MinerBundles = c.MinerBundles
 	enc.GasPrice = c.GasPrice
 	enc.EthashCacheDir = c.EthashCacheDir
 	enc.EthashCachesInMem = c.EthashCachesInMem
@@ -62,6 +73,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.EthashDatasetsOnDisk = c.EthashDatasetsOnDisk
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
+	enc.Filters = c.Filters
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.DocRoot = c.DocRoot
 	enc.PowFake = c.PowFake
@@ -75,15 +87,19 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		MaxPeers                *int  `toml:"-"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		Checkpoint              *params.TrustedCheckpoint `toml:",omitempty"`
+		PropagationTraceSize    *int                      `toml:",omitempty"`
+		PropagationTraceJournal *string                   `toml:",omitempty"`
+		LightServ               *int                      `toml:",omitempty"`
+		LightPeers              *int                      `toml:",omitempty"`
+		MaxPeers                *int                      `toml:"-"`
+		SkipBcVersionCheck      *bool                     `toml:"-"`
+		DatabaseHandles         *int                      `toml:"-"`
 		DatabaseCache           *int
 		Etherbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes   `toml:",omitempty"`
+		MinerBundles            *bool           `toml:",omitempty"`
 		GasPrice                *big.Int
 		EthashCacheDir          *string
 		EthashCachesInMem       *int
@@ -93,6 +109,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		EthashDatasetsOnDisk    *int
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
+		Filters                 *filters.Config
 		EnablePreimageRecording *bool
 		DocRoot                 *string `toml:"-"`
 		PowFake                 *bool   `toml:"-"`
@@ -112,6 +129,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.Checkpoint != nil {
+		c.Checkpoint = dec.Checkpoint
+	}
+	if dec.PropagationTraceSize != nil {
+		c.PropagationTraceSize = *dec.PropagationTraceSize
+	}
+	if dec.PropagationTraceJournal != nil {
+		c.PropagationTraceJournal = *dec.PropagationTraceJournal
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -136,6 +162,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.ExtraData != nil {
 		c.ExtraData = dec.ExtraData
 	}
+	if dec.MinerBundles != nil {
+		c.MinerBundles = *dec.MinerBundles
+	}
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
@@ -163,6 +192,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
+	if dec.Filters != nil {
+		c.Filters = *dec.Filters
+	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
@@ -26,6 +26,10 @@ var (
 	propTxnInTrafficMeter     = metrics.NewMeter("eth/prop/txns/in/traffic")
 	propTxnOutPacketsMeter    = metrics.NewMeter("eth/prop/txns/out/packets")
 	propTxnOutTrafficMeter    = metrics.NewMeter("eth/prop/txns/out/traffic")
+	propTxHashInPacketsMeter  = metrics.NewMeter("eth/prop/txhashes/in/packets")
+	propTxHashInTrafficMeter  = metrics.NewMeter("eth/prop/txhashes/in/traffic")
+	propTxHashOutPacketsMeter = metrics.NewMeter("eth/prop/txhashes/out/packets")
+	propTxHashOutTrafficMeter = metrics.NewMeter("eth/prop/txhashes/out/traffic")
 	propHashInPacketsMeter    = metrics.NewMeter("eth/prop/hashes/in/packets")
 	propHashInTrafficMeter    = metrics.NewMeter("eth/prop/hashes/in/traffic")
 	propHashOutPacketsMeter   = metrics.NewMeter("eth/prop/hashes/out/packets")
@@ -50,6 +54,10 @@ var (
 	reqReceiptInTrafficMeter  = metrics.NewMeter("eth/req/receipts/in/traffic")
 	reqReceiptOutPacketsMeter = metrics.NewMeter("eth/req/receipts/out/packets")
 	reqReceiptOutTrafficMeter = metrics.NewMeter("eth/req/receipts/out/traffic")
+	reqTxnInPacketsMeter      = metrics.NewMeter("eth/req/txns/in/packets")
+	reqTxnInTrafficMeter      = metrics.NewMeter("eth/req/txns/in/traffic")
+	reqTxnOutPacketsMeter     = metrics.NewMeter("eth/req/txns/out/packets")
+	reqTxnOutTrafficMeter     = metrics.NewMeter("eth/req/txns/out/traffic")
 	miscInPacketsMeter        = metrics.NewMeter("eth/misc/in/packets")
 	miscInTrafficMeter        = metrics.NewMeter("eth/misc/in/traffic")
 	miscOutPacketsMeter       = metrics.NewMeter("eth/misc/out/packets")
@@ -103,6 +111,11 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = propBlockInPacketsMeter, propBlockInTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+
+	case rw.version >= eth163 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashInPacketsMeter, propTxHashInTrafficMeter
+	case rw.version >= eth163 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = reqTxnInPacketsMeter, reqTxnInTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
@@ -130,6 +143,11 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = propBlockOutPacketsMeter, propBlockOutTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+
+	case rw.version >= eth163 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashOutPacketsMeter, propTxHashOutTrafficMeter
+	case rw.version >= eth163 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = reqTxnOutPacketsMeter, reqTxnOutTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
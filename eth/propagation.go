@@ -0,0 +1,317 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/log"
+)
+
+// maxPropagationArrivals is the maximum number of subsequent arrivals recorded
+// for a single transaction or block, the rest only being counted.
+const maxPropagationArrivals = 64
+
+// errPropagationTraceDisabled is returned if propagation records are requested
+// from a node not tracing them.
+var errPropagationTraceDisabled = errors.New("propagation tracing disabled")
+
+// propagationMsgNames are the names of the messages a transaction or a block
+// can be received through, as reported in the propagation records.
+var propagationMsgNames = map[uint64]string{
+	TxMsg:                         "TxMsg",
+	NewBlockHashesMsg:             "NewBlockHashesMsg",
+	NewBlockMsg:                   "NewBlockMsg",
+	NewPooledTransactionHashesMsg: "NewPooledTransactionHashesMsg",
+	PooledTransactionsMsg:         "PooledTransactionsMsg",
+}
+
+// PropagationArrival is a repeated arrival of an already seen transaction or
+// block from a remote peer.
+type PropagationArrival struct {
+	Peer  string        `json:"peer"`  // Identifier of the peer the item arrived from
+	Msg   string        `json:"msg"`   // Message type the item arrived in
+	Delta time.Duration `json:"delta"` // Nanoseconds elapsed since the item was first seen
+}
+
+// PropagationRecord contains the first arrival of a transaction or a block from
+// the network, along with the subsequent arrivals from other peers.
+type PropagationRecord struct {
+	Hash   common.Hash `json:"hash"`             // Hash of the transaction or block
+	Kind   string      `json:"kind"`             // Type of the item ("tx" or "block")
+	Number uint64      `json:"number,omitempty"` // Number of the block (0 for transactions)
+
+	FirstPeer string    `json:"firstPeer"` // Identifier of the peer the item first arrived from
+	FirstMsg  string    `json:"firstMsg"`  // Message type the item first arrived in
+	FirstSeen time.Time `json:"firstSeen"` // Timestamp of the first arrival
+
+	Arrivals []PropagationArrival `json:"arrivals,omitempty"` // Subsequent arrivals, in the order received
+	Omitted  int                  `json:"omitted,omitempty"`  // Number of subsequent arrivals exceeding the cap
+}
+
+// propagationTracer maintains a bounded index of the first arrivals of recently
+// seen transactions and blocks. Records falling out of the index (the oldest
+// first seen ones) are appended to an optional journal file as JSON lines, from
+// which the most recent ones are reloaded into the index on startup.
+//
+// All methods are safe to call on a nil tracer, in which case they are no-ops.
+type propagationTracer struct {
+	records *lru.Cache    // Recently seen items, mapping hashes to propagation records
+	journal *os.File      // Journal file for records evicted from the index (nil if not persisted)
+	writer  *bufio.Writer // Buffered output stream into the journal, flushed on close
+	encoder *json.Encoder // JSON lines encoder writing into the journal
+
+	lock sync.Mutex // Protects the records from concurrent updates
+}
+
+// newPropagationTracer creates a propagation tracer tracking the given number of
+// most recently seen items, persisting evicted records into the journal path if
+// not empty.
+func newPropagationTracer(size int, path string) (*propagationTracer, error) {
+	tracer := new(propagationTracer)
+	records, err := lru.NewWithEvict(size, tracer.evicted)
+	if err != nil {
+		return nil, err
+	}
+	tracer.records = records
+	if path == "" {
+		return tracer, nil
+	}
+	// Reload the most recent records, leaving the older ones in the journal
+	loaded, err := loadPropagationJournal(path, size)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	tracer.journal, tracer.writer = journal, bufio.NewWriter(journal)
+	tracer.encoder = json.NewEncoder(tracer.writer)
+
+	for _, record := range loaded {
+		tracer.records.Add(record.Hash, record)
+	}
+	return tracer, nil
+}
+
+// loadPropagationJournal reads the records of a propagation journal, returning the
+// last limit ones and regenerating the journal with only the preceding records.
+// The returned records are journaled again once they fall out of the index.
+func loadPropagationJournal(path string, limit int) ([]*PropagationRecord, error) {
+	// Skip the parsing if the journal file doesn't exist at all
+	input, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	replacement, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		writer  = bufio.NewWriter(replacement)
+		encoder = json.NewEncoder(writer)
+		decoder = json.NewDecoder(bufio.NewReader(input))
+		records []*PropagationRecord
+		kept    int
+		failure error
+	)
+	for {
+		// Parse the next record, dropping the rest of the journal if corrupted
+		record := new(PropagationRecord)
+		if err := decoder.Decode(record); err != nil {
+			if err != io.EOF {
+				log.Warn("Failed to parse propagation journal", "records", kept+len(records), "err", err)
+			}
+			break
+		}
+		// Keep the most recent records, moving the older ones into the new journal
+		if records = append(records, record); len(records) > limit {
+			if failure = encoder.Encode(records[0]); failure != nil {
+				break
+			}
+			records, kept = records[1:], kept+1
+		}
+	}
+	if failure == nil {
+		failure = writer.Flush()
+	}
+	if err := replacement.Close(); failure == nil {
+		failure = err
+	}
+	if failure != nil {
+		os.Remove(path + ".new")
+		return nil, failure
+	}
+	// Replace the live journal with the newly generated one
+	if err := os.Rename(path+".new", path); err != nil {
+		return nil, err
+	}
+	log.Info("Loaded propagation journal", "records", len(records), "kept", kept)
+	return records, nil
+}
+
+// markTxs records the arrival of a batch of transactions from a remote peer.
+func (t *propagationTracer) markTxs(peer string, code uint64, at time.Time, hashes []common.Hash) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, hash := range hashes {
+		t.mark(hash, "tx", 0, peer, code, at)
+	}
+}
+
+// markBlock records the arrival of a block (or its announcement) from a remote
+// peer.
+func (t *propagationTracer) markBlock(peer string, code uint64, at time.Time, hash common.Hash, number uint64) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.mark(hash, "block", number, peer, code, at)
+}
+
+// mark records the arrival of a single item, creating a new record if it's the
+// first time it's seen. The tracer lock is assumed to be held.
+func (t *propagationTracer) mark(hash common.Hash, kind string, number uint64, peer string, code uint64, at time.Time) {
+	if cached, ok := t.records.Peek(hash); ok {
+		record := cached.(*PropagationRecord)
+		if len(record.Arrivals) >= maxPropagationArrivals {
+			record.Omitted++
+			return
+		}
+		record.Arrivals = append(record.Arrivals, PropagationArrival{
+			Peer:  peer,
+			Msg:   propagationMsgNames[code],
+			Delta: at.Sub(record.FirstSeen),
+		})
+		return
+	}
+	t.records.Add(hash, &PropagationRecord{
+		Hash:      hash,
+		Kind:      kind,
+		Number:    number,
+		FirstPeer: peer,
+		FirstMsg:  propagationMsgNames[code],
+		FirstSeen: at,
+	})
+}
+
+// evicted is the callback invoked when a record falls out of the index, writing
+// it into the journal if persistence was requested. The tracer lock is held by
+// the caller adding the new record.
+func (t *propagationTracer) evicted(key interface{}, value interface{}) {
+	if t.encoder == nil {
+		return
+	}
+	if err := t.encoder.Encode(value); err != nil {
+		log.Warn("Failed to journal propagation record", "hash", key, "err", err)
+	}
+}
+
+// record retrieves a copy of the propagation record of an item, or nil if it's
+// not (or no longer) in the index.
+func (t *propagationTracer) record(hash common.Hash) *PropagationRecord {
+	if t == nil {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	cached, ok := t.records.Peek(hash)
+	if !ok {
+		return nil
+	}
+	return copyPropagationRecord(cached.(*PropagationRecord))
+}
+
+// export writes all the records currently in the index into the given stream as
+// JSON lines, oldest first, returning the number of records written.
+func (t *propagationTracer) export(w io.Writer) (int, error) {
+	if t == nil {
+		return 0, errPropagationTraceDisabled
+	}
+	// Copy the records out to avoid holding the lock while writing
+	t.lock.Lock()
+	keys := t.records.Keys()
+	records := make([]*PropagationRecord, 0, len(keys))
+	for _, key := range keys {
+		if cached, ok := t.records.Peek(key); ok {
+			records = append(records, copyPropagationRecord(cached.(*PropagationRecord)))
+		}
+	}
+	t.lock.Unlock()
+
+	encoder := json.NewEncoder(w)
+	for i, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return i, err
+		}
+	}
+	return len(records), nil
+}
+
+// close flushes all the records still in the index into the journal (if any)
+// and closes it.
+func (t *propagationTracer) close() {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.journal == nil {
+		return
+	}
+	for t.records.Len() > 0 {
+		t.records.RemoveOldest()
+	}
+	if err := t.writer.Flush(); err != nil {
+		log.Warn("Failed to flush propagation journal", "err", err)
+	}
+	if err := t.journal.Close(); err != nil {
+		log.Warn("Failed to close propagation journal", "err", err)
+	}
+	t.journal, t.writer, t.encoder = nil, nil, nil
+}
+
+// copyPropagationRecord creates a deep copy of a propagation record, so it can
+// be handed out while the original is being updated.
+func copyPropagationRecord(record *PropagationRecord) *PropagationRecord {
+	cpy := *record
+	cpy.Arrivals = make([]PropagationArrival, len(record.Arrivals))
+	copy(cpy.Arrivals, record.Arrivals)
+	return &cpy
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/eth/downloader"
+	"github.com/teamnsrg/ethereum-p2p/p2p"
+)
+
+// Tests that the first arrival and the subsequent ones are tracked correctly.
+func TestPropagationTracer(t *testing.T) {
+	tracer, err := newPropagationTracer(16, "")
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	var (
+		start = time.Now()
+		tx    = common.Hash{0x01}
+		block = common.Hash{0x02}
+	)
+	tracer.markTxs("first", NewPooledTransactionHashesMsg, start, []common.Hash{tx})
+	tracer.markTxs("second", TxMsg, start.Add(time.Second), []common.Hash{tx})
+	tracer.markBlock("third", NewBlockHashesMsg, start.Add(2*time.Second), block, 10)
+	tracer.markBlock("first", NewBlockMsg, start.Add(3*time.Second), block, 10)
+
+	record := tracer.record(tx)
+	if record == nil {
+		t.Fatalf("transaction record missing")
+	}
+	if record.Kind != "tx" || record.FirstPeer != "first" || record.FirstMsg != "NewPooledTransactionHashesMsg" || !record.FirstSeen.Equal(start) {
+		t.Errorf("transaction first arrival mismatch: have %s/%s/%s/%v", record.Kind, record.FirstPeer, record.FirstMsg, record.FirstSeen)
+	}
+	want := []PropagationArrival{{Peer: "second", Msg: "TxMsg", Delta: time.Second}}
+	if len(record.Arrivals) != 1 || record.Arrivals[0] != want[0] {
+		t.Errorf("transaction arrivals mismatch: have %v, want %v", record.Arrivals, want)
+	}
+	record = tracer.record(block)
+	if record == nil {
+		t.Fatalf("block record missing")
+	}
+	if record.Kind != "block" || record.Number != 10 || record.FirstPeer != "third" || record.FirstMsg != "NewBlockHashesMsg" {
+		t.Errorf("block first arrival mismatch: have %s/%d/%s/%s", record.Kind, record.Number, record.FirstPeer, record.FirstMsg)
+	}
+	want = []PropagationArrival{{Peer: "first", Msg: "NewBlockMsg", Delta: time.Second}}
+	if len(record.Arrivals) != 1 || record.Arrivals[0] != want[0] {
+		t.Errorf("block arrivals mismatch: have %v, want %v", record.Arrivals, want)
+	}
+	if record := tracer.record(common.Hash{0xff}); record != nil {
+		t.Errorf("unknown item traced: %v", record)
+	}
+	// Ensure the arrivals are capped, but still counted
+	for i := 0; i < maxPropagationArrivals+10; i++ {
+		tracer.markTxs("spammer", TxMsg, start, []common.Hash{tx})
+	}
+	record = tracer.record(tx)
+	if len(record.Arrivals) != maxPropagationArrivals || record.Omitted != 11 {
+		t.Errorf("arrival cap mismatch: have %d recorded, %d omitted; want %d, %d", len(record.Arrivals), record.Omitted, maxPropagationArrivals, 11)
+	}
+}
+
+// Tests that records falling out of the trace are journaled, along with the
+// remaining ones on close, and that the trace can be exported.
+func TestPropagationTracerJournal(t *testing.T) {
+	journal, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal.Close()
+	defer os.Remove(journal.Name())
+
+	tracer, err := newPropagationTracer(2, journal.Name())
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	hashes := []common.Hash{{0x01}, {0x02}, {0x03}}
+	tracer.markTxs("peer", TxMsg, time.Now(), hashes)
+
+	// Check that only the oldest record was evicted and that exports are ordered
+	if tracer.record(hashes[0]) != nil {
+		t.Errorf("oldest record not evicted")
+	}
+	buf := new(bytes.Buffer)
+	if n, err := tracer.export(buf); err != nil || n != 2 {
+		t.Fatalf("export mismatch: have %d/%v, want %d/nil", n, err, 2)
+	}
+	checkPropagationLines(t, buf.Bytes(), hashes[1:])
+
+	// Close the tracer and ensure all records are in the journal
+	tracer.close()
+
+	blob, err := ioutil.ReadFile(journal.Name())
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	checkPropagationLines(t, blob, hashes)
+
+	// Reopen the tracer and ensure the most recent records are reloaded
+	if tracer, err = newPropagationTracer(2, journal.Name()); err != nil {
+		t.Fatalf("failed to reopen tracer: %v", err)
+	}
+	if tracer.record(hashes[0]) != nil {
+		t.Errorf("record beyond the limit reloaded")
+	}
+	for i, hash := range hashes[1:] {
+		if record := tracer.record(hash); record == nil || record.Kind != "tx" || record.FirstPeer != "peer" {
+			t.Errorf("record %d: reload mismatch: have %+v", i+1, record)
+		}
+	}
+	if blob, err = ioutil.ReadFile(journal.Name()); err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	checkPropagationLines(t, blob, hashes[:1])
+
+	// Close the tracer again and ensure no records were duplicated
+	tracer.close()
+
+	if blob, err = ioutil.ReadFile(journal.Name()); err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	checkPropagationLines(t, blob, hashes)
+}
+
+// checkPropagationLines verifies that a JSON lines dump contains the records of
+// the given items, in order.
+func checkPropagationLines(t *testing.T, blob []byte, hashes []common.Hash) {
+	var have []common.Hash
+
+	scanner := bufio.NewScanner(bytes.NewReader(blob))
+	for scanner.Scan() {
+		var record PropagationRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to decode record %d: %v", len(have), err)
+		}
+		have = append(have, record.Hash)
+	}
+	if len(have) != len(hashes) {
+		t.Fatalf("record count mismatch: have %d, want %d", len(have), len(hashes))
+	}
+	for i := range have {
+		if have[i] != hashes[i] {
+			t.Errorf("record %d mismatch: have %x, want %x", i, have[i], hashes[i])
+		}
+	}
+}
+
+// Tests that transactions received from the network are traced.
+func TestPropagationTracing(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	pm.propagation, _ = newPropagationTracer(16, "")
+	p, _ := newTestPeer("peer", 63, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, TxMsg, []interface{}{tx}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if record := pm.propagation.record(tx.Hash()); record != nil {
+			if record.FirstPeer != p.id || record.FirstMsg != "TxMsg" {
+				t.Errorf("first arrival mismatch: have %s/%s, want %s/%s", record.FirstPeer, record.FirstMsg, p.id, "TxMsg")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transaction not traced within 2 seconds")
+		}
+	}
+}
@@ -37,8 +37,9 @@ func init() {
 var testAccount, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 
 // Tests that handshake failures are detected and reported correctly.
-func TestStatusMsgErrors62(t *testing.T) { testStatusMsgErrors(t, 62) }
-func TestStatusMsgErrors63(t *testing.T) { testStatusMsgErrors(t, 63) }
+func TestStatusMsgErrors62(t *testing.T)  { testStatusMsgErrors(t, 62) }
+func TestStatusMsgErrors63(t *testing.T)  { testStatusMsgErrors(t, 63) }
+func TestStatusMsgErrors163(t *testing.T) { testStatusMsgErrors(t, 163) }
 
 func testStatusMsgErrors(t *testing.T, protocol int) {
 	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
@@ -89,8 +90,9 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 }
 
 // This test checks that received transactions are added to the local pool.
-func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
-func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
+func TestRecvTransactions62(t *testing.T)  { testRecvTransactions(t, 62) }
+func TestRecvTransactions63(t *testing.T)  { testRecvTransactions(t, 63) }
+func TestRecvTransactions163(t *testing.T) { testRecvTransactions(t, 163) }
 
 func testRecvTransactions(t *testing.T, protocol int) {
 	txAdded := make(chan []*types.Transaction)
@@ -117,8 +119,9 @@ func testRecvTransactions(t *testing.T, protocol int) {
 }
 
 // This test checks that pending transactions are sent.
-func TestSendTransactions62(t *testing.T) { testSendTransactions(t, 62) }
-func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
+func TestSendTransactions62(t *testing.T)  { testSendTransactions(t, 62) }
+func TestSendTransactions63(t *testing.T)  { testSendTransactions(t, 63) }
+func TestSendTransactions163(t *testing.T) { testSendTransactions(t, 163) }
 
 func testSendTransactions(t *testing.T, protocol int) {
 	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
@@ -142,18 +145,27 @@ func testSendTransactions(t *testing.T, protocol int) {
 			seen[tx.Hash()] = false
 		}
 		for n := 0; n < len(alltxs) && !t.Failed(); {
-			var txs []*types.Transaction
+			var hashes []common.Hash
 			msg, err := p.app.ReadMsg()
 			if err != nil {
 				t.Errorf("%v: read error: %v", p.Peer, err)
-			} else if msg.Code != TxMsg {
+			} else if protocol < eth163 && msg.Code != TxMsg {
 				t.Errorf("%v: got code %d, want TxMsg", p.Peer, msg.Code)
+			} else if protocol >= eth163 && msg.Code != NewPooledTransactionHashesMsg {
+				t.Errorf("%v: got code %d, want NewPooledTransactionHashesMsg", p.Peer, msg.Code)
 			}
-			if err := msg.Decode(&txs); err != nil {
+			if protocol < eth163 {
+				var txs []*types.Transaction
+				if err := msg.Decode(&txs); err != nil {
+					t.Errorf("%v: %v", p.Peer, err)
+				}
+				for _, tx := range txs {
+					hashes = append(hashes, tx.Hash())
+				}
+			} else if err := msg.Decode(&hashes); err != nil {
 				t.Errorf("%v: %v", p.Peer, err)
 			}
-			for _, tx := range txs {
-				hash := tx.Hash()
+			for _, hash := range hashes {
 				seentx, want := seen[hash]
 				if seentx {
 					t.Errorf("%v: got tx more than once: %x", p.Peer, hash)
@@ -174,6 +186,111 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// Tests that pooled transactions can be retrieved by hash, skipping unknown ones.
+func TestGetPooledTransactions163(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	txs := []*types.Transaction{newTestTransaction(testAccount, 0, 0), newTestTransaction(testAccount, 1, 0)}
+	pm.txpool.AddRemotes(txs)
+
+	p, _ := newTestPeer("peer", eth163, pm, true)
+	defer p.close()
+
+	// Drain the initial transaction announcement
+	if msg, err := p.app.ReadMsg(); err != nil {
+		t.Fatalf("failed to read announcement: %v", err)
+	} else if msg.Code != NewPooledTransactionHashesMsg {
+		t.Fatalf("announcement code mismatch: have %d, want %d", msg.Code, NewPooledTransactionHashesMsg)
+	} else {
+		msg.Discard()
+	}
+	// Request the known transactions along with an unknown one
+	hashes := []common.Hash{txs[1].Hash(), common.Hash{0x01}, txs[0].Hash()}
+	if err := p2p.Send(p.app, GetPooledTransactionsMsg, hashes); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, PooledTransactionsMsg, []*types.Transaction{txs[1], txs[0]}); err != nil {
+		t.Errorf("pooled transactions mismatch: %v", err)
+	}
+}
+
+// Tests that announced transactions are requested from the announcing peer and
+// injected into the local pool upon delivery.
+func TestTransactionAnnouncement163(t *testing.T) {
+	txAdded := make(chan []*types.Transaction, 1)
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, txAdded)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	p, _ := newTestPeer("peer", eth163, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, NewPooledTransactionHashesMsg, []common.Hash{tx.Hash()}); err != nil {
+		t.Fatalf("failed to send announcement: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, GetPooledTransactionsMsg, []common.Hash{tx.Hash()}); err != nil {
+		t.Fatalf("transaction request mismatch: %v", err)
+	}
+	if err := p2p.Send(p.app, PooledTransactionsMsg, []*types.Transaction{tx}); err != nil {
+		t.Fatalf("failed to send transactions: %v", err)
+	}
+	select {
+	case added := <-txAdded:
+		if len(added) != 1 || added[0].Hash() != tx.Hash() {
+			t.Errorf("added transactions mismatch: have %v, want [%x]", added, tx.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("announced transaction not added within 2 seconds")
+	}
+}
+
+// Tests that transactions are propagated in full to only a square root of the
+// peers, the rest only receiving hash announcements.
+func TestBroadcastTransactionSubset163(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	peers := make([]*testPeer, 9)
+	for i := range peers {
+		peers[i], _ = newTestPeer(fmt.Sprintf("peer #%d", i), eth163, pm, true)
+		defer peers[i].close()
+	}
+	for pm.peers.Len() < len(peers) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tx := newTestTransaction(testAccount, 0, 0)
+	go pm.BroadcastTx(tx.Hash(), tx)
+
+	codes := make(chan uint64, len(peers))
+	for _, p := range peers {
+		go func(p *testPeer) {
+			msg, err := p.app.ReadMsg()
+			if err != nil {
+				t.Errorf("%v: read error: %v", p.Peer, err)
+				codes <- 0
+				return
+			}
+			msg.Discard()
+			codes <- msg.Code
+		}(p)
+	}
+	var full, announced int
+	for range peers {
+		switch code := <-codes; code {
+		case TxMsg:
+			full++
+		case NewPooledTransactionHashesMsg:
+			announced++
+		default:
+			t.Errorf("unexpected message code %d", code)
+		}
+	}
+	if full != 3 || announced != 6 {
+		t.Errorf("propagation mismatch: have %d full and %d announced, want 3 and 6", full, announced)
+	}
+}
+
 // Tests that the custom union field encoder and decoder works correctly.
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
@@ -31,6 +31,8 @@ import (
 const (
 	forceSyncCycle      = 10 * time.Second // Time interval to force syncs, even if few peers are available
 	minDesiredPeerCount = 5                // Amount of peers desired to start syncing
+	backfillCycle       = time.Second      // Time interval to retrieve blocks skipped by checkpoint sync
+	backfillBatches     = 8                // Number of block batches to backfill per cycle, leaving room for syncs
 
 	// This is the target size for the packs of transactions sent by txsyncLoop.
 	// A pack can get larger than this if a single transactions exceeds this size.
@@ -78,17 +80,30 @@ func (pm *ProtocolManager) txsyncLoop() {
 		pack.txs = pack.txs[:0]
 		for i := 0; i < len(s.txs) && size < txsyncPackSize; i++ {
 			pack.txs = append(pack.txs, s.txs[i])
-			size += s.txs[i].Size()
+			if s.p.version >= eth163 {
+				size += common.HashLength
+			} else {
+				size += s.txs[i].Size()
+			}
 		}
 		// Remove the transactions that will be sent.
 		s.txs = s.txs[:copy(s.txs, s.txs[len(pack.txs):])]
 		if len(s.txs) == 0 {
 			delete(pending, s.p.ID())
 		}
-		// Send the pack in the background.
-		s.p.Log().Trace("Sending batch of transactions", "count", len(pack.txs), "bytes", size)
+		// Send the pack in the background, only announcing it if the peer can fetch on demand.
 		sending = true
-		go func() { done <- pack.p.SendTransactions(pack.txs) }()
+		if s.p.version >= eth163 {
+			hashes := make([]common.Hash, len(pack.txs))
+			for i, tx := range pack.txs {
+				hashes[i] = tx.Hash()
+			}
+			s.p.Log().Trace("Announcing batch of transactions", "count", len(hashes), "bytes", size)
+			go func() { done <- pack.p.SendTransactionHashes(hashes) }()
+		} else {
+			s.p.Log().Trace("Sending batch of transactions", "count", len(pack.txs), "bytes", size)
+			go func() { done <- pack.p.SendTransactions(pack.txs) }()
+		}
 	}
 
 	// pick chooses the next pending sync.
@@ -135,6 +150,8 @@ func (pm *ProtocolManager) syncer() {
 	// Start and ensure cleanup of sync mechanisms
 	pm.fetcher.Start()
 	defer pm.fetcher.Stop()
+	pm.txFetcher.Start()
+	defer pm.txFetcher.Stop()
 	defer pm.downloader.Terminate()
 
 	// Wait for different events to fire synchronisation operations
@@ -160,6 +177,32 @@ func (pm *ProtocolManager) syncer() {
 	}
 }
 
+// backfiller periodically retrieves the bodies and receipts of the blocks skipped
+// by checkpoint sync between the checkpoint and the pivot, a few batches at a time
+// to not starve the synchronisations of the downloader.
+func (pm *ProtocolManager) backfiller() {
+	backfill := time.NewTicker(backfillCycle)
+	defer backfill.Stop()
+
+	for {
+		select {
+		case <-backfill.C:
+			peer := pm.peers.BestPeer()
+			if peer == nil {
+				break
+			}
+			for i := 0; i < backfillBatches; i++ {
+				if err := pm.downloader.Backfill(peer.id); err != nil {
+					break
+				}
+			}
+
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
 // synchronise tries to sync up our local block chain with a remote peer.
 func (pm *ProtocolManager) synchronise(peer *peer) {
 	// Short circuit if no peers are available
@@ -178,7 +221,7 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	mode := downloader.FullSync
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		// Fast sync was explicitly requested, and explicitly granted
-		mode = downloader.FastSync
+		mode = pm.fastSyncMode
 	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
@@ -0,0 +1,121 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+func init() {
+	Register("callTracer", func() Tracer { return new(callTracer) })
+}
+
+// errNoCallFrame is returned if a trace result is requested before any call
+// frame was entered.
+var errNoCallFrame = errors.New("no call frame captured")
+
+// callFrame is a single call, create or selfdestruct of a transaction, along
+// with the frames nested in it.
+type callFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*callFrame   `json:"calls,omitempty"`
+}
+
+// callTracer is a native tracer assembling the call tree of a transaction.
+type callTracer struct {
+	interrupter
+
+	root  *callFrame   // Outermost call frame of the transaction
+	stack []*callFrame // Call frames currently being executed
+}
+
+// CaptureEnter implements vm.Tracer, opening a new call frame.
+func (t *callTracer) CaptureEnter(env *vm.EVM, typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	t.check(env)
+
+	frame := &callFrame{
+		Type:  typ.String(),
+		From:  from,
+		To:    to,
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	if len(t.stack) == 0 {
+		t.root = frame
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	t.stack = append(t.stack, frame)
+	return nil
+}
+
+// CaptureState implements vm.Tracer, only checking for interruptions.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.check(env)
+	return nil
+}
+
+// CaptureExit implements vm.Tracer, closing the current call frame.
+func (t *callTracer) CaptureExit(env *vm.EVM, output []byte, gasUsed uint64, err error) error {
+	if len(t.stack) == 0 {
+		return nil
+	}
+	frame := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	frame.GasUsed = hexutil.Uint64(gasUsed)
+	frame.Output = common.CopyBytes(output)
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult implements Tracer, returning the call tree of the transaction.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	if t.root == nil {
+		return nil, errNoCallFrame
+	}
+	return json.Marshal(t.root)
+}
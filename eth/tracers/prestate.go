@@ -0,0 +1,140 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+func init() {
+	Register("prestateTracer", func() Tracer { return newPrestateTracer() })
+}
+
+// prestateAccount is the state of an account before a transaction was executed,
+// limited to the storage slots accessed by it.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateTracer is a native tracer collecting the state of all the accounts
+// and storage slots touched by a transaction, as it was before its execution.
+type prestateTracer struct {
+	interrupter
+
+	prestate map[common.Address]*prestateAccount
+	entered  bool // Whether the outermost call frame was already entered
+}
+
+func newPrestateTracer() *prestateTracer {
+	return &prestateTracer{prestate: make(map[common.Address]*prestateAccount)}
+}
+
+// CaptureEnter implements vm.Tracer, recording the accounts of the call frame.
+//
+// The outermost frame is entered after the transaction was charged for its gas
+// and its nonce incremented, so these are reverted in the sender's prestate.
+func (t *prestateTracer) CaptureEnter(env *vm.EVM, typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	t.check(env)
+
+	if !t.entered {
+		t.entered = true
+
+		t.lookupAccount(env, from)
+		t.lookupAccount(env, to)
+		t.lookupAccount(env, env.Coinbase)
+
+		homestead := env.ChainConfig().IsHomestead(env.BlockNumber)
+		gasLimit := new(big.Int).Add(new(big.Int).SetUint64(gas), core.IntrinsicGas(input, typ == vm.CREATE, homestead))
+
+		sender := t.prestate[from]
+		sender.Balance = (*hexutil.Big)(new(big.Int).Add(sender.Balance.ToInt(), new(big.Int).Mul(gasLimit, env.GasPrice)))
+		sender.Nonce--
+		return nil
+	}
+	t.lookupAccount(env, to)
+	return nil
+}
+
+// CaptureState implements vm.Tracer, recording the accounts and storage slots
+// accessed by the instruction about to be executed.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.check(env)
+
+	if len(stack.Data()) == 0 {
+		return nil
+	}
+	switch op {
+	case vm.SLOAD, vm.SSTORE:
+		t.lookupStorage(env, contract.Address(), common.BigToHash(stack.Back(0)))
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY:
+		t.lookupAccount(env, common.BigToAddress(stack.Back(0)))
+	}
+	return nil
+}
+
+// CaptureExit implements vm.Tracer.
+func (t *prestateTracer) CaptureExit(env *vm.EVM, output []byte, gasUsed uint64, err error) error {
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult implements Tracer, returning the collected prestate.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(t.prestate)
+}
+
+// lookupAccount records the current state of an account, unless already done.
+func (t *prestateTracer) lookupAccount(env *vm.EVM, addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(env.StateDB.GetBalance(addr))),
+		Nonce:   env.StateDB.GetNonce(addr),
+		Code:    common.CopyBytes(env.StateDB.GetCode(addr)),
+	}
+}
+
+// lookupStorage records the current value of a storage slot, unless already done.
+func (t *prestateTracer) lookupStorage(env *vm.EVM, addr common.Address, key common.Hash) {
+	t.lookupAccount(env, addr)
+
+	account := t.prestate[addr]
+	if account.Storage == nil {
+		account.Storage = make(map[common.Hash]common.Hash)
+	}
+	if _, ok := account.Storage[key]; !ok {
+		account.Storage[key] = env.StateDB.GetState(addr, key)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+func init() {
+	Register("profileTracer", func() Tracer { return NewProfileTracer() })
+}
+
+// PCProfile is the execution profile of a single instruction of a contract code.
+type PCProfile struct {
+	Op   string `json:"op"`   // Name of the opcode at the program counter
+	Hits uint64 `json:"hits"` // Number of times the instruction was executed
+	Gas  uint64 `json:"gas"`  // Gas consumed by the instruction itself
+}
+
+// CodeProfile is the execution profile of a contract code, aggregated across all
+// the addresses it was executed at.
+type CodeProfile struct {
+	Addresses []common.Address      `json:"addresses"` // Addresses the code was executed at
+	Gas       uint64                `json:"gas"`       // Total gas consumed by the code itself
+	PCs       map[uint64]*PCProfile `json:"pcs"`       // Instructions executed, by program counter
+}
+
+// OpProfile is the execution profile of an opcode across all contracts.
+type OpProfile struct {
+	Hits uint64 `json:"hits"` // Number of times the opcode was executed
+	Gas  uint64 `json:"gas"`  // Gas consumed by the opcode in total
+}
+
+// Profile is the coverage and gas profile of an execution. The gas consumed by
+// the calls and creates only includes their own costs, not the gas used by the
+// nested call frames, which is accounted to the code executed within them.
+type Profile struct {
+	Contracts map[common.Hash]*CodeProfile `json:"contracts"` // Code profiles, by code hash
+	Opcodes   map[string]*OpProfile        `json:"opcodes"`   // Opcode totals, by name
+}
+
+// profileFrame is a call frame being profiled.
+type profileFrame struct {
+	call *PCProfile // Call instruction waiting for its nested frame to be entered
+	cost uint64     // Gas cost of the pending call, including the gas forwarded
+	gas  uint64     // Gas available before the pending call
+}
+
+// ProfileTracer is a native tracer aggregating the instructions executed and the
+// gas consumed by them, per contract code and per program counter.
+type ProfileTracer struct {
+	interrupter
+
+	contracts map[common.Hash]*CodeProfile
+	frames    []*profileFrame
+	suicide   bool // Whether a selfdestruct pseudo-frame was entered
+}
+
+// NewProfileTracer creates a new execution profiling tracer.
+func NewProfileTracer() *ProfileTracer {
+	return &ProfileTracer{
+		contracts: make(map[common.Hash]*CodeProfile),
+		frames:    []*profileFrame{new(profileFrame)},
+	}
+}
+
+// isCall returns whether the opcode's gas cost includes the gas forwarded to the
+// nested call frame.
+func isCall(op vm.OpCode) bool {
+	return op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL
+}
+
+// CaptureEnter implements vm.Tracer, settling the cost of the pending call of
+// the current frame and opening a new one.
+func (t *ProfileTracer) CaptureEnter(env *vm.EVM, typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	t.check(env)
+
+	if typ == vm.SELFDESTRUCT {
+		t.suicide = true
+		return nil
+	}
+	if frame := t.frames[len(t.frames)-1]; frame.call != nil && isCall(typ) {
+		if frame.cost > gas {
+			frame.call.Gas += frame.cost - gas
+		}
+		frame.call = nil
+	}
+	t.frames = append(t.frames, new(profileFrame))
+	return nil
+}
+
+// CaptureState implements vm.Tracer, accounting the executed instruction.
+func (t *ProfileTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.check(env)
+
+	// A call not entering a nested frame only consumes the gas not returned
+	frame := t.frames[len(t.frames)-1]
+	if frame.call != nil {
+		if frame.gas > gas {
+			frame.call.Gas += frame.gas - gas
+		}
+		frame.call = nil
+	}
+	code := t.contracts[contract.CodeHash]
+	if code == nil {
+		code = &CodeProfile{PCs: make(map[uint64]*PCProfile)}
+		t.contracts[contract.CodeHash] = code
+	}
+	if addr := contract.Address(); !containsAddress(code.Addresses, addr) {
+		code.Addresses = append(code.Addresses, addr)
+	}
+	prof := code.PCs[pc]
+	if prof == nil {
+		prof = &PCProfile{Op: op.String()}
+		code.PCs[pc] = prof
+	}
+	prof.Hits++
+
+	if isCall(op) && err == nil {
+		frame.call, frame.cost, frame.gas = prof, cost, gas
+	} else {
+		prof.Gas += cost
+	}
+	return nil
+}
+
+// CaptureExit implements vm.Tracer, closing the current call frame.
+func (t *ProfileTracer) CaptureExit(env *vm.EVM, output []byte, gasUsed uint64, err error) error {
+	if t.suicide {
+		t.suicide = false
+		return nil
+	}
+	if len(t.frames) < 2 {
+		return nil
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	// Code ending right after a call without any further instruction
+	if frame.call != nil {
+		frame.call.Gas += frame.cost
+	}
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer.
+func (t *ProfileTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// Profile returns the execution profile collected so far, including the opcode
+// totals aggregated from the contract profiles.
+func (t *ProfileTracer) Profile() *Profile {
+	profile := &Profile{
+		Contracts: t.contracts,
+		Opcodes:   make(map[string]*OpProfile),
+	}
+	for _, code := range t.contracts {
+		code.Gas = 0
+		for _, prof := range code.PCs {
+			code.Gas += prof.Gas
+
+			op := profile.Opcodes[prof.Op]
+			if op == nil {
+				op = new(OpProfile)
+				profile.Opcodes[prof.Op] = op
+			}
+			op.Hits += prof.Hits
+			op.Gas += prof.Gas
+		}
+	}
+	return profile
+}
+
+// GetResult implements Tracer, returning the execution profile.
+func (t *ProfileTracer) GetResult() (json.RawMessage, error) {
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(t.Profile())
+}
+
+// containsAddress returns whether the address is in the list.
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+// SourceRange is a byte range of a source file, as referenced by source maps.
+type SourceRange struct {
+	Start  int `json:"start"`  // Byte offset of the range in the source file
+	Length int `json:"length"` // Length of the range in bytes
+	File   int `json:"file"`   // Index of the source file (-1 if compiler generated)
+}
+
+// SourceMap is a decompressed Solidity source map, holding the source range of
+// each instruction of a contract code, indexed by instruction (not by offset).
+type SourceMap []SourceRange
+
+// ParseSourceMap decompresses a Solidity source map in the "s:l:f:j;..." format,
+// where empty fields are inherited from the previous entry.
+func ParseSourceMap(srcmap string) (SourceMap, error) {
+	srcmap = strings.TrimSpace(srcmap)
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		entries = strings.Split(srcmap, ";")
+		result  = make(SourceMap, len(entries))
+		last    SourceRange
+	)
+	for i, entry := range entries {
+		fields := strings.Split(entry, ":")
+		for j, dst := range []*int{&last.Start, &last.Length, &last.File} {
+			if j >= len(fields) || fields[j] == "" {
+				continue
+			}
+			n, err := strconv.Atoi(fields[j])
+			if err != nil {
+				return nil, fmt.Errorf("invalid source map entry #%d %q: %v", i, entry, err)
+			}
+			*dst = n
+		}
+		result[i] = last
+	}
+	return result, nil
+}
+
+// SourceProfile is the execution profile of a source range, aggregated across all
+// the instructions mapped to it.
+type SourceProfile struct {
+	SourceRange
+	Hits uint64 `json:"hits"` // Number of times instructions of the range were executed
+	Gas  uint64 `json:"gas"`  // Gas consumed by the instructions of the range
+}
+
+// MapSource aggregates the profile of a contract code onto the source ranges of
+// the given source map, ordered by file and offset. The code is needed to resolve
+// program counters into instruction indexes.
+func (p *CodeProfile) MapSource(code []byte, srcmap SourceMap) []*SourceProfile {
+	ranges := make(map[SourceRange]*SourceProfile)
+	for pc, index := uint64(0), 0; pc < uint64(len(code)); index++ {
+		op := vm.OpCode(code[pc])
+		if prof := p.PCs[pc]; prof != nil && index < len(srcmap) {
+			src := ranges[srcmap[index]]
+			if src == nil {
+				src = &SourceProfile{SourceRange: srcmap[index]}
+				ranges[srcmap[index]] = src
+			}
+			src.Hits += prof.Hits
+			src.Gas += prof.Gas
+		}
+		pc++
+		if op >= vm.PUSH1 && op <= vm.PUSH32 {
+			pc += uint64(op - vm.PUSH1 + 1)
+		}
+	}
+	result := make([]*SourceProfile, 0, len(ranges))
+	for _, src := range ranges {
+		result = append(result, src)
+	}
+	sort.Sort(sourceProfiles(result))
+	return result
+}
+
+type sourceProfiles []*SourceProfile
+
+func (s sourceProfiles) Len() int      { return len(s) }
+func (s sourceProfiles) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sourceProfiles) Less(i, j int) bool {
+	if s[i].File != s[j].File {
+		return s[i].File < s[j].File
+	}
+	if s[i].Start != s[j].Start {
+		return s[i].Start < s[j].Start
+	}
+	return s[i].Length < s[j].Length
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracers is a collection of native EVM tracers, selectable by name in
+// the transaction tracing APIs as a fast alternative to JavaScript tracers.
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+// Tracer is a native EVM tracer, assembling a JSON result while the transaction
+// is executed.
+type Tracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON encoded result of the trace, or the error that
+	// caused it to be aborted.
+	GetResult() (json.RawMessage, error)
+
+	// Stop aborts the traced execution at the next step with the given reason.
+	// It is safe to be called concurrently with the execution.
+	Stop(err error)
+}
+
+// tracers is the registry of the native tracer constructors, indexed by name.
+var tracers = make(map[string]func() Tracer)
+
+// Register makes a native tracer available by the given name. It is meant to be
+// called from package initializers and panics if the name is already taken.
+func Register(name string, ctor func() Tracer) {
+	if _, ok := tracers[name]; ok {
+		panic(fmt.Sprintf("native tracer %q registered twice", name))
+	}
+	tracers[name] = ctor
+}
+
+// New creates a new instance of the native tracer registered with the given name,
+// returning false if there is no such tracer.
+func New(name string) (Tracer, bool) {
+	ctor, ok := tracers[name]
+	if !ok {
+		return nil, false
+	}
+	return ctor(), true
+}
+
+// Names returns the names of all the registered native tracers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(tracers))
+	for name := range tracers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// interrupter implements the stopping of native tracers, cancelling the traced
+// EVM on its next captured event.
+type interrupter struct {
+	stopped uint32 // Atomic flag whether the tracer was stopped
+	reason  error  // Reason of the stop, set before the flag
+}
+
+// Stop implements Tracer, flagging the tracer as stopped.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.stopped, 1)
+}
+
+// check cancels the given EVM if the tracer was stopped.
+func (i *interrupter) check(env *vm.EVM) {
+	if atomic.LoadUint32(&i.stopped) == 1 {
+		env.Cancel()
+	}
+}
+
+// err returns the reason the tracer was stopped with, if any.
+func (i *interrupter) err() error {
+	if atomic.LoadUint32(&i.stopped) == 1 {
+		return i.reason
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+var (
+	testSender   = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testCaller   = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testCallee   = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	testCoinbase = common.HexToAddress("0x4000000000000000000000000000000000000004")
+)
+
+// traceTestTx executes a transaction from testSender to testCaller, which calls
+// into testCallee (loading its slot 1) and then sets its own slot 0 to 1.
+func traceTestTx(t *testing.T, tracer Tracer) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	statedb.SetBalance(testSender, big.NewInt(1000000000))
+	statedb.SetNonce(testSender, 5)
+
+	// CALL(gas, callee, 0, 0, 0, 0, 0); SSTORE(0, 1)
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x73}
+	code = append(code, testCallee.Bytes()...)
+	code = append(code, 0x5a, 0xf1, 0x50, 0x60, 0x01, 0x60, 0x00, 0x55, 0x00)
+	statedb.SetCode(testCaller, code)
+	statedb.SetState(testCaller, common.Hash{}, common.BytesToHash([]byte{5}))
+
+	// SLOAD(1)
+	statedb.SetCode(testCallee, []byte{0x60, 0x01, 0x54, 0x50, 0x00})
+	statedb.SetState(testCallee, common.BytesToHash([]byte{1}), common.BytesToHash([]byte{7}))
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      testSender,
+		GasPrice:    big.NewInt(2),
+		Coinbase:    testCoinbase,
+		GasLimit:    big.NewInt(1000000),
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+	}
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg := types.NewMessage(testSender, &testCaller, 5, big.NewInt(3), big.NewInt(100000), big.NewInt(2), nil, true)
+
+	if _, _, failed, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(big.NewInt(1000000))); err != nil || failed {
+		t.Fatalf("failed to execute transaction: failed %v, err %v", failed, err)
+	}
+}
+
+// Tests that the call tracer assembles the call tree of a transaction.
+func TestCallTracer(t *testing.T) {
+	tracer, ok := New("callTracer")
+	if !ok {
+		t.Fatalf("call tracer not registered")
+	}
+	traceTestTx(t, tracer)
+
+	blob, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace: %v", err)
+	}
+	var root callFrame
+	if err := json.Unmarshal(blob, &root); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	if root.Type != "CALL" || root.From != testSender || root.To != testCaller || root.Value.ToInt().Int64() != 3 {
+		t.Errorf("root frame mismatch: %+v", root)
+	}
+	if root.GasUsed == 0 || uint64(root.GasUsed) > uint64(root.Gas) || root.Error != "" {
+		t.Errorf("root frame gas mismatch: gas %d, used %d, error %q", root.Gas, root.GasUsed, root.Error)
+	}
+	if len(root.Calls) != 1 {
+		t.Fatalf("nested call count mismatch: have %d, want %d", len(root.Calls), 1)
+	}
+	if call := root.Calls[0]; call.Type != "CALL" || call.From != testCaller || call.To != testCallee || len(call.Calls) != 0 {
+		t.Errorf("nested frame mismatch: %+v", call)
+	}
+}
+
+// Tests that the prestate tracer collects the touched accounts and storage slots
+// as they were before the transaction.
+func TestPrestateTracer(t *testing.T) {
+	tracer, ok := New("prestateTracer")
+	if !ok {
+		t.Fatalf("prestate tracer not registered")
+	}
+	traceTestTx(t, tracer)
+
+	blob, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace: %v", err)
+	}
+	var prestate map[common.Address]*prestateAccount
+	if err := json.Unmarshal(blob, &prestate); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	if len(prestate) != 4 {
+		t.Errorf("account count mismatch: have %d, want %d", len(prestate), 4)
+	}
+	if sender := prestate[testSender]; sender == nil || sender.Balance.ToInt().Int64() != 1000000000 || sender.Nonce != 5 {
+		t.Errorf("sender prestate mismatch: %+v", sender)
+	}
+	if caller := prestate[testCaller]; caller == nil || len(caller.Code) == 0 || caller.Storage[common.Hash{}] != common.BytesToHash([]byte{5}) {
+		t.Errorf("caller prestate mismatch: %+v", caller)
+	}
+	if callee := prestate[testCallee]; callee == nil || len(callee.Storage) != 1 || callee.Storage[common.BytesToHash([]byte{1})] != common.BytesToHash([]byte{7}) {
+		t.Errorf("callee prestate mismatch: %+v", callee)
+	}
+	if coinbase := prestate[testCoinbase]; coinbase == nil || coinbase.Balance.ToInt().Sign() != 0 {
+		t.Errorf("coinbase prestate mismatch: %+v", coinbase)
+	}
+}
+
+// Tests that stopped native tracers abort the execution and report the reason.
+func TestTracerStop(t *testing.T) {
+	for _, name := range Names() {
+		tracer, _ := New(name)
+
+		reason := errors.New("stopped")
+		tracer.Stop(reason)
+		traceTestTx(t, tracer)
+
+		if _, err := tracer.GetResult(); err != reason {
+			t.Errorf("%s: stop error mismatch: have %v, want %v", name, err, reason)
+		}
+	}
+}
+
+// Tests that the profile tracer aggregates the instructions executed and the gas
+// consumed by them, excluding the gas used by nested calls from the callers.
+func TestProfileTracer(t *testing.T) {
+	tracer, ok := New("profileTracer")
+	if !ok {
+		t.Fatalf("profile tracer not registered")
+	}
+	traceTestTx(t, tracer)
+
+	profile := tracer.(*ProfileTracer).Profile()
+	if len(profile.Contracts) != 2 {
+		t.Fatalf("contract count mismatch: have %d, want %d", len(profile.Contracts), 2)
+	}
+	var caller, callee *CodeProfile
+	for _, code := range profile.Contracts {
+		switch {
+		case containsAddress(code.Addresses, testCaller):
+			caller = code
+		case containsAddress(code.Addresses, testCallee):
+			callee = code
+		}
+	}
+	if caller == nil || callee == nil {
+		t.Fatalf("missing contract profiles: caller %v, callee %v", caller, callee)
+	}
+	if caller.Gas != 5728 || callee.Gas != 205 {
+		t.Errorf("contract gas mismatch: caller %d, callee %d, want 5728, 205", caller.Gas, callee.Gas)
+	}
+	if call := caller.PCs[32]; call == nil || call.Op != "CALL" || call.Hits != 1 || call.Gas != 700 {
+		t.Errorf("call profile mismatch: %+v", call)
+	}
+	if push := profile.Opcodes["PUSH1"]; push == nil || push.Hits != 8 || push.Gas != 24 {
+		t.Errorf("PUSH1 totals mismatch: %+v", push)
+	}
+	// Map the callee profile onto a source map
+	srcmap, err := ParseSourceMap("10:5:0;;20:3:0;10:5")
+	if err != nil {
+		t.Fatalf("failed to parse source map: %v", err)
+	}
+	sources := callee.MapSource([]byte{0x60, 0x01, 0x54, 0x50, 0x00}, srcmap)
+	if len(sources) != 2 {
+		t.Fatalf("source range count mismatch: have %d, want %d", len(sources), 2)
+	}
+	if src := sources[0]; src.Start != 10 || src.Length != 5 || src.File != 0 || src.Hits != 3 || src.Gas != 203 {
+		t.Errorf("first source range mismatch: %+v", src)
+	}
+	if src := sources[1]; src.Start != 20 || src.Length != 3 || src.Hits != 1 || src.Gas != 2 {
+		t.Errorf("second source range mismatch: %+v", src)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/teamnsrg/ethereum-p2p/common/math"
 	"github.com/teamnsrg/ethereum-p2p/consensus/ethash"
 	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
 	"github.com/teamnsrg/ethereum-p2p/core/types"
 	"github.com/teamnsrg/ethereum-p2p/core/vm"
 	"github.com/teamnsrg/ethereum-p2p/crypto"
@@ -64,6 +65,45 @@ func (s *PublicEthereumAPI) GasPrice(ctx context.Context) (*big.Int, error) {
 	return s.b.SuggestPrice(ctx)
 }
 
+// InclusionGasPrice returns a suggestion for a gas price to get a transaction
+// included within the given number of blocks, taking into account both recent
+// blocks and the transactions currently waiting in the pool.
+func (s *PublicEthereumAPI) InclusionGasPrice(ctx context.Context, blocks hexutil.Uint) (*hexutil.Big, error) {
+	price, err := s.b.SuggestInclusionPrice(ctx, int(blocks))
+	return (*hexutil.Big)(price), err
+}
+
+// FeeHistoryResult is the gas usage and price distribution of a range of blocks.
+type FeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the gas used ratios of a range of blocks ending at lastBlock,
+// along with the gas prices paid at the requested percentiles of the gas used
+// within each block. The range is truncated to the available blocks.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	oldest, rewards, ratios, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: ratios,
+	}
+	if rewards != nil {
+		result.Reward = make([][]*hexutil.Big, len(rewards))
+		for i, prices := range rewards {
+			result.Reward[i] = make([]*hexutil.Big, len(prices))
+			for j, price := range prices {
+				result.Reward[i][j] = (*hexutil.Big)(price)
+			}
+		}
+	}
+	return result, nil
+}
+
 // ProtocolVersion returns the current Ethereum protocol version this node supports
 func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
@@ -130,13 +170,18 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
+// Status returns the number of pending and queued transaction in the pool, along
+// with the policy governing its admission and eviction rules (if any).
+func (s *PublicTxPoolAPI) Status() map[string]interface{} {
 	pending, queue := s.b.Stats()
-	return map[string]hexutil.Uint{
+	status := map[string]interface{}{
 		"pending": hexutil.Uint(pending),
 		"queued":  hexutil.Uint(queue),
 	}
+	if policy := s.b.TxPoolPolicy(); policy != "" {
+		status["policy"] = policy
+	}
+	return status
 }
 
 // Inspect retrieves the content of the transaction pool and flattens it into an
@@ -174,6 +219,115 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// ContentFrom returns the transactions contained within the transaction pool
+// originating from a single account.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	content := make(map[string]map[string]*RPCTransaction, 2)
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	// Build the pending transactions
+	dump := make(map[string]*RPCTransaction, len(pending))
+	for _, tx := range pending {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["pending"] = dump
+
+	// Build the queued transactions
+	dump = make(map[string]*RPCTransaction, len(queue))
+	for _, tx := range queue {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["queued"] = dump
+
+	return content
+}
+
+// RPCTxPoolStatus is the fate of a transaction as known to the local node.
+type RPCTxPoolStatus struct {
+	Hash        common.Hash     `json:"hash"`
+	Status      string          `json:"status"`
+	Reason      string          `json:"reason,omitempty"`
+	ReplacedBy  *common.Hash    `json:"replacedBy,omitempty"`
+	Time        *time.Time      `json:"time,omitempty"`
+	Transaction *RPCTransaction `json:"transaction,omitempty"`
+}
+
+// GetTx returns the status of a transaction: whether it's currently pending or
+// queued in the pool, mined into the canonical chain, or was dropped from (or
+// replaced in) the pool, along with the reason. Nil is returned for transactions
+// unknown to the node.
+func (s *PublicTxPoolAPI) GetTx(hash common.Hash) *RPCTxPoolStatus {
+	// Try to return an already finalized transaction
+	if tx, blockHash, blockNumber, index := core.GetTransaction(s.b.ChainDb(), hash); tx != nil {
+		return &RPCTxPoolStatus{
+			Hash:        hash,
+			Status:      "mined",
+			Transaction: newRPCTransaction(tx, blockHash, blockNumber, index),
+		}
+	}
+	// No finalized transaction, try to retrieve it from the pool
+	status, event := s.b.TxPoolStatus(hash)
+	switch status {
+	case core.TxStatusPending, core.TxStatusQueued:
+		result := &RPCTxPoolStatus{Hash: hash, Status: core.TxEventPending}
+		if status == core.TxStatusQueued {
+			result.Status = core.TxEventQueued
+		}
+		if tx := s.b.GetPoolTransaction(hash); tx != nil {
+			result.Transaction = newRPCPendingTransaction(tx)
+		}
+		return result
+	}
+	// Not in the pool any more, report why if known
+	if event == nil {
+		return nil
+	}
+	return newRPCTxPoolStatus(*event)
+}
+
+// Events creates a subscription that is triggered each time a transaction
+// changes its status within the transaction pool: entering the queue, becoming
+// pending, being replaced, being mined or being dropped.
+func (s *PublicTxPoolAPI) Events(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.TxPoolEvent, 128)
+		eventsSub := s.b.SubscribeTxPoolEvent(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-events:
+				notifier.Notify(rpcSub.ID, newRPCTxPoolStatus(event))
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// newRPCTxPoolStatus converts a transaction pool event into its RPC form.
+func newRPCTxPoolStatus(event core.TxPoolEvent) *RPCTxPoolStatus {
+	status := &RPCTxPoolStatus{
+		Hash:   event.Hash,
+		Status: event.Status,
+		Reason: event.Reason,
+		Time:   &event.Time,
+	}
+	if event.Status == core.TxEventReplaced {
+		status.ReplacedBy = &event.Replacement
+	}
+	return status
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -580,17 +734,76 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config) ([]byte, *big.Int, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+// OverrideAccount specifies the state of an account to be overridden for the
+// duration of a call. The storage can be either replaced entirely (State), or
+// patched slot by slot (StateDiff), but not both.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   *hexutil.Big                 `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the collection of accounts overridden for a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply writes the overridden account fields into the given state.
+func (diff *StateOverride) Apply(statedb *state.StateDB) error {
+	if diff == nil {
+		return nil
+	}
+	for addr, account := range *diff {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		if account.State != nil {
+			statedb.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
 
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, vmCfg vm.Config) ([]byte, *big.Int, bool, error) {
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
 		return nil, common.Big0, false, err
 	}
+	return DoCall(ctx, s.b, args, state, header, overrides, vmCfg)
+}
+
+// DoCall executes a call message on top of the given state and header, without
+// persisting any of its state changes. Unset fields of the call are defaulted.
+// If state overrides are given, they are applied to a copy of the state before
+// executing the call.
+func DoCall(ctx context.Context, b Backend, args CallArgs, statedb *state.StateDB, header *types.Header, overrides *StateOverride, vmCfg vm.Config) ([]byte, *big.Int, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	if overrides != nil {
+		statedb = statedb.Copy()
+		if err := overrides.Apply(statedb); err != nil {
+			return nil, common.Big0, false, err
+		}
+	}
+
 	// Set sender address or use a default if none specified
 	addr := args.From
 	if addr == (common.Address{}) {
-		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+		if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
 			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
 				addr = accounts[0].Address
 			}
@@ -621,7 +834,7 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	defer func() { cancel() }()
 
 	// Get a new instance of the EVM.
-	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
+	evm, vmError, err := b.GetEVM(ctx, msg, statedb, header, vmCfg)
 	if err != nil {
 		return nil, common.Big0, false, err
 	}
@@ -644,14 +857,18 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{DisableGasMetering: true})
+//
+// Optionally, the state of some accounts can be overridden for the duration of
+// the call.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, _, err := s.doCall(ctx, args, blockNr, overrides, vm.Config{DisableGasMetering: true})
 	return (hexutil.Bytes)(result), err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*hexutil.Big, error) {
+// given transaction against the current pending block, optionally with the
+// state of some accounts overridden.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, overrides *StateOverride) (*hexutil.Big, error) {
 	// Determine the lowest and highest possible gas limits to binary search in between
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -673,7 +890,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 	// Create a helper to check if a gas allowance results in an executable transaction
 	executable := func(gas uint64) bool {
 		(*big.Int)(&args.Gas).SetUint64(gas)
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{})
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, overrides, vm.Config{})
 		if err != nil || failed {
 			return false
 		}
@@ -758,10 +975,10 @@ func FormatLogs(logs []vm.StructLog) []StructLogRes {
 	return formatted
 }
 
-// rpcOutputBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
+// RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
-func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
+func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
 	head := b.Header() // copies the header once
 	fields := map[string]interface{}{
 		"number":           (*hexutil.Big)(head.Number),
@@ -774,7 +991,6 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		"stateRoot":        head.Root,
 		"miner":            head.Coinbase,
 		"difficulty":       (*hexutil.Big)(head.Difficulty),
-		"totalDifficulty":  (*hexutil.Big)(s.b.GetTd(b.Hash())),
 		"extraData":        hexutil.Bytes(head.Extra),
 		"size":             hexutil.Uint64(uint64(b.Size().Int64())),
 		"gasLimit":         (*hexutil.Big)(head.GasLimit),
@@ -816,6 +1032,17 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 	return fields, nil
 }
 
+// rpcOutputBlock uses the generalized output filler, then adds the total difficulty field, which requires
+// a `PublicBlockchainAPI`.
+func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
+	fields, err := RPCMarshalBlock(b, inclTx, fullTx)
+	if err != nil {
+		return nil, err
+	}
+	fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
+	return fields, err
+}
+
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
 	BlockHash        common.Hash     `json:"blockHash"`
@@ -0,0 +1,81 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+)
+
+// Tests that state overrides are decoded and applied to the state, replacing or
+// patching the storage as requested.
+func TestStateOverride(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	var (
+		replaced = common.HexToAddress("0x01")
+		patched  = common.HexToAddress("0x02")
+		slot1    = common.HexToHash("0x01")
+		slot2    = common.HexToHash("0x02")
+	)
+	for _, addr := range []common.Address{replaced, patched} {
+		statedb.SetState(addr, slot1, common.HexToHash("0xaa"))
+		statedb.SetState(addr, slot2, common.HexToHash("0xbb"))
+	}
+	blob := `{
+		"0x0000000000000000000000000000000000000001": {
+			"balance": "0x10", "nonce": "0x5", "code": "0x6000",
+			"state": {"0x0000000000000000000000000000000000000000000000000000000000000002": "0x00000000000000000000000000000000000000000000000000000000000000cc"}
+		},
+		"0x0000000000000000000000000000000000000002": {
+			"stateDiff": {"0x0000000000000000000000000000000000000000000000000000000000000002": "0x00000000000000000000000000000000000000000000000000000000000000cc"}
+		}
+	}`
+	var overrides StateOverride
+	if err := json.Unmarshal([]byte(blob), &overrides); err != nil {
+		t.Fatalf("failed to decode overrides: %v", err)
+	}
+	if err := overrides.Apply(statedb); err != nil {
+		t.Fatalf("failed to apply overrides: %v", err)
+	}
+	if balance, nonce, code := statedb.GetBalance(replaced), statedb.GetNonce(replaced), statedb.GetCode(replaced); balance.Cmp(big.NewInt(16)) != 0 || nonce != 5 || len(code) != 2 {
+		t.Errorf("account override mismatch: balance %v, nonce %d, code %x", balance, nonce, code)
+	}
+	if value := statedb.GetState(replaced, slot1); value != (common.Hash{}) {
+		t.Errorf("replaced storage retained slot: %x", value)
+	}
+	if value := statedb.GetState(patched, slot1); value != common.HexToHash("0xaa") {
+		t.Errorf("patched storage lost slot: %x", value)
+	}
+	for _, addr := range []common.Address{replaced, patched} {
+		if value := statedb.GetState(addr, slot2); value != common.HexToHash("0xcc") {
+			t.Errorf("%x: overridden slot mismatch: have %x, want %x", addr, value, common.HexToHash("0xcc"))
+		}
+	}
+	// Ensure conflicting storage overrides are rejected
+	storage := map[common.Hash]common.Hash{}
+	conflict := StateOverride{replaced: OverrideAccount{State: &storage, StateDiff: &storage}}
+	if err := conflict.Apply(statedb); err == nil {
+		t.Errorf("conflicting storage overrides accepted")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/consensus/misc"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+var (
+	// ErrUnknownParent is returned if a block is requested to be built on top of
+	// a parent not present in the local chain.
+	ErrUnknownParent = errors.New("unknown parent block")
+
+	// ErrInvalidTimestamp is returned if a block is requested to be built with a
+	// timestamp not newer than its parent's.
+	ErrInvalidTimestamp = errors.New("timestamp older than parent")
+
+	// errTxReverted is reported for transactions that were successfully executed,
+	// but later discarded by the selector (e.g. failing bundle).
+	errTxReverted = errors.New("reverted by transaction selector")
+)
+
+// BuildArgs are the parameters of a block to assemble. Unset (zero) fields are
+// filled in the same way the miner would when creating a new mining work.
+type BuildArgs struct {
+	Parent   common.Hash    // Block to build on top of (zero = current head)
+	Coinbase common.Address // Address to credit the rewards to (zero = etherbase)
+	Extra    []byte         // Extra-data of the block (nil = configured extra)
+	Time     uint64         // Timestamp of the block (0 = now, or parent + 1)
+	GasLimit uint64         // Gas limit of the block (0 = next from parent)
+}
+
+// TxResult is the outcome of a transaction the selector attempted to include.
+type TxResult struct {
+	Hash     common.Hash    // Hash of the attempted transaction
+	From     common.Address // Sender of the attempted transaction
+	Included bool           // Whether the transaction made it into the block
+	GasUsed  *big.Int       // Gas used by the transaction if included
+	Err      error          // Reason the transaction was rejected, if any
+}
+
+// BuildResult is a block assembled and executed, but not sealed.
+type BuildResult struct {
+	Block    *types.Block   // Finalized, but unsealed block
+	Receipts types.Receipts // Receipts of the included transactions
+	GasUsed  *big.Int       // Total gas used by the block
+	Results  []*TxResult    // Outcomes of all the attempted transactions, in order
+}
+
+// recordingEnvironment is a transaction environment recording all the commit
+// attempts of the selector filling it.
+type recordingEnvironment struct {
+	*workEnvironment
+	results []*TxResult
+}
+
+// Commit implements TxEnvironment, recording the outcome of the execution.
+func (env *recordingEnvironment) Commit(tx *types.Transaction) error {
+	from, _ := types.Sender(env.Signer(), tx)
+
+	err := env.workEnvironment.Commit(tx)
+	env.results = append(env.results, &TxResult{Hash: tx.Hash(), From: from, Err: err})
+	return err
+}
+
+// buildBlock assembles and executes a new block on top of the requested parent
+// with the current transaction selector and pool contents. The block is neither
+// sealed nor pushed to the agents, and the current mining work is untouched.
+//
+// Note, stateful selectors may still update their internal bookkeeping (e.g.
+// drop stale bundles), the same way as if the block was built for mining.
+func (self *worker) buildBlock(args BuildArgs) (*BuildResult, error) {
+	// Gather the current miner configuration
+	self.mu.Lock()
+	coinbase, extra, selector := self.coinbase, self.extra, self.selector
+	self.mu.Unlock()
+
+	if args.Coinbase != (common.Address{}) {
+		coinbase = args.Coinbase
+	}
+	if args.Extra != nil {
+		if uint64(len(args.Extra)) > params.MaximumExtraDataSize {
+			return nil, fmt.Errorf("extra-data too long: %d > %d", len(args.Extra), params.MaximumExtraDataSize)
+		}
+		extra = args.Extra
+	}
+	// Resolve the parent and assemble the header on top
+	parent := self.chain.CurrentBlock()
+	if args.Parent != (common.Hash{}) {
+		if parent = self.chain.GetBlockByHash(args.Parent); parent == nil {
+			return nil, ErrUnknownParent
+		}
+	}
+	tstamp := args.Time
+	if tstamp == 0 {
+		tstamp = uint64(time.Now().Unix())
+		if parent.Time().Cmp(new(big.Int).SetUint64(tstamp)) >= 0 {
+			tstamp = parent.Time().Uint64() + 1
+		}
+	}
+	if parent.Time().Cmp(new(big.Int).SetUint64(tstamp)) >= 0 {
+		return nil, ErrInvalidTimestamp
+	}
+	gasLimit := core.CalcGasLimit(parent)
+	if args.GasLimit != 0 {
+		gasLimit = new(big.Int).SetUint64(args.GasLimit)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   gasLimit,
+		GasUsed:    new(big.Int),
+		Extra:      extra,
+		Time:       new(big.Int).SetUint64(tstamp),
+		Coinbase:   coinbase,
+	}
+	if err := self.engine.Prepare(self.chain, header); err != nil {
+		return nil, fmt.Errorf("failed to prepare header: %v", err)
+	}
+	self.overrideDAOExtra(header)
+
+	// Create a standalone work and fill it with transactions
+	work, err := self.makeWork(parent, header)
+	if err != nil {
+		return nil, err
+	}
+	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
+		misc.ApplyDAOHardFork(work.state)
+	}
+	pending, err := self.eth.TxPool().Pending()
+	if err != nil {
+		return nil, err
+	}
+	env := &recordingEnvironment{workEnvironment: newWorkEnvironment(work, self.chain, coinbase)}
+	selector.Select(env, pending)
+
+	self.uncleMu.Lock()
+	uncles, _ := self.commitUncles(work)
+	self.uncleMu.Unlock()
+
+	block, err := self.engine.Finalize(self.chain, header, work.state, work.txs, uncles, work.receipts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize block: %v", err)
+	}
+	// Cross reference the attempted transactions with the ones included
+	included := make(map[common.Hash]*types.Receipt, len(work.txs))
+	for i, tx := range work.txs {
+		included[tx.Hash()] = work.receipts[i]
+	}
+	for _, result := range env.results {
+		if result.Err != nil {
+			continue
+		}
+		if receipt, ok := included[result.Hash]; ok {
+			result.Included, result.GasUsed = true, receipt.GasUsed
+		} else {
+			result.Err = errTxReverted
+		}
+	}
+	return &BuildResult{
+		Block:    block,
+		Receipts: work.receipts,
+		GasUsed:  new(big.Int).Set(header.GasUsed),
+		Results:  env.results,
+	}, nil
+}
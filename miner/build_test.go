@@ -0,0 +1,112 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/consensus/ethash"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/event"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+// Tests that blocks can be built on demand without sealing or importing them,
+// reporting the outcome of every transaction attempted.
+func TestBuildBlock(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	addrs := make([]common.Address, len(keys))
+	alloc := make(core.GenesisAlloc)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = (&core.Genesis{Config: params.TestChainConfig, Timestamp: 100, Alloc: alloc}).MustCommit(db)
+		engine  = ethash.NewFaker()
+	)
+	chain, err := core.NewBlockChain(db, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	pool := core.NewTxPool(config, params.TestChainConfig, chain)
+	defer pool.Stop()
+
+	worker := newWorker(params.TestChainConfig, engine, common.Address{}, &testBackend{db, chain, pool}, new(event.TypeMux))
+
+	// Add two transactions, out of which only the more expensive fits
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	cheap, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[0])
+	pricy, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(2), nil), signer, keys[1])
+
+	for _, err := range pool.AddRemotes([]*types.Transaction{cheap, pricy}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	coinbase := common.HexToAddress("0xc0ffee")
+	result, err := worker.buildBlock(BuildArgs{Coinbase: coinbase, Extra: []byte("dry"), Time: genesis.Time().Uint64() + 10, GasLimit: 30000})
+	if err != nil {
+		t.Fatalf("failed to build block: %v", err)
+	}
+	block := result.Block
+	if block.NumberU64() != 1 || block.ParentHash() != genesis.Hash() {
+		t.Errorf("block position mismatch: have #%d [%x], want #1 [%x]", block.NumberU64(), block.ParentHash(), genesis.Hash())
+	}
+	if block.Coinbase() != coinbase || string(block.Extra()) != "dry" || block.Time().Uint64() != genesis.Time().Uint64()+10 || block.GasLimit().Uint64() != 30000 {
+		t.Errorf("block header mismatch: coinbase %x, extra %q, time %v, gas limit %v", block.Coinbase(), block.Extra(), block.Time(), block.GasLimit())
+	}
+	if len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != pricy.Hash() {
+		t.Errorf("included transactions mismatch: have %d, want 1 [%x]", len(block.Transactions()), pricy.Hash())
+	}
+	if len(result.Receipts) != 1 || result.GasUsed.Uint64() != 21000 {
+		t.Errorf("execution mismatch: receipts %d, gas used %v", len(result.Receipts), result.GasUsed)
+	}
+	if len(result.Results) != 2 {
+		t.Fatalf("transaction result count mismatch: have %d, want %d", len(result.Results), 2)
+	}
+	if res := result.Results[0]; res.Hash != pricy.Hash() || res.From != addrs[1] || !res.Included || res.GasUsed.Uint64() != 21000 || res.Err != nil {
+		t.Errorf("included transaction result mismatch: %+v", res)
+	}
+	if res := result.Results[1]; res.Hash != cheap.Hash() || res.Included || res.Err != core.ErrGasLimitReached {
+		t.Errorf("rejected transaction result mismatch: %+v", res)
+	}
+	// Ensure nothing was imported and invalid requests are rejected
+	if head := chain.CurrentBlock(); head.Hash() != genesis.Hash() {
+		t.Errorf("chain head changed: have %x, want %x", head.Hash(), genesis.Hash())
+	}
+	if _, err := worker.buildBlock(BuildArgs{Parent: common.HexToHash("0xdeadbeef")}); err != ErrUnknownParent {
+		t.Errorf("unknown parent error mismatch: have %v, want %v", err, ErrUnknownParent)
+	}
+	if _, err := worker.buildBlock(BuildArgs{Time: genesis.Time().Uint64()}); err != ErrInvalidTimestamp {
+		t.Errorf("stale timestamp error mismatch: have %v, want %v", err, ErrInvalidTimestamp)
+	}
+}
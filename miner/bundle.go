@@ -0,0 +1,136 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/log"
+)
+
+// maxBundles is the maximum number of transaction bundles waiting for inclusion.
+const maxBundles = 256
+
+var (
+	// ErrEmptyBundle is returned if a bundle without transactions is submitted.
+	ErrEmptyBundle = errors.New("empty transaction bundle")
+
+	// ErrBundlesFull is returned if a bundle is submitted while the maximum
+	// number of bundles are already waiting for inclusion.
+	ErrBundlesFull = errors.New("too many pending bundles")
+)
+
+// Bundle is a list of transactions which must be included into a block all
+// together and in the given order, or not at all.
+type Bundle struct {
+	Txs         types.Transactions // Transactions to include, in order
+	BlockNumber uint64             // Number of the block to include the bundle in (0 = any)
+}
+
+// BundleSelector is a transaction selector which includes externally submitted
+// bundles at the top of each block (in submission order), filling the rest of
+// the block using a fallback selector.
+//
+// Bundles are retained until they are included into the chain (detected by their
+// first transaction's nonce becoming stale) or their target block passes.
+type BundleSelector struct {
+	fallback TransactionSelector // Selector to fill the block with after the bundles
+	bundles  []*Bundle           // Bundles waiting for inclusion, in submission order
+	lock     sync.Mutex          // Protects the bundle list
+}
+
+// NewBundleSelector creates a bundle accepting transaction selector on top of a
+// fallback one filling up the remainder of the blocks.
+func NewBundleSelector(fallback TransactionSelector) *BundleSelector {
+	return &BundleSelector{
+		fallback: fallback,
+	}
+}
+
+// Submit schedules a bundle of transactions for atomic inclusion.
+func (s *BundleSelector) Submit(bundle *Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return ErrEmptyBundle
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.bundles) >= maxBundles {
+		return ErrBundlesFull
+	}
+	s.bundles = append(s.bundles, bundle)
+	return nil
+}
+
+// Pending returns the number of bundles waiting for inclusion.
+func (s *BundleSelector) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.bundles)
+}
+
+// Select implements TransactionSelector, including all the applicable bundles
+// atomically before deferring to the fallback selector.
+func (s *BundleSelector) Select(env TxEnvironment, pending map[common.Address]types.Transactions) {
+	number := env.Header().Number.Uint64()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keep := s.bundles[:0]
+	for _, bundle := range s.bundles {
+		// Drop bundles which missed their target block, skip future ones
+		if bundle.BlockNumber != 0 && bundle.BlockNumber < number {
+			log.Debug("Dropping expired transaction bundle", "target", bundle.BlockNumber, "number", number)
+			continue
+		}
+		if bundle.BlockNumber > number {
+			keep = append(keep, bundle)
+			continue
+		}
+		// Try to include the entire bundle, reverting everything on failure
+		snap := env.Snapshot()
+
+		var err error
+		for _, tx := range bundle.Txs {
+			if err = env.Commit(tx); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			env.RevertToSnapshot(snap)
+			if err == core.ErrNonceTooLow {
+				log.Debug("Dropping stale transaction bundle", "txs", len(bundle.Txs))
+				continue
+			}
+			log.Debug("Transaction bundle failed, postponing", "txs", len(bundle.Txs), "err", err)
+		}
+		keep = append(keep, bundle)
+	}
+	// Release the references of the dropped bundles
+	for i := len(keep); i < len(s.bundles); i++ {
+		s.bundles[i] = nil
+	}
+	s.bundles = keep
+
+	s.fallback.Select(env, pending)
+}
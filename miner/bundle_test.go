@@ -0,0 +1,169 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+)
+
+// testEnvironment is a mock transaction environment only checking nonces and
+// the block gas limit.
+type testEnvironment struct {
+	header *types.Header
+	nonces map[common.Address]uint64
+	txs    types.Transactions
+	gas    uint64
+
+	snaps []testSnapshot
+}
+
+type testSnapshot struct {
+	nonces map[common.Address]uint64
+	txs    int
+	gas    uint64
+}
+
+func newTestEnvironment(number uint64, nonces map[common.Address]uint64, gas uint64) *testEnvironment {
+	return &testEnvironment{
+		header: &types.Header{Number: new(big.Int).SetUint64(number)},
+		nonces: nonces,
+		gas:    gas,
+	}
+}
+
+func (env *testEnvironment) Header() *types.Header { return env.header }
+func (env *testEnvironment) State() *state.StateDB { return nil }
+func (env *testEnvironment) Signer() types.Signer  { return types.HomesteadSigner{} }
+
+func (env *testEnvironment) Commit(tx *types.Transaction) error {
+	from, _ := types.Sender(env.Signer(), tx)
+	switch nonce := env.nonces[from]; {
+	case tx.Nonce() < nonce:
+		return core.ErrNonceTooLow
+	case tx.Nonce() > nonce:
+		return core.ErrNonceTooHigh
+	case tx.Gas().Uint64() > env.gas:
+		return core.ErrGasLimitReached
+	}
+	env.nonces[from]++
+	env.gas -= tx.Gas().Uint64()
+	env.txs = append(env.txs, tx)
+	return nil
+}
+
+func (env *testEnvironment) Snapshot() int {
+	nonces := make(map[common.Address]uint64, len(env.nonces))
+	for addr, nonce := range env.nonces {
+		nonces[addr] = nonce
+	}
+	env.snaps = append(env.snaps, testSnapshot{nonces, len(env.txs), env.gas})
+	return len(env.snaps) - 1
+}
+
+func (env *testEnvironment) RevertToSnapshot(id int) {
+	snap := env.snaps[id]
+	env.snaps = env.snaps[:id]
+
+	env.nonces, env.txs, env.gas = snap.nonces, env.txs[:snap.txs], snap.gas
+}
+
+func signedTx(nonce uint64, gas int64, price int64, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), big.NewInt(gas), big.NewInt(price), nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that bundles are included atomically and in order ahead of the pool
+// transactions, and that failing ones are reverted entirely.
+func TestBundleSelection(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addrs := make([]common.Address, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	selector := NewBundleSelector(PriceSelector{})
+
+	// A valid bundle paying less than the pool, and one with a nonce gap
+	valid := &Bundle{Txs: types.Transactions{signedTx(0, 21000, 1, keys[0]), signedTx(0, 21000, 1, keys[1])}}
+	broken := &Bundle{Txs: types.Transactions{signedTx(1, 21000, 1, keys[0]), signedTx(5, 21000, 1, keys[1])}}
+
+	for _, bundle := range []*Bundle{valid, broken} {
+		if err := selector.Submit(bundle); err != nil {
+			t.Fatalf("failed to submit bundle: %v", err)
+		}
+	}
+	if err := selector.Submit(&Bundle{}); err != ErrEmptyBundle {
+		t.Fatalf("empty bundle error mismatch: have %v, want %v", err, ErrEmptyBundle)
+	}
+	pooled := signedTx(0, 21000, 10, keys[2])
+
+	env := newTestEnvironment(1, make(map[common.Address]uint64), 1000000)
+	selector.Select(env, map[common.Address]types.Transactions{addrs[2]: {pooled}})
+
+	want := types.Transactions{valid.Txs[0], valid.Txs[1], pooled}
+	if len(env.txs) != len(want) {
+		t.Fatalf("included transaction count mismatch: have %d, want %d", len(env.txs), len(want))
+	}
+	for i, tx := range env.txs {
+		if tx.Hash() != want[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, tx.Hash(), want[i].Hash())
+		}
+	}
+	if env.nonces[addrs[0]] != 1 || env.nonces[addrs[1]] != 1 {
+		t.Errorf("broken bundle not reverted: nonces %d, %d", env.nonces[addrs[0]], env.nonces[addrs[1]])
+	}
+	if pending := selector.Pending(); pending != 2 {
+		t.Errorf("pending bundle count mismatch: have %d, want %d", pending, 2)
+	}
+}
+
+// Tests that bundles already included into the chain or missing their target
+// block are dropped, while bundles targeting future blocks are retained.
+func TestBundleExpiration(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	selector := NewBundleSelector(PriceSelector{})
+	selector.Submit(&Bundle{Txs: types.Transactions{signedTx(0, 21000, 1, key)}})                  // stale
+	selector.Submit(&Bundle{Txs: types.Transactions{signedTx(1, 21000, 1, key)}, BlockNumber: 9})  // expired
+	selector.Submit(&Bundle{Txs: types.Transactions{signedTx(1, 21000, 1, key)}, BlockNumber: 11}) // future
+
+	env := newTestEnvironment(10, map[common.Address]uint64{addr: 1}, 1000000)
+	selector.Select(env, nil)
+
+	if len(env.txs) != 0 {
+		t.Errorf("included transaction count mismatch: have %d, want %d", len(env.txs), 0)
+	}
+	if pending := selector.Pending(); pending != 1 {
+		t.Fatalf("pending bundle count mismatch: have %d, want %d", pending, 1)
+	}
+	// Move on to the targeted block and ensure the bundle is included
+	env = newTestEnvironment(11, map[common.Address]uint64{addr: 1}, 1000000)
+	selector.Select(env, nil)
+
+	if len(env.txs) != 1 {
+		t.Errorf("included transaction count mismatch: have %d, want %d", len(env.txs), 1)
+	}
+}
@@ -163,6 +163,18 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetTransactionSelector replaces the selector deciding on the transactions to
+// include into the blocks being mined, effective from the next block onwards.
+func (self *Miner) SetTransactionSelector(selector TransactionSelector) {
+	self.worker.setSelector(selector)
+}
+
+// TransactionSelector returns the selector deciding on the transactions to include
+// into the blocks being mined.
+func (self *Miner) TransactionSelector() TransactionSelector {
+	return self.worker.getSelector()
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
@@ -181,3 +193,10 @@ func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
 }
+
+// BuildBlock assembles and executes a block with the given parameters using the
+// current transaction selector and pool contents, without sealing it. It can be
+// used to simulate what the miner would produce.
+func (self *Miner) BuildBlock(args BuildArgs) (*BuildResult, error) {
+	return self.worker.buildBlock(args)
+}
@@ -0,0 +1,232 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/event"
+	"github.com/teamnsrg/ethereum-p2p/log"
+)
+
+// errReplayProtected is returned if a replay protected transaction is attempted
+// to be included into a block before the EIP155 fork.
+var errReplayProtected = errors.New("replay protected transaction before EIP155")
+
+// TxEnvironment is the block being assembled by the miner, as seen by the
+// transaction selectors filling it.
+type TxEnvironment interface {
+	// Header returns the header of the block being assembled. It must not be
+	// modified by the caller.
+	Header() *types.Header
+
+	// State returns the pending state, containing the effects of all the already
+	// included transactions. It must not be modified by the caller, nor retained
+	// across commits and reverts.
+	State() *state.StateDB
+
+	// Signer returns the signer to derive the transaction senders with.
+	Signer() types.Signer
+
+	// Commit executes a transaction on top of the pending state and includes it
+	// into the block. On failure, the environment is left untouched.
+	Commit(tx *types.Transaction) error
+
+	// Snapshot returns an identifier for the current content of the block, which
+	// can be used to discard all transactions committed since.
+	Snapshot() int
+
+	// RevertToSnapshot removes all the transactions committed since the given
+	// snapshot was taken, along with their effects on the pending state.
+	RevertToSnapshot(id int)
+}
+
+// TransactionSelector decides which transactions are included into the blocks
+// assembled by the miner, and in what order.
+type TransactionSelector interface {
+	// Select fills the block being assembled with transactions, drawing on the
+	// executable transactions of the pool (grouped by account, nonce sorted).
+	Select(env TxEnvironment, pending map[common.Address]types.Transactions)
+}
+
+// PriceSelector is the default transaction selector, filling the block greedily
+// with the highest priced pool transactions, respecting the account nonces.
+type PriceSelector struct{}
+
+// Select implements TransactionSelector.
+func (PriceSelector) Select(env TxEnvironment, pending map[common.Address]types.Transactions) {
+	commitTransactionSet(env, types.NewTransactionsByPriceAndNonce(env.Signer(), pending))
+}
+
+// commitTransactionSet includes transactions from a price and nonce sorted set
+// into the block until the set is exhausted or the block becomes full.
+func commitTransactionSet(env TxEnvironment, txs *types.TransactionsByPriceAndNonce) {
+	for {
+		// Retrieve the next transaction and abort if all done
+		tx := txs.Peek()
+		if tx == nil {
+			break
+		}
+		// Error may be ignored here. The error has already been checked
+		// during transaction acceptance is the transaction pool.
+		//
+		// We use the eip155 signer regardless of the current hf.
+		from, _ := types.Sender(env.Signer(), tx)
+
+		switch err := env.Commit(tx); err {
+		case errReplayProtected:
+			// If we're not in the EIP155 hf phase, start ignoring the sender until we do
+			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash())
+			txs.Pop()
+
+		case core.ErrGasLimitReached:
+			// Pop the current out-of-gas transaction without shifting in the next from the account
+			log.Trace("Gas limit exceeded for current block", "sender", from)
+			txs.Pop()
+
+		case core.ErrNonceTooLow:
+			// New head notification data race between the transaction pool and miner, shift
+			log.Trace("Skipping transaction with low nonce", "sender", from, "nonce", tx.Nonce())
+			txs.Shift()
+
+		case core.ErrNonceTooHigh:
+			// Reorg notification data race between the transaction pool and miner, skip account =
+			log.Trace("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
+			txs.Pop()
+
+		case nil:
+			// Everything ok, shift in the next transaction from the same account
+			txs.Shift()
+
+		default:
+			// Strange error, discard the transaction and get the next in line (note, the
+			// nonce-too-high clause will prevent us from executing in vain).
+			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			txs.Shift()
+		}
+	}
+}
+
+// workSnapshot is the content of a block being assembled at a point in time.
+//
+// Note, the state is copied instead of using state snapshots, as the journal
+// of the pending state is flushed after every executed transaction.
+type workSnapshot struct {
+	state   *state.StateDB // Copy of the pending state
+	txs     int            // Number of included transactions
+	logs    int            // Number of logs generated
+	gasLeft *big.Int       // Gas still available in the block
+	gasUsed *big.Int       // Gas used by the included transactions
+}
+
+// workEnvironment is the TxEnvironment implementation on top of a mining work.
+type workEnvironment struct {
+	work     *Work
+	chain    *core.BlockChain
+	coinbase common.Address
+
+	logs  []*types.Log   // Logs generated by the included transactions
+	snaps []workSnapshot // Snapshots taken of the work so far
+}
+
+// newWorkEnvironment creates a transaction environment to fill the work with.
+func newWorkEnvironment(work *Work, chain *core.BlockChain, coinbase common.Address) *workEnvironment {
+	return &workEnvironment{
+		work:     work,
+		chain:    chain,
+		coinbase: coinbase,
+	}
+}
+
+func (env *workEnvironment) Header() *types.Header { return env.work.header }
+func (env *workEnvironment) State() *state.StateDB { return env.work.state }
+func (env *workEnvironment) Signer() types.Signer  { return env.work.signer }
+
+// Commit implements TxEnvironment, executing a transaction on top of the work.
+func (env *workEnvironment) Commit(tx *types.Transaction) error {
+	// Check whether the tx is replay protected. If we're not in the EIP155 hf
+	// phase, the transaction cannot be included yet.
+	if tx.Protected() && !env.work.config.IsEIP155(env.work.header.Number) {
+		return errReplayProtected
+	}
+	env.work.state.Prepare(tx.Hash(), common.Hash{}, env.work.tcount)
+
+	err, logs := env.work.commitTransaction(tx, env.chain, env.coinbase, env.work.gasPool)
+	if err != nil {
+		return err
+	}
+	env.logs = append(env.logs, logs...)
+	env.work.tcount++
+	return nil
+}
+
+// Snapshot implements TxEnvironment, saving the current content of the work.
+func (env *workEnvironment) Snapshot() int {
+	env.snaps = append(env.snaps, workSnapshot{
+		state:   env.work.state.Copy(),
+		txs:     len(env.work.txs),
+		logs:    len(env.logs),
+		gasLeft: new(big.Int).Set((*big.Int)(env.work.gasPool)),
+		gasUsed: new(big.Int).Set(env.work.header.GasUsed),
+	})
+	return len(env.snaps) - 1
+}
+
+// RevertToSnapshot implements TxEnvironment, restoring a previous content of the
+// work. All snapshots taken after the reverted one are invalidated.
+func (env *workEnvironment) RevertToSnapshot(id int) {
+	snap := env.snaps[id]
+	env.snaps = env.snaps[:id]
+
+	env.work.state = snap.state
+	env.work.txs = env.work.txs[:snap.txs]
+	env.work.receipts = env.work.receipts[:snap.txs]
+	env.work.tcount = snap.txs
+	env.logs = env.logs[:snap.logs]
+
+	(*big.Int)(env.work.gasPool).Set(snap.gasLeft)
+	env.work.header.GasUsed.Set(snap.gasUsed)
+}
+
+// post notifies the subsystems of the pending logs and state generated by the
+// transactions included into the work.
+func (env *workEnvironment) post(mux *event.TypeMux) {
+	if len(env.logs) == 0 && env.work.tcount == 0 {
+		return
+	}
+	// make a copy, the state caches the logs and these logs get "upgraded" from pending to mined
+	// logs by filling in the block hash when the block was mined by the local miner. This can
+	// cause a race condition if a log was "upgraded" before the PendingLogsEvent is processed.
+	cpy := make([]*types.Log, len(env.logs))
+	for i, l := range env.logs {
+		cpy[i] = new(types.Log)
+		*cpy[i] = *l
+	}
+	go func(logs []*types.Log, tcount int) {
+		if len(logs) > 0 {
+			mux.Post(core.PendingLogsEvent{Logs: logs})
+		}
+		if tcount > 0 {
+			mux.Post(core.PendingStateEvent{})
+		}
+	}(cpy, env.work.tcount)
+}
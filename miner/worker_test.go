@@ -0,0 +1,182 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/teamnsrg/ethereum-p2p/accounts"
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/consensus/ethash"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/types"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+	"github.com/teamnsrg/ethereum-p2p/ethdb"
+	"github.com/teamnsrg/ethereum-p2p/event"
+	"github.com/teamnsrg/ethereum-p2p/params"
+)
+
+// testBackend implements Backend with an in-memory chain and transaction pool.
+type testBackend struct {
+	db     ethdb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return nil }
+func (b *testBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool              { return b.txPool }
+func (b *testBackend) ChainDb() ethdb.Database           { return b.db }
+
+// skipSelector is a transaction selector excluding the transactions of a single
+// account, deferring to the price selector for the rest.
+type skipSelector struct {
+	skip common.Address
+}
+
+// Select implements TransactionSelector.
+func (s skipSelector) Select(env TxEnvironment, pending map[common.Address]types.Transactions) {
+	filtered := make(map[common.Address]types.Transactions)
+	for addr, txs := range pending {
+		if addr != s.skip {
+			filtered[addr] = txs
+		}
+	}
+	PriceSelector{}.Select(env, filtered)
+}
+
+// Tests that transactions arriving while not mining are included into the pending
+// block through the transaction selector.
+func TestPendingSelection(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	addrs := make([]common.Address, len(keys))
+	alloc := make(core.GenesisAlloc)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		_      = (&core.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(db)
+		engine = ethash.NewFaker()
+	)
+	chain, err := core.NewBlockChain(db, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	pool := core.NewTxPool(config, params.TestChainConfig, chain)
+	defer pool.Stop()
+
+	worker := newWorker(params.TestChainConfig, engine, common.Address{}, &testBackend{db, chain, pool}, new(event.TypeMux))
+	worker.setSelector(skipSelector{skip: addrs[0]})
+
+	// Add a transaction from both accounts and wait for the pending block
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	skipped, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[0])
+	selected, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[1])
+
+	for _, err := range pool.AddRemotes([]*types.Transaction{skipped, selected}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		block, _ := worker.pending()
+		if len(block.Transactions()) > 0 {
+			if len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != selected.Hash() {
+				t.Fatalf("pending transactions mismatch: have %d, want 1 [%x]", len(block.Transactions()), selected.Hash())
+			}
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("pending block not updated")
+		}
+	}
+}
+
+// Tests that a bundle failing midway through is reverted in its entirety on top
+// of a real mining work, leaving the pending block and state as if never tried.
+func TestPendingBundleRevert(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	addrs := make([]common.Address, len(keys))
+	alloc := make(core.GenesisAlloc)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		_      = (&core.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(db)
+		engine = ethash.NewFaker()
+	)
+	chain, err := core.NewBlockChain(db, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	pool := core.NewTxPool(config, params.TestChainConfig, chain)
+	defer pool.Stop()
+
+	worker := newWorker(params.TestChainConfig, engine, common.Address{}, &testBackend{db, chain, pool}, new(event.TypeMux))
+	selector := NewBundleSelector(PriceSelector{})
+	worker.setSelector(selector)
+
+	// Submit a bundle failing on its second transaction and a valid pool transaction
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	first, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[0])
+	gapped, _ := types.SignTx(types.NewTransaction(2, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[0])
+	if err := selector.Submit(&Bundle{Txs: types.Transactions{first, gapped}}); err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	valid, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, keys[1])
+	if err := pool.AddRemote(valid); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	worker.commitNewWork()
+
+	block, statedb := worker.pending()
+	if len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != valid.Hash() {
+		t.Fatalf("pending transactions mismatch: have %d, want 1 [%x]", len(block.Transactions()), valid.Hash())
+	}
+	if nonce := statedb.GetNonce(addrs[0]); nonce != 0 {
+		t.Errorf("bundle sender nonce mismatch: have %d, want %d", nonce, 0)
+	}
+	if nonce := statedb.GetNonce(addrs[1]); nonce != 1 {
+		t.Errorf("pool sender nonce mismatch: have %d, want %d", nonce, 1)
+	}
+	if root := statedb.IntermediateRoot(params.TestChainConfig.IsEIP158(block.Number())); root != block.Root() {
+		t.Errorf("pending state root mismatch: have %x, want %x", root, block.Root())
+	}
+	if selector.Pending() != 1 {
+		t.Errorf("failed bundle not retained: have %d, want %d", selector.Pending(), 1)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+)
+
+// TrustedCheckpoint is a block of a network which is trusted to be canonical.
+// Checkpoint synchronisation starts verifying headers from such a block on,
+// without requiring any of the history preceding it.
+type TrustedCheckpoint struct {
+	Number uint64      // Number of the checkpoint block
+	Hash   common.Hash // Hash of the checkpoint block
+	Td     *big.Int    // Total difficulty of the chain up to and including the checkpoint
+}
+
+// TrustedCheckpoints contains the checkpoints known for networks, keyed by the
+// hash of their genesis block. Networks not listed here need a checkpoint to be
+// explicitly configured to use checkpoint synchronisation.
+//
+// Every checkpoint must carry the total difficulty of its block, as the fork
+// choice of the synced chain is anchored on it.
+var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{}
+
+// String implements the fmt.Stringer interface, returning the checkpoint in the
+// same format accepted by ParseTrustedCheckpoint.
+func (c *TrustedCheckpoint) String() string {
+	return fmt.Sprintf("%d:%s:%v", c.Number, c.Hash.Hex(), c.Td)
+}
+
+// ParseTrustedCheckpoint parses a checkpoint in the "<number>:<hash>:<td>" format.
+func ParseTrustedCheckpoint(spec string) (*TrustedCheckpoint, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid checkpoint %q, want <number>:<hash>:<td>", spec)
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint number %q: %v", parts[0], err)
+	}
+	hash, err := hexutil.Decode(parts[1])
+	if err != nil || len(hash) != common.HashLength {
+		return nil, fmt.Errorf("invalid checkpoint hash %q", parts[1])
+	}
+	td, ok := new(big.Int).SetString(parts[2], 10)
+	if !ok || td.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid checkpoint total difficulty %q", parts[2])
+	}
+	return &TrustedCheckpoint{Number: number, Hash: common.BytesToHash(hash), Td: td}, nil
+}
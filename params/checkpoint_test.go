@@ -0,0 +1,60 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+)
+
+func TestParseTrustedCheckpoint(t *testing.T) {
+	want := &TrustedCheckpoint{
+		Number: 4370000,
+		Hash:   common.HexToHash("0xb1fcff633029ee18ab6482b58ff8b6e95dd7c82a954c852157152a7a6d32785e"),
+		Td:     big.NewInt(2302034208),
+	}
+	have, err := ParseTrustedCheckpoint(want.String())
+	if err != nil {
+		t.Fatalf("failed to parse checkpoint: %v", err)
+	}
+	if have.Number != want.Number || have.Hash != want.Hash || have.Td.Cmp(want.Td) != 0 {
+		t.Errorf("checkpoint mismatch: have %v, want %v", have, want)
+	}
+	for _, spec := range []string{
+		"",
+		"4370000",
+		"4370000:0xb1fcff633029ee18ab6482b58ff8b6e95dd7c82a954c852157152a7a6d32785e",
+		"number:0xb1fcff633029ee18ab6482b58ff8b6e95dd7c82a954c852157152a7a6d32785e:1",
+		"4370000:0xb1fc:1",
+		"4370000:0xb1fcff633029ee18ab6482b58ff8b6e95dd7c82a954c852157152a7a6d32785e:0",
+	} {
+		if _, err := ParseTrustedCheckpoint(spec); err == nil {
+			t.Errorf("invalid checkpoint %q parsed", spec)
+		}
+	}
+}
+
+// Tests that all the shipped checkpoints specify their total difficulty.
+func TestTrustedCheckpointsTd(t *testing.T) {
+	for genesis, checkpoint := range TrustedCheckpoints {
+		if checkpoint.Td == nil || checkpoint.Td.Sign() <= 0 {
+			t.Errorf("checkpoint #%d of network %x: missing total difficulty", checkpoint.Number, genesis)
+		}
+	}
+}
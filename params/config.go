@@ -82,16 +82,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -117,11 +117,22 @@ type ChainConfig struct {
 
 	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` // Byzantium switch block (nil = no fork, 0 = already on byzantium)
 
+	// Precompiles activates extra precompiled contracts (or replaces the default
+	// ones) at the given addresses.
+	Precompiles map[common.Address]*PrecompileConfig `json:"precompiles,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
 }
 
+// PrecompileConfig activates a precompiled contract registered in the EVM under
+// the given name, from the given block on.
+type PrecompileConfig struct {
+	Name  string   `json:"name"`            // Name of the registered precompiled contract
+	Block *big.Int `json:"block,omitempty"` // Activation block (nil = never)
+}
+
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
 type EthashConfig struct{}
 
@@ -251,6 +262,38 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	return c.checkPrecompilesCompatible(newcfg, head)
+}
+
+// checkPrecompilesCompatible checks whether the precompiled contracts activated
+// by the new config are compatible with the ones already active at head.
+func (c *ChainConfig) checkPrecompilesCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	addrs := make(map[common.Address]struct{})
+	for addr := range c.Precompiles {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range newcfg.Precompiles {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range addrs {
+		var (
+			stored, updated = c.Precompiles[addr], newcfg.Precompiles[addr]
+			s1, s2          *big.Int
+		)
+		if stored != nil {
+			s1 = stored.Block
+		}
+		if updated != nil {
+			s2 = updated.Block
+		}
+		what := fmt.Sprintf("precompile %x activation block", addr)
+		if isForkIncompatible(s1, s2, head) {
+			return newCompatError(what, s1, s2)
+		}
+		if isForked(s1, head) && stored.Name != updated.Name {
+			return newCompatError(fmt.Sprintf("precompile %x implementation", addr), s1, s2)
+		}
+	}
 	return nil
 }
 
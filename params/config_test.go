@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +72,34 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2F", Block: big.NewInt(10)}}},
+			new:     &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2F", Block: big.NewInt(20)}}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2F", Block: big.NewInt(10)}}},
+			new:    &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2F", Block: big.NewInt(20)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0000000000000000000000000000000000000009 activation block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2F", Block: big.NewInt(10)}}},
+			new:    &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "sha256", Block: big.NewInt(10)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0000000000000000000000000000000000000009 implementation",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
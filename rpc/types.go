@@ -23,6 +23,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
 	"gopkg.in/fatih/set.v0"
 )
@@ -163,3 +164,32 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
+
+// BlockNumberOrHash references a block either by its number (including the
+// "latest", "earliest" and "pending" tags) or by its hash. Exactly one of the
+// fields is set after unmarshalling.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber
+	BlockHash   *common.Hash
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash. A 32
+// byte hex string is interpreted as a block hash, anything else is parsed as a
+// BlockNumber.
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	input := strings.TrimSpace(string(data))
+	if len(input) == 2*common.HashLength+4 && input[0] == '"' && input[len(input)-1] == '"' {
+		var hash common.Hash
+		if err := hash.UnmarshalJSON([]byte(input)); err != nil {
+			return err
+		}
+		*bnh = BlockNumberOrHash{BlockHash: &hash}
+		return nil
+	}
+	var number BlockNumber
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*bnh = BlockNumberOrHash{BlockNumber: &number}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/teamnsrg/ethereum-p2p/common"
 	"github.com/teamnsrg/ethereum-p2p/common/math"
 )
 
@@ -64,3 +65,40 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHashJSONUnmarshal(t *testing.T) {
+	hash := common.HexToHash("0x1122334455667788990011223344556677889900112233445566778899001122")
+	tests := []struct {
+		input    string
+		mustFail bool
+		number   *BlockNumber
+		hash     *common.Hash
+	}{
+		0: {`"0x12"`, false, func() *BlockNumber { n := BlockNumber(18); return &n }(), nil},
+		1: {`"latest"`, false, func() *BlockNumber { n := LatestBlockNumber; return &n }(), nil},
+		2: {`"` + hash.Hex() + `"`, false, nil, &hash},
+		3: {`"0x` + hash.Hex()[3:] + `"`, true, nil, nil},
+		4: {`"0xzz22334455667788990011223344556677889900112233445566778899001122"`, true, nil, nil},
+		5: {`someString`, true, nil, nil},
+	}
+	for i, test := range tests {
+		var bnh BlockNumberOrHash
+		err := json.Unmarshal([]byte(test.input), &bnh)
+		if test.mustFail {
+			if err == nil {
+				t.Errorf("Test %d should fail", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d should pass but got err: %v", i, err)
+			continue
+		}
+		if (bnh.BlockNumber == nil) != (test.number == nil) || (test.number != nil && *bnh.BlockNumber != *test.number) {
+			t.Errorf("Test %d got unexpected number, want %v, got %v", i, test.number, bnh.BlockNumber)
+		}
+		if (bnh.BlockHash == nil) != (test.hash == nil) || (test.hash != nil && *bnh.BlockHash != *test.hash) {
+			t.Errorf("Test %d got unexpected hash, want %v, got %v", i, test.hash, bnh.BlockHash)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/common/math"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/state"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+// FillForks are the forks state tests are filled for by default, in the order
+// they were activated on the main network.
+var FillForks = []string{"Frontier", "Homestead", "EIP150", "EIP158", "Byzantium"}
+
+// StateFiller is the source of a General State Test: a pre-state, a transaction
+// with its data, gas and value variants, and the expected post conditions. The
+// post state roots of the filled test are generated by executing it.
+type StateFiller struct {
+	json sfJSON
+}
+
+type sfJSON struct {
+	Env    stEnv             `json:"env"`
+	Pre    core.GenesisAlloc `json:"pre"`
+	Tx     stTransaction     `json:"transaction"`
+	Expect []sfExpect        `json:"expect"`
+}
+
+// sfExpect is a set of post conditions expected for some transaction variants on
+// some forks. Indexes of -1 match any variant, an empty network any fork.
+type sfExpect struct {
+	Indexes struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+	Network []string                                     `json:"network"`
+	Result  map[common.UnprefixedAddress]sfExpectAccount `json:"result"`
+}
+
+// sfExpectAccount is the expected post state of an account. Unset fields are not
+// checked, storage slots not listed neither.
+type sfExpectAccount struct {
+	Balance        *math.HexOrDecimal256 `json:"balance"`
+	Nonce          *math.HexOrDecimal64  `json:"nonce"`
+	Code           *hexutil.Bytes        `json:"code"`
+	Storage        map[string]string     `json:"storage"`
+	ShouldNotExist bool                  `json:"shouldnotexist"`
+}
+
+func (f *StateFiller) UnmarshalJSON(in []byte) error {
+	return json.Unmarshal(in, &f.json)
+}
+
+func (f *StateFiller) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&f.json)
+}
+
+// Fill executes all the transaction variants of the filler on the given forks
+// (all the default ones if none given), checks the expectations against the post
+// states and assembles a state test from them.
+func (f *StateFiller) Fill(forks []string) (*StateTest, error) {
+	if len(forks) == 0 {
+		forks = FillForks
+	}
+	test := &StateTest{json: stJSON{
+		Env:  f.json.Env,
+		Pre:  f.json.Pre,
+		Tx:   f.json.Tx,
+		Post: make(map[string][]stPostState),
+	}}
+	for _, fork := range forks {
+		config, ok := Forks[fork]
+		if !ok {
+			return nil, UnsupportedForkError{fork}
+		}
+		for d := range f.json.Tx.Data {
+			for g := range f.json.Tx.GasLimit {
+				for v := range f.json.Tx.Value {
+					var post stPostState
+					post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value = d, g, v
+
+					statedb, result, err := test.json.apply(config, post, vm.Config{})
+					if err != nil {
+						return nil, fmt.Errorf("%s/d%d/g%d/v%d: %v", fork, d, g, v, err)
+					}
+					if err := f.check(fork, post, statedb); err != nil {
+						return nil, fmt.Errorf("%s/d%d/g%d/v%d: %v", fork, d, g, v, err)
+					}
+					post.Root, post.Logs = common.UnprefixedHash(result.Root), common.UnprefixedHash(result.Logs)
+					test.json.Post[fork] = append(test.json.Post[fork], post)
+				}
+			}
+		}
+	}
+	return test, nil
+}
+
+// check verifies the post state of a transaction variant on a fork against all
+// the expectations applying to it.
+func (f *StateFiller) check(fork string, post stPostState, statedb *state.StateDB) error {
+	for _, expect := range f.json.Expect {
+		if !expect.matches(fork, post) {
+			continue
+		}
+		for addr, account := range expect.Result {
+			if err := account.check(common.Address(addr), statedb); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matches returns whether the expectation applies to a transaction variant on a
+// fork. Networks may be fork names, or ">=" and "<" prefixed ones selecting the
+// default forks activated since or before them.
+func (e *sfExpect) matches(fork string, post stPostState) bool {
+	if (e.Indexes.Data >= 0 && e.Indexes.Data != post.Indexes.Data) ||
+		(e.Indexes.Gas >= 0 && e.Indexes.Gas != post.Indexes.Gas) ||
+		(e.Indexes.Value >= 0 && e.Indexes.Value != post.Indexes.Value) {
+		return false
+	}
+	if len(e.Network) == 0 {
+		return true
+	}
+	for _, network := range e.Network {
+		switch {
+		case network == "ALL" || network == fork:
+			return true
+		case strings.HasPrefix(network, ">="):
+			if from, at := forkIndex(network[2:]), forkIndex(fork); from >= 0 && at >= from {
+				return true
+			}
+		case strings.HasPrefix(network, "<"):
+			if until, at := forkIndex(network[1:]), forkIndex(fork); at >= 0 && at < until {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// forkIndex returns the position of a fork among the default ones, or -1.
+func forkIndex(fork string) int {
+	for i, name := range FillForks {
+		if name == fork {
+			return i
+		}
+	}
+	return -1
+}
+
+// check verifies the post state of an account against the expectation.
+func (e *sfExpectAccount) check(addr common.Address, statedb *state.StateDB) error {
+	if e.ShouldNotExist {
+		if statedb.Exist(addr) {
+			return fmt.Errorf("account %x: exists, want non-existent", addr)
+		}
+		return nil
+	}
+	if e.Balance != nil {
+		if have := statedb.GetBalance(addr); have.Cmp((*big.Int)(e.Balance)) != 0 {
+			return fmt.Errorf("account %x: balance mismatch: have %v, want %v", addr, have, (*big.Int)(e.Balance))
+		}
+	}
+	if e.Nonce != nil {
+		if have := statedb.GetNonce(addr); have != uint64(*e.Nonce) {
+			return fmt.Errorf("account %x: nonce mismatch: have %d, want %d", addr, have, uint64(*e.Nonce))
+		}
+	}
+	if e.Code != nil {
+		if have := statedb.GetCode(addr); string(have) != string(*e.Code) {
+			return fmt.Errorf("account %x: code mismatch: have %x, want %x", addr, have, []byte(*e.Code))
+		}
+	}
+	for key, val := range e.Storage {
+		k, ok := math.ParseBig256(key)
+		if !ok {
+			return fmt.Errorf("account %x: invalid storage key %q", addr, key)
+		}
+		v, ok := math.ParseBig256(val)
+		if !ok {
+			return fmt.Errorf("account %x: invalid storage value %q", addr, val)
+		}
+		if have := statedb.GetState(addr, common.BigToHash(k)); have != common.BigToHash(v) {
+			return fmt.Errorf("account %x: storage %x mismatch: have %x, want %x", addr, common.BigToHash(k), have, common.BigToHash(v))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+)
+
+// testFiller calls a contract storing the call value in slot 0, then reverting
+// on Byzantium (an invalid opcode before).
+const testFiller = `{
+	"env": {
+		"currentCoinbase": "2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+		"currentDifficulty": "0x020000",
+		"currentGasLimit": "0x7fffffffffffffff",
+		"currentNumber": "1",
+		"currentTimestamp": "1000"
+	},
+	"pre": {
+		"0x1000000000000000000000000000000000000001": {
+			"balance": "0",
+			"code": "0x3460005560016000fd"
+		},
+		"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+			"balance": "1000000000000"
+		}
+	},
+	"transaction": {
+		"data": ["0x"],
+		"gasLimit": ["100000"],
+		"gasPrice": "0x01",
+		"nonce": "0x00",
+		"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+		"to": "0x1000000000000000000000000000000000000001",
+		"value": ["0", "5"]
+	},
+	"expect": [{
+		"indexes": {"data": -1, "gas": -1, "value": -1},
+		"network": ["ALL"],
+		"result": {
+			"1000000000000000000000000000000000000001": {"nonce": "0", "storage": {"0x00": "0x00"}}
+		}
+	}]
+}`
+
+// Tests that state test fillers are filled with post states that pass as tests,
+// and that expectations are enforced.
+func TestStateFiller(t *testing.T) {
+	var filler StateFiller
+	if err := json.Unmarshal([]byte(testFiller), &filler); err != nil {
+		t.Fatalf("failed to decode filler: %v", err)
+	}
+	test, err := filler.Fill([]string{"EIP158", "Byzantium"})
+	if err != nil {
+		t.Fatalf("failed to fill test: %v", err)
+	}
+	// Round trip the filled test and ensure all its subtests pass
+	blob, err := json.Marshal(test)
+	if err != nil {
+		t.Fatalf("failed to encode filled test: %v", err)
+	}
+	var filled StateTest
+	if err := json.Unmarshal(blob, &filled); err != nil {
+		t.Fatalf("failed to decode filled test: %v", err)
+	}
+	if subtests := filled.Subtests(); len(subtests) != 4 {
+		t.Fatalf("subtest count mismatch: have %d, want %d", len(subtests), 4)
+	}
+	for _, subtest := range filled.Subtests() {
+		if _, _, err := filled.Run(subtest, vm.Config{}); err != nil {
+			t.Errorf("%s/%d: filled test failed: %v", subtest.Fork, subtest.Index, err)
+		}
+	}
+	// Ensure unmet expectations are reported
+	contract := common.UnprefixedAddress(common.HexToAddress("0x1000000000000000000000000000000000000001"))
+	filler.json.Expect[0].Result[contract].Storage["0x00"] = "0x01"
+	if _, err := filler.Fill([]string{"Byzantium"}); err == nil {
+		t.Errorf("unmet expectation not reported")
+	}
+}
+
+// Tests that fork divergences are detected, and that random fillers can be
+// executed on all forks.
+func TestStateFuzzer(t *testing.T) {
+	var filler StateFiller
+	if err := json.Unmarshal([]byte(testFiller), &filler); err != nil {
+		t.Fatalf("failed to decode filler: %v", err)
+	}
+	divergences, err := filler.DiffForks([]string{"EIP158", "EIP158"})
+	if err != nil || len(divergences) != 0 {
+		t.Fatalf("same fork diverged: %v, %v", divergences, err)
+	}
+	if divergences, err = filler.DiffForks([]string{"EIP158", "Byzantium"}); err != nil {
+		t.Fatalf("failed to diff forks: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, d := range divergences {
+		fields[d.Field] = true
+	}
+	if !fields["gas"] || !fields["root"] {
+		t.Errorf("REVERT divergence not detected: %v", divergences)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		if _, err := RandomStateFiller(r).DiffForks(FillForks); err != nil {
+			t.Fatalf("case %d: failed to diff forks: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	"github.com/teamnsrg/ethereum-p2p/common"
+	"github.com/teamnsrg/ethereum-p2p/common/hexutil"
+	"github.com/teamnsrg/ethereum-p2p/core"
+	"github.com/teamnsrg/ethereum-p2p/core/vm"
+	"github.com/teamnsrg/ethereum-p2p/crypto"
+)
+
+var (
+	// fuzzKey is the key of the account sending the fuzzed transactions.
+	fuzzKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	// fuzzContracts are the addresses of the contracts with fuzzed code.
+	fuzzContracts = []common.Address{
+		common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		common.HexToAddress("0x1000000000000000000000000000000000000002"),
+		common.HexToAddress("0x1000000000000000000000000000000000000003"),
+	}
+
+	// fuzzOpcodes are the opcodes defined by the EVM, used to generate code.
+	fuzzOpcodes []vm.OpCode
+)
+
+func init() {
+	for i := 0; i < 256; i++ {
+		if op := vm.OpCode(i); !strings.HasPrefix(op.String(), "Missing opcode") {
+			fuzzOpcodes = append(fuzzOpcodes, op)
+		}
+	}
+}
+
+// RandomStateFiller generates a state filler with a few contracts of random code
+// and storage in the pre-state, and a single transaction calling into one of them
+// (or creating a contract from random init code) with random data and value.
+func RandomStateFiller(r *rand.Rand) *StateFiller {
+	sender := crypto.PubkeyToAddress(fuzzKey.PublicKey)
+
+	pre := core.GenesisAlloc{
+		sender: {Balance: big.NewInt(1000000000000000000)},
+	}
+	for _, addr := range fuzzContracts {
+		account := core.GenesisAccount{
+			Code:    randomCode(r, 1+r.Intn(256)),
+			Balance: big.NewInt(r.Int63n(1000000)),
+			Storage: make(map[common.Hash]common.Hash),
+		}
+		for i := r.Intn(4); i > 0; i-- {
+			account.Storage[common.BigToHash(big.NewInt(r.Int63n(8)))] = common.BigToHash(big.NewInt(r.Int63()))
+		}
+		pre[addr] = account
+	}
+	data := make([]byte, r.Intn(68))
+	r.Read(data)
+
+	to := fuzzContracts[r.Intn(len(fuzzContracts))].Hex()
+	if r.Intn(4) == 0 {
+		to, data = "", randomCode(r, 1+r.Intn(128))
+	}
+	return &StateFiller{json: sfJSON{
+		Env: stEnv{
+			Coinbase:   common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba"),
+			Difficulty: big.NewInt(0x20000),
+			GasLimit:   big.NewInt(10000000),
+			Number:     1,
+			Timestamp:  1000,
+		},
+		Pre: pre,
+		Tx: stTransaction{
+			GasPrice:   big.NewInt(1),
+			To:         to,
+			Data:       []string{hexutil.Encode(data)},
+			GasLimit:   []uint64{uint64(100000 + r.Intn(900000))},
+			Value:      []string{hexutil.EncodeBig(big.NewInt(r.Int63n(1000)))},
+			PrivateKey: crypto.FromECDSA(fuzzKey),
+		},
+	}}
+}
+
+// randomCode generates random EVM bytecode of about the given length, biased
+// towards pushing small values and the fuzzed contract addresses, so that the
+// instructions have a chance to execute instead of underflowing the stack.
+func randomCode(r *rand.Rand, n int) []byte {
+	var code []byte
+	for len(code) < n {
+		switch r.Intn(10) {
+		case 0, 1, 2, 3:
+			code = append(code, byte(vm.PUSH1), byte(r.Intn(32)))
+		case 4:
+			code = append(code, byte(vm.PUSH20))
+			code = append(code, fuzzContracts[r.Intn(len(fuzzContracts))].Bytes()...)
+		default:
+			op := fuzzOpcodes[r.Intn(len(fuzzOpcodes))]
+			code = append(code, byte(op))
+			if op.IsPush() {
+				push := make([]byte, int(op-vm.PUSH1)+1)
+				r.Read(push)
+				code = append(code, push...)
+			}
+		}
+	}
+	return code
+}
+
+// StateDivergence is a difference between the outcomes of the same transaction
+// executed with two different fork configurations.
+type StateDivergence struct {
+	Fork  string // Fork whose outcome diverged from the reference fork's
+	Field string // Outcome that differs (root, logs, output, gas or error)
+	Have  string // Outcome on the diverging fork
+	Want  string // Outcome on the reference fork
+}
+
+func (d *StateDivergence) String() string {
+	return fmt.Sprintf("%s: %s mismatch: have %s, want %s", d.Fork, d.Field, d.Have, d.Want)
+}
+
+// DiffForks executes the first transaction variant of the filler with each of
+// the given forks, reporting the differences of the outcomes from the first one.
+func (f *StateFiller) DiffForks(forks []string) ([]*StateDivergence, error) {
+	test := &stJSON{Env: f.json.Env, Pre: f.json.Pre, Tx: f.json.Tx}
+
+	var (
+		reference   *StateResult
+		divergences []*StateDivergence
+	)
+	for _, fork := range forks {
+		config, ok := Forks[fork]
+		if !ok {
+			return nil, UnsupportedForkError{fork}
+		}
+		_, result, err := test.apply(config, stPostState{}, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fork, err)
+		}
+		if reference == nil {
+			reference = result
+			continue
+		}
+		diff := func(field string, have, want interface{}) {
+			if h, w := fmt.Sprint(have), fmt.Sprint(want); h != w {
+				divergences = append(divergences, &StateDivergence{Fork: fork, Field: field, Have: h, Want: w})
+			}
+		}
+		diff("root", result.Root.Hex(), reference.Root.Hex())
+		diff("logs", result.Logs.Hex(), reference.Logs.Hex())
+		diff("output", hexutil.Encode(result.Output), hexutil.Encode(reference.Output))
+		diff("gas", result.GasUsed, reference.GasUsed)
+		diff("error", result.Err, reference.Err)
+	}
+	return divergences, nil
+}
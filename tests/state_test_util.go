@@ -53,6 +53,10 @@ func (t *StateTest) UnmarshalJSON(in []byte) error {
 	return json.Unmarshal(in, &t.json)
 }
 
+func (t *StateTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&t.json)
+}
+
 type stJSON struct {
 	Env  stEnv                    `json:"env"`
 	Pre  core.GenesisAlloc        `json:"pre"`
@@ -68,7 +72,7 @@ type stPostState struct {
 		Data  int `json:"data"`
 		Gas   int `json:"gas"`
 		Value int `json:"value"`
-	}
+	} `json:"indexes"`
 }
 
 //go:generate gencodec -type stEnv -field-override stEnvMarshaling -out gen_stenv.go
@@ -119,39 +123,72 @@ func (t *StateTest) Subtests() []StateSubtest {
 	return sub
 }
 
-// Run executes a specific subtest.
-func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateDB, error) {
+// StateResult is the outcome of executing the transaction of a state subtest.
+type StateResult struct {
+	Root    common.Hash // Post state root after executing the transaction
+	Logs    common.Hash // Hash of the logs emitted by the transaction
+	Output  []byte      // Return data of the transaction
+	GasUsed uint64      // Gas used by the transaction, including the intrinsic gas
+	Err     error       // Reason the transaction was rejected, if any
+}
+
+// Run executes a specific subtest and verifies the post state. The outcome of
+// the transaction is returned even if the verification fails.
+func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateDB, *StateResult, error) {
+	statedb, result, err := t.execute(subtest, vmconfig)
+	if err != nil {
+		return statedb, result, err
+	}
+	post := t.json.Post[subtest.Fork][subtest.Index]
+	if result.Logs != common.Hash(post.Logs) {
+		return statedb, result, fmt.Errorf("post state logs hash mismatch: got %x, want %x", result.Logs, post.Logs)
+	}
+	if result.Root != common.Hash(post.Root) {
+		return statedb, result, fmt.Errorf("post state root mismatch: got %x, want %x", result.Root, post.Root)
+	}
+	return statedb, result, nil
+}
+
+// execute runs the transaction of a specific subtest without checking the post
+// state, returning the outcome of the transaction.
+func (t *StateTest) execute(subtest StateSubtest, vmconfig vm.Config) (*state.StateDB, *StateResult, error) {
 	config, ok := Forks[subtest.Fork]
 	if !ok {
-		return nil, UnsupportedForkError{subtest.Fork}
+		return nil, nil, UnsupportedForkError{subtest.Fork}
 	}
+	return t.json.apply(config, t.json.Post[subtest.Fork][subtest.Index], vmconfig)
+}
+
+// apply executes the transaction variant selected by the indexes of the post
+// state on top of the pre-state, with the given chain configuration.
+func (t *stJSON) apply(config *params.ChainConfig, post stPostState, vmconfig vm.Config) (*state.StateDB, *StateResult, error) {
 	block, _ := t.genesis(config).ToBlock()
 	db, _ := ethdb.NewMemDatabase()
-	statedb := makePreState(db, t.json.Pre)
+	statedb := makePreState(db, t.Pre)
 
-	post := t.json.Post[subtest.Fork][subtest.Index]
-	msg, err := t.json.Tx.toMessage(post)
+	msg, err := t.Tx.toMessage(post)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	context := core.NewEVMContext(msg, block.Header(), nil, &t.json.Env.Coinbase)
+	context := core.NewEVMContext(msg, block.Header(), nil, &t.Env.Coinbase)
 	context.GetHash = vmTestBlockHash
 	evm := vm.NewEVM(context, statedb, config, vmconfig)
 
 	gaspool := new(core.GasPool)
 	gaspool.AddGas(block.GasLimit())
 	snapshot := statedb.Snapshot()
-	if _, _, _, err := core.ApplyMessage(evm, msg, gaspool); err != nil {
+
+	result := new(StateResult)
+	ret, gasUsed, _, err := core.ApplyMessage(evm, msg, gaspool)
+	if err != nil {
 		statedb.RevertToSnapshot(snapshot)
+		result.Err = err
+	} else {
+		result.Output, result.GasUsed = ret, gasUsed.Uint64()
 	}
-	if logs := rlpHash(statedb.Logs()); logs != common.Hash(post.Logs) {
-		return statedb, fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
-	}
-	root, _ := statedb.CommitTo(db, config.IsEIP158(block.Number()))
-	if root != common.Hash(post.Root) {
-		return statedb, fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
-	}
-	return statedb, nil
+	result.Logs = rlpHash(statedb.Logs())
+	result.Root, _ = statedb.CommitTo(db, config.IsEIP158(block.Number()))
+	return statedb, result, nil
 }
 
 func (t *StateTest) gasLimit(subtest StateSubtest) uint64 {
@@ -175,15 +212,15 @@ func makePreState(db ethdb.Database, accounts core.GenesisAlloc) *state.StateDB
 	return statedb
 }
 
-func (t *StateTest) genesis(config *params.ChainConfig) *core.Genesis {
+func (t *stJSON) genesis(config *params.ChainConfig) *core.Genesis {
 	return &core.Genesis{
 		Config:     config,
-		Coinbase:   t.json.Env.Coinbase,
-		Difficulty: t.json.Env.Difficulty,
-		GasLimit:   t.json.Env.GasLimit.Uint64(),
-		Number:     t.json.Env.Number,
-		Timestamp:  t.json.Env.Timestamp,
-		Alloc:      t.json.Pre,
+		Coinbase:   t.Env.Coinbase,
+		Difficulty: t.Env.Difficulty,
+		GasLimit:   t.Env.GasLimit.Uint64(),
+		Number:     t.Env.Number,
+		Timestamp:  t.Env.Timestamp,
+		Alloc:      t.Pre,
 	}
 }
 